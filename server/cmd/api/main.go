@@ -1,11 +1,27 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/A-Words/ne-resource-community/server/internal/bounty"
 	"github.com/A-Words/ne-resource-community/server/internal/config"
 	"github.com/A-Words/ne-resource-community/server/internal/database"
+	"github.com/A-Words/ne-resource-community/server/internal/digest"
 	httpserver "github.com/A-Words/ne-resource-community/server/internal/http"
+	"github.com/A-Words/ne-resource-community/server/internal/http/handlers"
+	"github.com/A-Words/ne-resource-community/server/internal/linkcheck"
+	"github.com/A-Words/ne-resource-community/server/internal/mail"
+	"github.com/A-Words/ne-resource-community/server/internal/moderation"
+	"github.com/A-Words/ne-resource-community/server/internal/realtime"
+	"github.com/A-Words/ne-resource-community/server/internal/scheduler"
+	"github.com/A-Words/ne-resource-community/server/internal/webhook"
 )
 
 func main() {
@@ -15,9 +31,47 @@ func main() {
 	if err := database.AutoMigrate(db); err != nil {
 		log.Fatalf("migrations failed: %v", err)
 	}
+	if err := handlers.NewAuthHandler(db, cfg).SealTOTPSecrets(); err != nil {
+		log.Fatalf("seal totp secrets: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Background jobs
+	checker := linkcheck.NewChecker(db,
+		linkcheck.NewClient(linkcheck.ClientOptions{Timeout: cfg.LinkCheckTimeout, MaxRedirects: 5}),
+		linkcheck.CheckerOptions{
+			Concurrency:  cfg.LinkCheckConcurrency,
+			BrokenAfter:  cfg.LinkBrokenAfter,
+			RecheckAfter: cfg.LinkCheckInterval / 2, // links probed by the previous run are due again
+		})
+	go scheduler.Every(ctx, "link-check", cfg.LinkCheckInterval, checker.Run)
+	go scheduler.Every(ctx, "moderation-escalation", 10*time.Minute, moderation.Escalator(db, cfg.ModerationSLA))
+	go scheduler.Every(ctx, "request-expiry", 15*time.Minute, bounty.Expirer(db, cfg.RequestExpiry))
+	go scheduler.Every(ctx, "digest", time.Hour, digest.Runner(db, mail.NewSender(cfg), cfg))
+	webhookClient := linkcheck.NewClient(linkcheck.ClientOptions{Timeout: cfg.WebhookTimeout})
+	go scheduler.Every(ctx, "mirror", 15*time.Second, handlers.NewResourceHandler(db, cfg).RunMirrors)
+	go scheduler.Every(ctx, "webhook-delivery", 30*time.Second, webhook.Dispatcher(db, webhookClient, cfg.WebhookMaxAttempts))
+	go scheduler.Every(ctx, "webhook-prune", 24*time.Hour, webhook.Pruner(db, cfg.WebhookRetention))
+
+	// Real-time events travel through PostgreSQL so every replica sees them.
+	hub := realtime.NewHub()
+	go realtime.Listen(ctx, cfg.DatabaseDSN, hub)
+
+	r := httpserver.NewRouter(db, cfg, hub)
+	srv := &http.Server{Addr: cfg.Addr, Handler: r}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("server stopped: %v", err)
+		}
+	}()
 
-	r := httpserver.NewRouter(db, cfg)
-	if err := r.Run(cfg.Addr); err != nil {
-		log.Fatalf("server stopped: %v", err)
+	<-ctx.Done()
+	hub.Close()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("shutdown: %v", err)
 	}
 }
@@ -3,10 +3,14 @@ package main
 import (
 	"flag"
 	"log"
+	"os/user"
 
+	"github.com/A-Words/ne-resource-community/server/internal/audit"
 	"github.com/A-Words/ne-resource-community/server/internal/config"
 	"github.com/A-Words/ne-resource-community/server/internal/database"
 	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/A-Words/ne-resource-community/server/internal/session"
+	"gorm.io/gorm"
 )
 
 func main() {
@@ -20,12 +24,35 @@ func main() {
 	cfg := config.Load()
 	db := database.New(cfg.DatabaseDSN)
 
-	var user models.User
-	if err := db.Where("email = ?", *email).First(&user).Error; err != nil {
+	var target models.User
+	if err := db.Where("email = ?", *email).First(&target).Error; err != nil {
 		log.Fatalf("User with email %s not found: %v", *email, err)
 	}
 
-	if err := db.Model(&user).Update("role", "admin").Error; err != nil {
+	actor := "cli:promote_admin"
+	if u, err := user.Current(); err == nil {
+		actor += " (" + u.Username + ")"
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		before := map[string]string{"role": target.Role}
+		if err := tx.Model(&target).Update("role", models.RoleAdmin).Error; err != nil {
+			return err
+		}
+		// Make the user log in again so the new role is in every token.
+		if err := session.Invalidate(tx, target.ID); err != nil {
+			return err
+		}
+		return audit.Record(tx, audit.Entry{
+			Actor:      actor,
+			Action:     audit.ActionUserRoleChange,
+			TargetType: "user",
+			TargetID:   target.ID.String(),
+			Before:     before,
+			After:      map[string]string{"role": models.RoleAdmin},
+		})
+	})
+	if err != nil {
 		log.Fatalf("Failed to promote user: %v", err)
 	}
 
@@ -0,0 +1,152 @@
+package apitoken
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Prefix marks personal access tokens so they are easy to tell from JWTs and
+// to find with secret scanners.
+const Prefix = "nerc_"
+
+// Scopes.
+const (
+	ScopeRead   = "read"   // read endpoints of the owner's account
+	ScopeUpload = "upload" // create and mirror resources
+	ScopeReview = "review" // moderation queue, subject to the owner's role
+	ScopeAdmin  = "admin"  // admin-only endpoints, subject to the owner's role
+)
+
+// MaxPerUser caps live tokens per account.
+const MaxPerUser = 50
+
+// lastUsedGranularity limits last-used writes to one per token per minute.
+const lastUsedGranularity = time.Minute
+
+var (
+	ErrInvalid      = errors.New("invalid or expired api token")
+	ErrUnknownScope = errors.New("unknown scope")
+	ErrTooMany      = fmt.Errorf("at most %d active tokens per user", MaxPerUser)
+)
+
+var validScopes = map[string]bool{ScopeRead: true, ScopeUpload: true, ScopeReview: true, ScopeAdmin: true}
+
+// Issue creates a token for userID and returns it together with the raw
+// secret, which is not stored and cannot be shown again.
+func Issue(db *gorm.DB, userID uuid.UUID, name string, scopes []string, expiresAt *time.Time) (models.APIToken, string, error) {
+	normalized, err := normalizeScopes(scopes)
+	if err != nil {
+		return models.APIToken{}, "", err
+	}
+
+	var active int64
+	if err := db.Model(&models.APIToken{}).
+		Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", userID, time.Now()).
+		Count(&active).Error; err != nil {
+		return models.APIToken{}, "", err
+	}
+	if active >= MaxPerUser {
+		return models.APIToken{}, "", ErrTooMany
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return models.APIToken{}, "", err
+	}
+	raw := Prefix + base64.RawURLEncoding.EncodeToString(buf)
+	token := models.APIToken{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    raw[:len(Prefix)+6],
+		TokenHash: hash(raw),
+		Scopes:    strings.Join(normalized, ","),
+		ExpiresAt: expiresAt,
+	}
+	if err := db.Create(&token).Error; err != nil {
+		return models.APIToken{}, "", err
+	}
+	return token, raw, nil
+}
+
+// Authenticate resolves a raw token and records its use.
+func Authenticate(db *gorm.DB, raw, ip string) (models.APIToken, error) {
+	var token models.APIToken
+	now := time.Now()
+	err := db.Where("token_hash = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", hash(raw), now).
+		First(&token).Error
+	if err != nil {
+		return models.APIToken{}, ErrInvalid
+	}
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > lastUsedGranularity || token.LastUsedIP != ip {
+		db.Model(&token).Updates(map[string]interface{}{"last_used_at": now, "last_used_ip": ip})
+	}
+	return token, nil
+}
+
+// Revoke revokes one token of userID.
+func Revoke(db *gorm.DB, userID, tokenID uuid.UUID) (bool, error) {
+	res := db.Model(&models.APIToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", tokenID, userID).
+		Update("revoked_at", time.Now())
+	return res.RowsAffected > 0, res.Error
+}
+
+// RevokeAll revokes every live token of userID. Pass the transaction that
+// changes the account's credentials.
+func RevokeAll(tx *gorm.DB, userID uuid.UUID) error {
+	return tx.Model(&models.APIToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// Has reports whether scopes grant want. The admin scope implies review,
+// since admin routes sit below the moderation ones.
+func Has(scopes []string, want string) bool {
+	for _, s := range scopes {
+		if s == want || (s == ScopeAdmin && want == ScopeReview) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsToken reports whether a bearer credential looks like a personal token.
+func IsToken(bearer string) bool {
+	return strings.HasPrefix(bearer, Prefix)
+}
+
+func normalizeScopes(scopes []string) ([]string, error) {
+	seen := map[string]bool{}
+	var out []string
+	for _, s := range scopes {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if !validScopes[s] {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownScope, s)
+		}
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrUnknownScope)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func hash(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,82 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Actions recorded in the audit log.
+const (
+	ActionResourceApprove      = "resource.approve"
+	ActionResourceReject       = "resource.reject"
+	ActionResourceMirror       = "resource.mirror"
+	ActionResourceAutoHide     = "resource.auto_hide"
+	ActionReportResolve        = "report.resolve"
+	ActionUserRoleChange       = "user.role_change"
+	ActionUserSuspend          = "user.suspend"
+	ActionUserUnsuspend        = "user.unsuspend"
+	ActionUserPasswordReset    = "user.password_reset"
+	ActionUserMerge            = "user.merge"
+	ActionUserIdentityLink     = "user.identity_link"
+	ActionPointsReconcile      = "points.reconcile"
+	ActionRequestCancel        = "request.cancel"
+	ActionRequestCommentDelete = "request.comment_delete"
+	ActionWebhookCreate        = "webhook.create"
+	ActionWebhookUpdate        = "webhook.update"
+	ActionWebhookDelete        = "webhook.delete"
+	ActionWebhookRedeliver     = "webhook.redeliver"
+)
+
+// Entry describes a privileged action to be recorded.
+type Entry struct {
+	ActorID    uuid.UUID // uuid.Nil for CLI tools
+	Actor      string
+	Action     string
+	TargetType string
+	TargetID   string
+	Before     interface{} // marshalled to JSON; nil is stored as empty
+	After      interface{}
+	IP         string
+}
+
+// Record appends e to the audit log. Pass the transaction that performs the
+// action so the entry commits or rolls back with it.
+func Record(db *gorm.DB, e Entry) error {
+	before, err := marshal(e.Before)
+	if err != nil {
+		return fmt.Errorf("audit before: %w", err)
+	}
+	after, err := marshal(e.After)
+	if err != nil {
+		return fmt.Errorf("audit after: %w", err)
+	}
+
+	log := models.AuditLog{
+		Actor:      e.Actor,
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		Before:     before,
+		After:      after,
+		IP:         e.IP,
+	}
+	if e.ActorID != uuid.Nil {
+		log.ActorID = &e.ActorID
+	}
+	return db.Create(&log).Error
+}
+
+func marshal(v interface{}) (string, error) {
+	if v == nil {
+		return "", nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,119 @@
+package authn
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/A-Words/ne-resource-community/server/internal/audit"
+	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/A-Words/ne-resource-community/server/internal/session"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// IdentityError is ErrInvalidCredentials for a login that resolved to an
+// external identity, such as a directory user name, so the failure can count
+// towards the lockout of the linked local account.
+type IdentityError struct {
+	Provider string
+	Subject  string
+}
+
+func (e *IdentityError) Error() string { return ErrInvalidCredentials.Error() }
+func (e *IdentityError) Unwrap() error { return ErrInvalidCredentials }
+
+// Authenticator verifies a login name and password and returns the local user.
+type Authenticator interface {
+	Authenticate(ctx context.Context, login, password string) (models.User, error)
+}
+
+// New builds the authenticator chain configured in AUTH_BACKENDS.
+func New(db *gorm.DB, cfg config.Config) Authenticator {
+	var chain Chain
+	for _, name := range cfg.AuthBackends {
+		switch strings.ToLower(name) {
+		case "local":
+			chain = append(chain, Local{DB: db})
+		case "ldap":
+			chain = append(chain, NewLDAP(db, cfg, nil))
+		default:
+			log.Fatalf("unknown auth backend %q", name)
+		}
+	}
+	if len(chain) == 1 {
+		return chain[0]
+	}
+	return chain
+}
+
+// Chain tries each authenticator in order and returns the first success. A
+// backend that is unreachable does not stop the others from being tried.
+// When all fail, an IdentityError from any backend is returned.
+type Chain []Authenticator
+
+func (c Chain) Authenticate(ctx context.Context, login, password string) (models.User, error) {
+	failed := ErrInvalidCredentials
+	for _, a := range c {
+		user, err := a.Authenticate(ctx, login, password)
+		if err == nil || errors.Is(err, ErrLinkRequired) {
+			return user, err
+		}
+		var identity *IdentityError
+		if errors.As(err, &identity) {
+			failed = err
+		} else if !errors.Is(err, ErrInvalidCredentials) {
+			log.Printf("auth backend %T: %v", a, err)
+		}
+	}
+	return models.User{}, failed
+}
+
+// Local checks the bcrypt hash stored on the user row.
+type Local struct {
+	DB *gorm.DB
+}
+
+func (l Local) Authenticate(ctx context.Context, login, password string) (models.User, error) {
+	var user models.User
+	if err := l.DB.WithContext(ctx).Where("email = ?", login).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return user, ErrInvalidCredentials
+		}
+		return user, err
+	}
+	if !user.CheckPassword(password) {
+		return models.User{}, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// SyncRole sets user's role to the one mapped from an external directory.
+// Only accounts the directory created through link follow it; roles of
+// local accounts linked later stay under admin control. Changing it signs
+// the user out everywhere and is recorded in the audit log with actor as the
+// responsible system.
+func SyncRole(tx *gorm.DB, link models.UserIdentity, user *models.User, role, actor string, groups []string) error {
+	if !link.Provisioned || role == user.Role {
+		return nil
+	}
+	before := user.Role
+	if err := tx.Model(user).Update("role", role).Error; err != nil {
+		return err
+	}
+	if err := session.Invalidate(tx, user.ID); err != nil {
+		return err
+	}
+	user.TokenVersion++
+	return audit.Record(tx, audit.Entry{
+		Actor:      actor,
+		Action:     audit.ActionUserRoleChange,
+		TargetType: "user",
+		TargetID:   user.ID.String(),
+		Before:     map[string]interface{}{"role": before},
+		After:      map[string]interface{}{"role": role, "groups": groups},
+	})
+}
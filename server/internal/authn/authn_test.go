@@ -0,0 +1,47 @@
+package authn
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/google/uuid"
+)
+
+type stubAuth struct {
+	user models.User
+	err  error
+}
+
+func (s stubAuth) Authenticate(context.Context, string, string) (models.User, error) {
+	return s.user, s.err
+}
+
+func TestChain(t *testing.T) {
+	alice := models.User{ID: uuid.New(), Email: "alice@example.com"}
+	identity := &IdentityError{Provider: LDAPProvider, Subject: "uid=alice,dc=corp"}
+	cases := []struct {
+		name     string
+		chain    Chain
+		wantUser uuid.UUID
+		wantErr  error
+	}{
+		{name: "first success wins", chain: Chain{stubAuth{err: ErrInvalidCredentials}, stubAuth{user: alice}}, wantUser: alice.ID},
+		{name: "backend down falls through", chain: Chain{stubAuth{err: errors.New("dial: refused")}, stubAuth{user: alice}}, wantUser: alice.ID},
+		{name: "all fail", chain: Chain{stubAuth{err: errors.New("dial: refused")}, stubAuth{err: ErrInvalidCredentials}}, wantErr: ErrInvalidCredentials},
+		{name: "identity failure is kept", chain: Chain{stubAuth{err: identity}, stubAuth{err: ErrInvalidCredentials}}, wantErr: identity},
+		{name: "link required stops", chain: Chain{stubAuth{err: ErrLinkRequired}, stubAuth{user: alice}}, wantErr: ErrLinkRequired},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			user, err := tc.chain.Authenticate(context.Background(), "alice", "pw")
+			if !errors.Is(err, tc.wantErr) || (tc.wantErr == nil && err != nil) {
+				t.Fatalf("err = %v, want %v", err, tc.wantErr)
+			}
+			if user.ID != tc.wantUser {
+				t.Errorf("user = %s, want %s", user.ID, tc.wantUser)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package authn
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/go-ldap/ldap/v3"
+	"gorm.io/gorm"
+)
+
+// LDAPProvider is the UserIdentity provider name for directory accounts.
+const LDAPProvider = "ldap"
+
+const (
+	ldapDialTimeout    = 10 * time.Second
+	ldapRequestTimeout = 10 * time.Second
+)
+
+// ErrLinkRequired is returned when a directory login matches an existing
+// local account that may not be linked automatically. An admin has to link
+// the identity to the account first.
+var ErrLinkRequired = errors.New("a local account with this email already exists; ask an administrator to link it")
+
+// Conn is the subset of an LDAP connection the authenticator uses.
+type Conn interface {
+	Bind(username, password string) error
+	Search(req *ldap.SearchRequest) (*ldap.SearchResult, error)
+	Close() error
+}
+
+// Dialer opens a directory connection.
+type Dialer func(ctx context.Context) (Conn, error)
+
+// LDAP authenticates by binding as the user found under the base DN. Users
+// are provisioned on first login and their role follows group membership.
+type LDAP struct {
+	db   *gorm.DB
+	cfg  config.Config
+	dial Dialer
+}
+
+// NewLDAP returns an LDAP authenticator. dial may be nil to connect to LDAP_URL.
+func NewLDAP(db *gorm.DB, cfg config.Config, dial Dialer) *LDAP {
+	l := &LDAP{db: db, cfg: cfg, dial: dial}
+	if l.dial == nil {
+		l.dial = l.dialURL
+	}
+	return l
+}
+
+// dialURL connects to LDAP_URL. The TCP and TLS handshakes honour ctx and
+// ldapDialTimeout; later requests time out after ldapRequestTimeout.
+func (l *LDAP) dialURL(ctx context.Context) (Conn, error) {
+	u, err := url.Parse(l.cfg.LDAPURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse LDAP_URL: %w", err)
+	}
+	isTLS := u.Scheme == "ldaps"
+	port := u.Port()
+	if port == "" {
+		port = "389"
+		if isTLS {
+			port = "636"
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, ldapDialTimeout)
+	defer cancel()
+	raw, err := (&net.Dialer{}).DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return nil, err
+	}
+	if isTLS {
+		tc := tls.Client(raw, &tls.Config{ServerName: u.Hostname()})
+		if err := tc.HandshakeContext(ctx); err != nil {
+			raw.Close()
+			return nil, fmt.Errorf("tls handshake: %w", err)
+		}
+		raw = tc
+	}
+	conn := ldap.NewConn(raw, isTLS)
+	conn.Start()
+	conn.SetTimeout(ldapRequestTimeout)
+	if l.cfg.LDAPStartTLS && !isTLS {
+		if err := conn.StartTLS(&tls.Config{ServerName: u.Hostname()}); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("starttls: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+func (l *LDAP) Authenticate(ctx context.Context, login, password string) (models.User, error) {
+	// An empty password would be an anonymous bind, which always succeeds.
+	if login == "" || password == "" {
+		return models.User{}, ErrInvalidCredentials
+	}
+	conn, err := l.dial(ctx)
+	if err != nil {
+		return models.User{}, fmt.Errorf("ldap dial: %w", err)
+	}
+	defer conn.Close()
+	// Abandon the directory when the client goes away.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if l.cfg.LDAPBindDN != "" {
+		if err := conn.Bind(l.cfg.LDAPBindDN, l.cfg.LDAPBindPassword); err != nil {
+			return models.User{}, fmt.Errorf("ldap service bind: %w", err)
+		}
+	}
+	filter := strings.ReplaceAll(l.cfg.LDAPUserFilter, "%s", ldap.EscapeFilter(login))
+	res, err := conn.Search(ldap.NewSearchRequest(
+		l.cfg.LDAPBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, 10, false,
+		filter, []string{"mail", "displayName", "cn", "memberOf"}, nil,
+	))
+	if err != nil {
+		return models.User{}, fmt.Errorf("ldap search: %w", err)
+	}
+	if len(res.Entries) != 1 {
+		return models.User{}, ErrInvalidCredentials
+	}
+	entry := res.Entries[0]
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return models.User{}, &IdentityError{Provider: LDAPProvider, Subject: strings.ToLower(entry.DN)}
+		}
+		return models.User{}, fmt.Errorf("ldap user bind: %w", err)
+	}
+
+	name := entry.GetAttributeValue("displayName")
+	if name == "" {
+		name = entry.GetAttributeValue("cn")
+	}
+	return l.provision(ctx, entry.DN, entry.GetAttributeValue("mail"), name, entry.GetAttributeValues("memberOf"))
+}
+
+// provision finds or creates the local user for a directory entry and applies
+// the group role mapping. An existing account with the same email is only
+// linked when LDAP_LINK_BY_EMAIL allows it and the account is not staff;
+// otherwise the login is refused with ErrLinkRequired.
+func (l *LDAP) provision(ctx context.Context, dn, email, name string, groups []string) (models.User, error) {
+	subject := strings.ToLower(dn)
+	var user models.User
+	err := l.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var link models.UserIdentity
+		err := tx.Where("provider = ? AND subject = ?", LDAPProvider, subject).First(&link).Error
+		switch {
+		case err == nil:
+			if err := tx.First(&user, "id = ?", link.UserID).Error; err != nil {
+				return err
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if email == "" {
+				return errors.New("directory entry has no mail attribute")
+			}
+			err := tx.Where("LOWER(email) = ?", strings.ToLower(email)).First(&user).Error
+			provisioned := false
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				now := time.Now()
+				user = models.User{Email: email, DisplayName: name, Role: models.RoleUser, EmailVerifiedAt: &now}
+				if err := tx.Create(&user).Error; err != nil {
+					return err
+				}
+				provisioned = true
+			case err != nil:
+				return err
+			case !l.cfg.LDAPLinkByEmail || user.Role != models.RoleUser:
+				log.Printf("ldap: refusing to link %q to existing account %s", subject, user.ID)
+				return ErrLinkRequired
+			}
+			link = models.UserIdentity{UserID: user.ID, Provider: LDAPProvider, Subject: subject, Email: email, Provisioned: provisioned}
+			if err := tx.Create(&link).Error; err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+		if err := tx.Model(&link).Update("last_login_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		role, mapped := l.mapRole(groups)
+		if !mapped {
+			return nil
+		}
+		return SyncRole(tx, link, &user, role, "ldap", groups)
+	})
+	return user, err
+}
+
+// mapRole derives the role from memberOf values. Configured groups match
+// either the full DN or the group's CN.
+func (l *LDAP) mapRole(groups []string) (string, bool) {
+	if len(l.cfg.LDAPAdminGroups) == 0 && len(l.cfg.LDAPModeratorGroups) == 0 {
+		return "", false
+	}
+	switch {
+	case memberOf(groups, l.cfg.LDAPAdminGroups):
+		return models.RoleAdmin, true
+	case memberOf(groups, l.cfg.LDAPModeratorGroups):
+		return models.RoleModerator, true
+	default:
+		return models.RoleUser, true
+	}
+}
+
+func memberOf(groups, wanted []string) bool {
+	for _, g := range groups {
+		cn := g
+		if dn, err := ldap.ParseDN(g); err == nil && len(dn.RDNs) > 0 && len(dn.RDNs[0].Attributes) > 0 {
+			cn = dn.RDNs[0].Attributes[0].Value
+		}
+		for _, w := range wanted {
+			if strings.EqualFold(w, g) || strings.EqualFold(w, cn) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,271 @@
+package authn
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/database/dbtest"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const serviceDN = "cn=svc,dc=corp"
+
+type fakeEntry struct {
+	dn       string
+	password string
+	mail     string
+	name     string
+	groups   []string
+}
+
+// fakeDir is an in-memory directory reached through a fake Dialer. Users are
+// found with the filter "(uid=%s)".
+type fakeDir struct {
+	users   map[string]fakeEntry // by uid
+	dialErr error
+	dials   atomic.Int32
+	closes  atomic.Int32
+}
+
+func (d *fakeDir) dial(ctx context.Context) (Conn, error) {
+	d.dials.Add(1)
+	if d.dialErr != nil {
+		return nil, d.dialErr
+	}
+	return &fakeConn{dir: d}, nil
+}
+
+type fakeConn struct {
+	dir *fakeDir
+}
+
+func (c *fakeConn) Bind(dn, password string) error {
+	if dn == serviceDN && password == "svc-pass" {
+		return nil
+	}
+	for _, e := range c.dir.users {
+		if e.dn == dn && e.password == password {
+			return nil
+		}
+	}
+	return ldap.NewError(ldap.LDAPResultInvalidCredentials, errors.New("invalid credentials"))
+}
+
+func (c *fakeConn) Search(req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	uid := strings.TrimSuffix(strings.TrimPrefix(req.Filter, "(uid="), ")")
+	e, ok := c.dir.users[uid]
+	if !ok {
+		return &ldap.SearchResult{}, nil
+	}
+	return &ldap.SearchResult{Entries: []*ldap.Entry{ldap.NewEntry(e.dn, map[string][]string{
+		"mail":        {e.mail},
+		"displayName": {e.name},
+		"memberOf":    e.groups,
+	})}}, nil
+}
+
+func (c *fakeConn) Close() error {
+	c.dir.closes.Add(1)
+	return nil
+}
+
+func testLDAPConfig() config.Config {
+	return config.Config{
+		LDAPBindDN:          serviceDN,
+		LDAPBindPassword:    "svc-pass",
+		LDAPBaseDN:          "dc=corp",
+		LDAPUserFilter:      "(uid=%s)",
+		LDAPAdminGroups:     []string{"ne-admins"},
+		LDAPModeratorGroups: []string{"cn=ne-reviewers,ou=groups,dc=corp"},
+	}
+}
+
+func newFakeDir(entries ...fakeEntry) *fakeDir {
+	d := &fakeDir{users: map[string]fakeEntry{}}
+	for _, e := range entries {
+		uid := strings.TrimPrefix(strings.SplitN(e.dn, ",", 2)[0], "uid=")
+		d.users[uid] = e
+	}
+	return d
+}
+
+func TestLDAPRejectsBeforeProvisioning(t *testing.T) {
+	alice := fakeEntry{dn: "uid=alice,DC=corp", password: "secret", mail: "alice@corp.example"}
+	cases := []struct {
+		name     string
+		cfg      func(*config.Config)
+		dir      *fakeDir
+		login    string
+		password string
+		wantCred bool // ErrInvalidCredentials rather than a backend error
+		// wantSubject is set when the failure names a directory entry whose
+		// linked account should count it towards lockout.
+		wantSubject string
+		noDial      bool
+	}{
+		{name: "wrong password", dir: newFakeDir(alice), login: "alice", password: "nope", wantCred: true, wantSubject: "uid=alice,dc=corp"},
+		{name: "unknown user", dir: newFakeDir(alice), login: "bob", password: "secret", wantCred: true},
+		{name: "empty password", dir: newFakeDir(alice), login: "alice", password: "", wantCred: true, noDial: true},
+		{name: "service bind fails", cfg: func(c *config.Config) { c.LDAPBindPassword = "wrong" }, dir: newFakeDir(alice), login: "alice", password: "secret"},
+		{name: "directory down", dir: &fakeDir{dialErr: errors.New("connection refused")}, login: "alice", password: "secret"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := testLDAPConfig()
+			if tc.cfg != nil {
+				tc.cfg(&cfg)
+			}
+			// No database: every case fails before the account is touched.
+			l := NewLDAP(nil, cfg, tc.dir.dial)
+			_, err := l.Authenticate(context.Background(), tc.login, tc.password)
+			if err == nil {
+				t.Fatal("Authenticate succeeded")
+			}
+			if got := errors.Is(err, ErrInvalidCredentials); got != tc.wantCred {
+				t.Errorf("err = %v, want invalid credentials: %v", err, tc.wantCred)
+			}
+			var identity *IdentityError
+			if got := errors.As(err, &identity); got != (tc.wantSubject != "") {
+				t.Errorf("err = %#v, want identity error: %v", err, tc.wantSubject != "")
+			} else if got && (identity.Provider != LDAPProvider || identity.Subject != tc.wantSubject) {
+				t.Errorf("identity = %+v, want subject %q", identity, tc.wantSubject)
+			}
+			if dialed := tc.dir.dials.Load() > 0; dialed == tc.noDial {
+				t.Errorf("dialed = %v", dialed)
+			}
+			if tc.dir.dialErr == nil && tc.dir.closes.Load() != tc.dir.dials.Load() {
+				t.Errorf("%d connections opened, %d closed", tc.dir.dials.Load(), tc.dir.closes.Load())
+			}
+		})
+	}
+}
+
+func TestLDAPMapRole(t *testing.T) {
+	l := NewLDAP(nil, testLDAPConfig(), nil)
+	cases := []struct {
+		groups []string
+		want   string
+	}{
+		{[]string{"cn=NE-Admins,ou=groups,dc=corp"}, models.RoleAdmin},
+		{[]string{"ne-admins"}, models.RoleAdmin},
+		{[]string{"cn=ne-reviewers,ou=groups,dc=corp"}, models.RoleModerator},
+		{[]string{"cn=ne-reviewers,ou=other,dc=corp"}, models.RoleUser}, // DN configured, so the full DN must match
+		{[]string{"cn=staff,ou=groups,dc=corp"}, models.RoleUser},
+		{nil, models.RoleUser},
+	}
+	for _, tc := range cases {
+		if got, ok := l.mapRole(tc.groups); !ok || got != tc.want {
+			t.Errorf("mapRole(%v) = %q, %v; want %q", tc.groups, got, ok, tc.want)
+		}
+	}
+	if _, ok := NewLDAP(nil, config.Config{}, nil).mapRole([]string{"ne-admins"}); ok {
+		t.Error("mapRole without group mapping reported ok")
+	}
+}
+
+func uniqueMail(name string) string {
+	return name + "-" + uuid.NewString()[:8] + "@corp.example"
+}
+
+func identityOf(t *testing.T, db *gorm.DB, dn string) models.UserIdentity {
+	t.Helper()
+	var link models.UserIdentity
+	if err := db.Where("provider = ? AND subject = ?", LDAPProvider, strings.ToLower(dn)).First(&link).Error; err != nil {
+		t.Fatalf("load identity: %v", err)
+	}
+	return link
+}
+
+func TestLDAPFirstLoginProvisions(t *testing.T) {
+	db := dbtest.Tx(t)
+	entry := fakeEntry{dn: "uid=carol,dc=corp", password: "secret", mail: uniqueMail("carol"), name: "Carol", groups: []string{"cn=ne-admins,ou=groups,dc=corp"}}
+	dir := newFakeDir(entry)
+	l := NewLDAP(db, testLDAPConfig(), dir.dial)
+
+	user, err := l.Authenticate(context.Background(), "carol", "secret")
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if user.Email != entry.mail || user.DisplayName != "Carol" || user.Role != models.RoleAdmin || user.EmailVerifiedAt == nil {
+		t.Errorf("provisioned user = %+v", user)
+	}
+	if link := identityOf(t, db, entry.dn); link.UserID != user.ID || !link.Provisioned {
+		t.Errorf("identity = %+v", link)
+	}
+
+	// Leaving the group demotes the directory-created account.
+	entry.groups = nil
+	dir.users["carol"] = entry
+	again, err := l.Authenticate(context.Background(), "carol", "secret")
+	if err != nil {
+		t.Fatalf("second login: %v", err)
+	}
+	if again.ID != user.ID || again.Role != models.RoleUser {
+		t.Errorf("second login = %s role %s, want %s role user", again.ID, again.Role, user.ID)
+	}
+}
+
+func TestLDAPEmailCollision(t *testing.T) {
+	cases := []struct {
+		name      string
+		linkEmail bool
+		role      string
+		wantLink  bool
+	}{
+		{name: "linking disabled", linkEmail: false, role: models.RoleUser},
+		{name: "local admin", linkEmail: true, role: models.RoleAdmin},
+		{name: "local moderator", linkEmail: true, role: models.RoleModerator},
+		{name: "linking enabled", linkEmail: true, role: models.RoleUser, wantLink: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := dbtest.Tx(t)
+			mail := uniqueMail("dave")
+			local := models.User{Email: mail, DisplayName: "Dave", Role: tc.role}
+			if err := local.SetPassword("local-pass"); err != nil {
+				t.Fatal(err)
+			}
+			if err := db.Create(&local).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			cfg := testLDAPConfig()
+			cfg.LDAPLinkByEmail = tc.linkEmail
+			entry := fakeEntry{dn: "uid=dave,dc=corp", password: "secret", mail: strings.ToUpper(mail), groups: []string{"ne-admins"}}
+			l := NewLDAP(db, cfg, newFakeDir(entry).dial)
+
+			user, err := l.Authenticate(context.Background(), "dave", "secret")
+			if !tc.wantLink {
+				if !errors.Is(err, ErrLinkRequired) {
+					t.Fatalf("err = %v, want ErrLinkRequired", err)
+				}
+				var n int64
+				db.Model(&models.UserIdentity{}).Where("user_id = ?", local.ID).Count(&n)
+				if n != 0 {
+					t.Errorf("%d identities linked", n)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate: %v", err)
+			}
+			if user.ID != local.ID {
+				t.Errorf("linked to %s, want %s", user.ID, local.ID)
+			}
+			// Linked accounts keep their local role despite the admin group.
+			if user.Role != tc.role {
+				t.Errorf("role = %s, want %s", user.Role, tc.role)
+			}
+			if link := identityOf(t, db, entry.dn); link.Provisioned {
+				t.Error("linked identity marked as provisioned")
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package authn
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Lockout locks accounts after repeated failed logins. Each failure past the
+// threshold doubles the lock, up to a maximum.
+type Lockout struct {
+	db        *gorm.DB
+	threshold int
+	base      time.Duration
+	max       time.Duration
+}
+
+func NewLockout(db *gorm.DB, cfg config.Config) *Lockout {
+	return &Lockout{db: db, threshold: cfg.LoginLockoutThreshold, base: cfg.LoginLockoutBase, max: cfg.LoginLockoutMax}
+}
+
+// Locked returns how long the account behind login stays locked, if at all.
+func (l *Lockout) Locked(ctx context.Context, login string) (time.Duration, bool) {
+	return l.locked(l.db.WithContext(ctx).Where("email = ?", login))
+}
+
+// LockedIdentity is Locked for the account linked to an external identity.
+func (l *Lockout) LockedIdentity(ctx context.Context, id *IdentityError) (time.Duration, bool) {
+	return l.locked(l.db.WithContext(ctx).Where("id IN (?)", identityUser(l.db, id)))
+}
+
+// LockedUser is Locked for a user that is already loaded.
+func (l *Lockout) LockedUser(user models.User) (time.Duration, bool) {
+	if l.threshold <= 0 {
+		return 0, false
+	}
+	return lockRemaining(user, time.Now())
+}
+
+// FailLogin records a failed password for the account behind login. Unknown
+// logins are ignored.
+func (l *Lockout) FailLogin(ctx context.Context, login string) error {
+	return l.fail(ctx, l.db.WithContext(ctx).Where("email = ?", login))
+}
+
+// FailIdentity records a failed password for the account linked to an
+// external identity. Identities without an account are ignored.
+func (l *Lockout) FailIdentity(ctx context.Context, id *IdentityError) error {
+	return l.fail(ctx, l.db.WithContext(ctx).Where("id IN (?)", identityUser(l.db, id)))
+}
+
+// Fail records a failed second factor for userID.
+func (l *Lockout) Fail(ctx context.Context, userID uuid.UUID) error {
+	return l.fail(ctx, l.db.WithContext(ctx).Where("id = ?", userID))
+}
+
+// Reset clears the failure count after a complete login.
+func (l *Lockout) Reset(ctx context.Context, userID uuid.UUID) error {
+	return l.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND (failed_logins > 0 OR locked_until IS NOT NULL)", userID).
+		Updates(map[string]interface{}{"failed_logins": 0, "locked_until": nil}).Error
+}
+
+func (l *Lockout) locked(scope *gorm.DB) (time.Duration, bool) {
+	if l.threshold <= 0 {
+		return 0, false
+	}
+	var user models.User
+	if err := scope.Select("id", "locked_until").First(&user).Error; err != nil {
+		return 0, false
+	}
+	return lockRemaining(user, time.Now())
+}
+
+func (l *Lockout) fail(ctx context.Context, scope *gorm.DB) error {
+	if l.threshold <= 0 {
+		return nil
+	}
+	var users []models.User
+	if err := scope.Model(&users).Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}, {Name: "failed_logins"}}}).
+		UpdateColumn("failed_logins", gorm.Expr("failed_logins + 1")).Error; err != nil {
+		return fmt.Errorf("count failed login: %w", err)
+	}
+	for _, u := range users {
+		if u.FailedLogins < l.threshold {
+			continue
+		}
+		lock := l.max
+		if shift := u.FailedLogins - l.threshold; shift < 20 {
+			lock = min(l.base<<shift, l.max)
+		}
+		if err := l.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", u.ID).
+			UpdateColumn("locked_until", time.Now().Add(lock)).Error; err != nil {
+			return fmt.Errorf("lock account: %w", err)
+		}
+	}
+	return nil
+}
+
+func identityUser(db *gorm.DB, id *IdentityError) *gorm.DB {
+	return db.Model(&models.UserIdentity{}).Select("user_id").Where("provider = ? AND subject = ?", id.Provider, id.Subject)
+}
+
+func lockRemaining(user models.User, now time.Time) (time.Duration, bool) {
+	if user.LockedUntil == nil || !user.LockedUntil.After(now) {
+		return 0, false
+	}
+	return user.LockedUntil.Sub(now), true
+}
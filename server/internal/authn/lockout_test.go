@@ -0,0 +1,65 @@
+package authn
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/database/dbtest"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+)
+
+func TestLockedUser(t *testing.T) {
+	l := NewLockout(nil, config.Config{LoginLockoutThreshold: 3})
+	past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+	cases := []struct {
+		name   string
+		until  *time.Time
+		locked bool
+	}{
+		{"never locked", nil, false},
+		{"lock expired", &past, false},
+		{"locked", &future, true},
+	}
+	for _, tc := range cases {
+		wait, locked := l.LockedUser(models.User{LockedUntil: tc.until})
+		if locked != tc.locked || (locked && (wait <= 0 || wait > time.Hour)) {
+			t.Errorf("%s: LockedUser = %v, %v", tc.name, wait, locked)
+		}
+	}
+	if _, locked := NewLockout(nil, config.Config{}).LockedUser(models.User{LockedUntil: &future}); locked {
+		t.Error("lockout disabled but account reported locked")
+	}
+}
+
+func TestFailIdentityLocksLinkedAccount(t *testing.T) {
+	db := dbtest.Tx(t)
+	ctx := context.Background()
+	user := models.User{Email: uniqueMail("erin"), DisplayName: "Erin", Role: models.RoleUser}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	id := &IdentityError{Provider: LDAPProvider, Subject: "uid=erin,dc=corp"}
+	if err := db.Create(&models.UserIdentity{UserID: user.ID, Provider: id.Provider, Subject: id.Subject}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	l := NewLockout(db, config.Config{LoginLockoutThreshold: 2, LoginLockoutBase: time.Minute, LoginLockoutMax: time.Hour})
+	for i := 0; i < 2; i++ {
+		if _, locked := l.LockedIdentity(ctx, id); locked {
+			t.Fatalf("locked after %d failures", i)
+		}
+		if err := l.FailIdentity(ctx, id); err != nil {
+			t.Fatalf("FailIdentity: %v", err)
+		}
+	}
+	if _, locked := l.LockedIdentity(ctx, id); !locked {
+		t.Error("account not locked at the threshold")
+	}
+
+	unknown := &IdentityError{Provider: LDAPProvider, Subject: "uid=nobody,dc=corp"}
+	if err := l.FailIdentity(ctx, unknown); err != nil {
+		t.Errorf("FailIdentity for an unlinked identity: %v", err)
+	}
+}
@@ -0,0 +1,323 @@
+package bounty
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/events"
+	"github.com/A-Words/ne-resource-community/server/internal/matcher"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/A-Words/ne-resource-community/server/internal/points"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Request statuses.
+const (
+	StatusOpen      = "open"
+	StatusFulfilled = "fulfilled" // an answer was accepted and paid
+	StatusClosed    = "closed"    // withdrawn by the requester
+	StatusCancelled = "cancelled" // withdrawn by staff
+	StatusExpired   = "expired"
+)
+
+var (
+	ErrNotFound            = errors.New("request not found")
+	ErrNotOpen             = errors.New("request is no longer open")
+	ErrNotRequester        = errors.New("only the requester can do this")
+	ErrOwnRequest          = errors.New("cannot answer your own request")
+	ErrDuplicateAnswer     = errors.New("resource was already submitted to this request")
+	ErrAnswerNotFound      = errors.New("answer not found")
+	ErrResourceUnavailable = errors.New("resource is not available")
+	ErrNotUploader         = errors.New("only the uploader can submit this resource")
+	ErrNotApproved         = errors.New("the answer's resource has not been approved yet")
+)
+
+// ExpiresAt returns when a request created at t expires, or nil when requests
+// never expire.
+func ExpiresAt(t time.Time, ttl time.Duration) *time.Time {
+	if ttl <= 0 {
+		return nil
+	}
+	at := t.Add(ttl)
+	return &at
+}
+
+// lockOpen loads a request for update and makes sure it still accepts changes.
+func lockOpen(tx *gorm.DB, requestID uuid.UUID) (models.Request, error) {
+	var req models.Request
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&req, "id = ?", requestID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return req, ErrNotFound
+		}
+		return req, err
+	}
+	if req.Status != StatusOpen {
+		return req, ErrNotOpen
+	}
+	return req, nil
+}
+
+// Submit records resourceID as userID's answer to an open request and tells
+// the requester. Only the uploader may submit a resource. Pending resources
+// may be submitted but can only be accepted once approved.
+func Submit(tx *gorm.DB, requestID, userID, resourceID uuid.UUID, note string) (models.RequestAnswer, error) {
+	req, err := lockOpen(tx, requestID)
+	if err != nil {
+		return models.RequestAnswer{}, err
+	}
+	if req.UserID == userID {
+		return models.RequestAnswer{}, ErrOwnRequest
+	}
+	var resource models.Resource
+	if err := tx.First(&resource, "id = ?", resourceID).Error; err != nil {
+		return models.RequestAnswer{}, ErrResourceUnavailable
+	}
+	if resource.UploaderID != userID {
+		return models.RequestAnswer{}, ErrNotUploader
+	}
+	if resource.Status != "approved" && resource.Status != "pending" {
+		return models.RequestAnswer{}, ErrResourceUnavailable
+	}
+
+	var count int64
+	if err := tx.Model(&models.RequestAnswer{}).Where("request_id = ? AND resource_id = ?", requestID, resourceID).Count(&count).Error; err != nil {
+		return models.RequestAnswer{}, err
+	}
+	if count > 0 {
+		return models.RequestAnswer{}, ErrDuplicateAnswer
+	}
+
+	answer := models.RequestAnswer{RequestID: requestID, ResourceID: resourceID, UserID: userID, Note: note}
+	if err := tx.Create(&answer).Error; err != nil {
+		return models.RequestAnswer{}, err
+	}
+	// A suggestion for this pair has now been acted on.
+	if err := tx.Model(&models.RequestMatch{}).
+		Where("request_id = ? AND resource_id = ?", requestID, resourceID).
+		Update("status", matcher.StatusSubmitted).Error; err != nil {
+		return models.RequestAnswer{}, err
+	}
+	answer.Resource = resource
+	body := fmt.Sprintf("\"%s\" was submitted as an answer to your request \"%s\".", resource.Title, req.Title)
+	if err := events.Publish(tx, events.Event{
+		Type:       events.TypeRequestAnswered,
+		Recipients: []uuid.UUID{req.UserID},
+		Title:      "New answer to your request",
+		Body:       body,
+		RefType:    "request",
+		RefID:      req.ID,
+	}); err != nil {
+		return models.RequestAnswer{}, err
+	}
+	return answer, nil
+}
+
+// Accept marks answerID as the accepted answer of an open request owned by
+// actor and pays the escrowed bounty to the answerer.
+func Accept(tx *gorm.DB, requestID, answerID, actor uuid.UUID) (models.Request, error) {
+	req, err := lockOpen(tx, requestID)
+	if err != nil {
+		return req, err
+	}
+	if req.UserID != actor {
+		return req, ErrNotRequester
+	}
+	var answer models.RequestAnswer
+	if err := tx.Preload("Resource").First(&answer, "id = ? AND request_id = ?", answerID, requestID).Error; err != nil {
+		return req, ErrAnswerNotFound
+	}
+	if answer.Resource.Status != "approved" {
+		return req, ErrNotApproved
+	}
+
+	now := time.Now()
+	req.Status = StatusFulfilled
+	req.AcceptedAnswerID = &answer.ID
+	req.ClosedAt = &now
+	if err := tx.Model(&req).Select("status", "accepted_answer_id", "closed_at").Updates(&req).Error; err != nil {
+		return req, err
+	}
+	if req.Bounty > 0 {
+		if _, err := points.Apply(tx, points.Entry{
+			UserID:  answer.UserID,
+			Delta:   req.Bounty,
+			Reason:  points.ReasonBountyAward,
+			RefType: "request",
+			RefID:   req.ID,
+		}); err != nil {
+			return req, err
+		}
+	}
+	body := fmt.Sprintf("Your answer to \"%s\" was accepted and you received %d points.", req.Title, req.Bounty)
+	if err := events.Publish(tx, events.Event{
+		Type:       events.TypeRequestAccepted,
+		Recipients: []uuid.UUID{answer.UserID},
+		Title:      "Your answer was accepted",
+		Body:       body,
+		RefType:    "request",
+		RefID:      req.ID,
+	}); err != nil {
+		return req, err
+	}
+	return req, nil
+}
+
+// Close ends an open request without an accepted answer and refunds the
+// escrowed bounty to the requester and contributors. status is StatusClosed, StatusCancelled
+// or StatusExpired.
+func Close(tx *gorm.DB, requestID uuid.UUID, status string) (models.Request, error) {
+	req, err := lockOpen(tx, requestID)
+	if err != nil {
+		return req, err
+	}
+	now := time.Now()
+	req.Status = status
+	req.ClosedAt = &now
+	if err := tx.Model(&req).Select("status", "closed_at").Updates(&req).Error; err != nil {
+		return req, err
+	}
+	return req, refund(tx, req, status)
+}
+
+type share struct {
+	UserID uuid.UUID
+	Amount int
+}
+
+// refund returns every top-up to its contributor and the rest of the bounty
+// to the requester.
+func refund(tx *gorm.DB, req models.Request, status string) error {
+	var shares []share
+	if err := tx.Model(&models.BountyContribution{}).Select("user_id, SUM(amount) AS amount").
+		Where("request_id = ?", req.ID).Group("user_id").Scan(&shares).Error; err != nil {
+		return err
+	}
+	own := req.Bounty
+	for _, s := range shares {
+		own -= s.Amount
+	}
+	shares = append(shares, share{UserID: req.UserID, Amount: own})
+
+	for _, s := range shares {
+		if s.Amount <= 0 {
+			continue
+		}
+		if _, err := points.Apply(tx, points.Entry{
+			UserID:  s.UserID,
+			Delta:   s.Amount,
+			Reason:  points.ReasonBountyRefund,
+			RefType: "request",
+			RefID:   req.ID,
+			Note:    status,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TopUp moves amount points from userID into the bounty of an open request.
+func TopUp(tx *gorm.DB, requestID, userID uuid.UUID, amount int) (models.Request, error) {
+	req, err := lockOpen(tx, requestID)
+	if err != nil {
+		return req, err
+	}
+	if _, err := points.Apply(tx, points.Entry{
+		UserID:  userID,
+		Delta:   -amount,
+		Reason:  points.ReasonBountyEscrow,
+		RefType: "request",
+		RefID:   req.ID,
+	}); err != nil {
+		return req, err
+	}
+	// The requester's own top-ups are part of their own share.
+	if userID != req.UserID {
+		if err := tx.Create(&models.BountyContribution{RequestID: req.ID, UserID: userID, Amount: amount}).Error; err != nil {
+			return req, err
+		}
+	}
+	req.Bounty += amount
+	if err := tx.Model(&req).UpdateColumn("bounty", req.Bounty).Error; err != nil {
+		return req, err
+	}
+	if userID == req.UserID {
+		return req, nil
+	}
+	body := fmt.Sprintf("Someone added %d points to the bounty of your request \"%s\".", amount, req.Title)
+	if err := events.Publish(tx, events.Event{
+		Type:       events.TypeRequestBountyRaised,
+		Recipients: []uuid.UUID{req.UserID},
+		Title:      "Bounty raised",
+		Body:       body,
+		RefType:    "request",
+		RefID:      req.ID,
+	}); err != nil {
+		return req, err
+	}
+	return req, nil
+}
+
+// Withdraw closes actor's own open request and refunds its bounty.
+func Withdraw(tx *gorm.DB, requestID, actor uuid.UUID) (models.Request, error) {
+	var req models.Request
+	if err := tx.Select("id", "user_id").First(&req, "id = ?", requestID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return req, ErrNotFound
+		}
+		return req, err
+	}
+	if req.UserID != actor {
+		return req, ErrNotRequester
+	}
+	return Close(tx, requestID, StatusClosed)
+}
+
+// Expirer returns a job that closes open requests past their expiry and
+// refunds their bounties. Requests created without an expiry get one based
+// on ttl; a non-positive ttl only expires requests that already have one.
+func Expirer(db *gorm.DB, ttl time.Duration) func(context.Context) error {
+	return func(ctx context.Context) error {
+		db := db.WithContext(ctx)
+		if ttl > 0 {
+			if err := db.Model(&models.Request{}).
+				Where("status = ? AND expires_at IS NULL", StatusOpen).
+				Update("expires_at", gorm.Expr("created_at + make_interval(secs => ?)", ttl.Seconds())).Error; err != nil {
+				return fmt.Errorf("schedule expiry: %w", err)
+			}
+		}
+
+		var due []uuid.UUID
+		if err := db.Model(&models.Request{}).
+			Where("status = ? AND expires_at < ?", StatusOpen, time.Now()).
+			Pluck("id", &due).Error; err != nil {
+			return fmt.Errorf("load expired requests: %w", err)
+		}
+		for _, id := range due {
+			err := db.Transaction(func(tx *gorm.DB) error {
+				req, err := Close(tx, id, StatusExpired)
+				if err != nil {
+					return err
+				}
+				body := fmt.Sprintf("Your request \"%s\" expired without an accepted answer; its bounty was refunded.", req.Title)
+				return events.Publish(tx, events.Event{
+					Type:       events.TypeRequestClosed,
+					Recipients: []uuid.UUID{req.UserID},
+					Title:      "Request expired",
+					Body:       body,
+					RefType:    "request",
+					RefID:      req.ID,
+				})
+			})
+			// Someone may have accepted or closed it meanwhile.
+			if err != nil && !errors.Is(err, ErrNotOpen) {
+				return fmt.Errorf("expire request %s: %w", id, err)
+			}
+		}
+		return nil
+	}
+}
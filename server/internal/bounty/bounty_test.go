@@ -0,0 +1,44 @@
+package bounty
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/A-Words/ne-resource-community/server/internal/database/dbtest"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func newUser(t *testing.T, db *gorm.DB, name string) models.User {
+	t.Helper()
+	user := models.User{Email: name + "-" + uuid.NewString()[:8] + "@example.com", DisplayName: name, Role: models.RoleUser}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestSubmitRequiresUploader(t *testing.T) {
+	db := dbtest.Tx(t)
+	requester, uploader, other := newUser(t, db, "requester"), newUser(t, db, "uploader"), newUser(t, db, "other")
+	req := models.Request{Title: "Need a config", UserID: requester.ID, Status: StatusOpen}
+	if err := db.Create(&req).Error; err != nil {
+		t.Fatal(err)
+	}
+	res := models.Resource{Title: "Config", Type: "template", UploaderID: uploader.ID, Status: "approved"}
+	if err := db.Create(&res).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Submit(db, req.ID, other.ID, res.ID, ""); !errors.Is(err, ErrNotUploader) {
+		t.Fatalf("submit by other user: err = %v, want ErrNotUploader", err)
+	}
+	answer, err := Submit(db, req.ID, uploader.ID, res.ID, "")
+	if err != nil {
+		t.Fatalf("submit by uploader: %v", err)
+	}
+	if answer.UserID != uploader.ID || answer.ResourceID != res.ID {
+		t.Errorf("answer = %+v", answer)
+	}
+}
@@ -1,8 +1,12 @@
 package config
 
 import (
+	"encoding/json"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds server configuration loaded from environment variables.
@@ -13,6 +17,93 @@ type Config struct {
 	UploadDir   string
 	Env         string
 	ClamAVAddr  string
+
+	LinkCheckInterval    time.Duration
+	LinkCheckConcurrency int
+	LinkCheckTimeout     time.Duration
+	LinkBrokenAfter      int
+
+	MirrorMaxBytes     int64
+	MirrorTimeout      time.Duration
+	MirrorMaxRedirects int
+
+	ModerationSLA      time.Duration
+	ModerationClaimTTL time.Duration
+
+	ReportHideThreshold int
+
+	RequestExpiry time.Duration // open requests are refunded and expired after this; 0 keeps them open
+	MatchMinScore float64       // approved uploads scoring at least this against an open request are suggested
+
+	WebhookTimeout     time.Duration
+	WebhookMaxAttempts int           // deliveries are given up after this many failed attempts
+	WebhookRetention   time.Duration // finished deliveries are deleted after this; 0 keeps them
+
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	SMTPAddr             string
+	SMTPUsername         string
+	SMTPPassword         string
+	MailFrom             string
+	MailLogBodies        bool   // log full bodies when SMTP is unset; leaks account links
+	PublicBaseURL        string // frontend origin used in email links
+	RequireVerifiedEmail bool
+	Require2FAForStaff   bool   // admin routes refuse staff without TOTP enrolled
+	TOTPEncryptionKey    string // master key for TOTP secrets at rest; defaults to JWTSecret
+
+	OIDCProviders []OIDCProvider
+
+	RateLimitAPI  Rate // per client IP across the API
+	RateLimitAuth Rate // per client IP on login, registration and recovery
+	RateLimitUser Rate // per user on authenticated routes
+	// TrustedProxies lists the proxy addresses or CIDRs whose X-Forwarded-For
+	// header is believed. Empty means the peer address is the client.
+	TrustedProxies []string
+
+	LoginLockoutThreshold int // failed logins before the account locks
+	LoginLockoutBase      time.Duration
+	LoginLockoutMax       time.Duration
+
+	UploadDailyCount         int   // uploads per rolling 24h at level 1
+	UploadDailyCountPerLevel int   // extra uploads per level above 1
+	UploadDailyBytes         int64 // upload volume per rolling 24h at level 1
+	UploadDailyBytesPerLevel int64
+
+	AvatarMaxBytes int64
+
+	AuthBackends        []string // password backends tried in order: local, ldap
+	LDAPURL             string
+	LDAPStartTLS        bool
+	LDAPBindDN          string // service account used to look users up
+	LDAPBindPassword    string
+	LDAPBaseDN          string
+	LDAPUserFilter      string // %s is replaced by the escaped login name
+	LDAPAdminGroups     []string
+	LDAPModeratorGroups []string
+	LDAPLinkByEmail     bool // link directory logins to existing non-staff accounts with the same email
+}
+
+// Rate is a request budget such as "10/1m". Zero requests disables the limit.
+type Rate struct {
+	Requests int
+	Per      time.Duration
+}
+
+// OIDCProvider configures one OpenID Connect identity provider. Providers are
+// supplied as a JSON array in OIDC_PROVIDERS.
+type OIDCProvider struct {
+	Name            string   `json:"name"` // used in URLs, e.g. /api/auth/oidc/{name}/login
+	DisplayName     string   `json:"displayName"`
+	Issuer          string   `json:"issuer"`
+	ClientID        string   `json:"clientId"`
+	ClientSecret    string   `json:"clientSecret"`
+	RedirectURL     string   `json:"redirectUrl"` // must point at the callback route
+	Scopes          []string `json:"scopes"`
+	GroupsClaim     string   `json:"groupsClaim"` // defaults to "groups"
+	AdminGroups     []string `json:"adminGroups"`
+	ModeratorGroups []string `json:"moderatorGroups"`
+	LinkByEmail     bool     `json:"linkByEmail"` // link first logins to existing verified non-staff accounts with the same email
 }
 
 // Load builds Config with sensible defaults; environment variables can override them.
@@ -24,6 +115,73 @@ func Load() Config {
 		UploadDir:   getEnv("UPLOAD_DIR", "uploads"),
 		Env:         getEnv("ENV", "dev"),
 		ClamAVAddr:  getEnv("CLAMAV_ADDR", "tcp://localhost:3310"),
+
+		LinkCheckInterval:    getEnvDuration("LINK_CHECK_INTERVAL", 6*time.Hour),
+		LinkCheckConcurrency: getEnvInt("LINK_CHECK_CONCURRENCY", 8),
+		LinkCheckTimeout:     getEnvDuration("LINK_CHECK_TIMEOUT", 15*time.Second),
+		LinkBrokenAfter:      getEnvInt("LINK_BROKEN_AFTER", 3),
+
+		MirrorMaxBytes:     int64(getEnvInt("MIRROR_MAX_MB", 512)) << 20,
+		MirrorTimeout:      getEnvDuration("MIRROR_TIMEOUT", 10*time.Minute),
+		MirrorMaxRedirects: getEnvInt("MIRROR_MAX_REDIRECTS", 5),
+
+		ModerationSLA:      getEnvDuration("MODERATION_SLA", 48*time.Hour),
+		ModerationClaimTTL: getEnvDuration("MODERATION_CLAIM_TTL", 30*time.Minute),
+
+		ReportHideThreshold: getEnvInt("REPORT_HIDE_THRESHOLD", 3),
+
+		RequestExpiry: getEnvDuration("REQUEST_EXPIRY", 30*24*time.Hour),
+		MatchMinScore: getEnvFloat("MATCH_MIN_SCORE", 0.3),
+
+		WebhookTimeout:     getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		WebhookMaxAttempts: getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		WebhookRetention:   getEnvDuration("WEBHOOK_RETENTION", 30*24*time.Hour),
+
+		AccessTokenTTL:  getEnvDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL: getEnvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+
+		SMTPAddr:             getEnv("SMTP_ADDR", ""),
+		SMTPUsername:         getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:         getEnv("SMTP_PASSWORD", ""),
+		MailFrom:             getEnv("MAIL_FROM", "NE Resource Community <no-reply@localhost>"),
+		MailLogBodies:        getEnvBool("MAIL_LOG_BODIES", false),
+		PublicBaseURL:        strings.TrimSuffix(getEnv("PUBLIC_BASE_URL", "http://localhost:5173"), "/"),
+		RequireVerifiedEmail: getEnvBool("REQUIRE_VERIFIED_EMAIL", false),
+		Require2FAForStaff:   getEnvBool("REQUIRE_2FA_FOR_STAFF", false),
+		TOTPEncryptionKey:    getEnv("TOTP_ENCRYPTION_KEY", ""),
+
+		RateLimitAPI:   getEnvRate("RATE_LIMIT_API", Rate{300, time.Minute}),
+		RateLimitAuth:  getEnvRate("RATE_LIMIT_AUTH", Rate{10, time.Minute}),
+		RateLimitUser:  getEnvRate("RATE_LIMIT_USER", Rate{120, time.Minute}),
+		TrustedProxies: getEnvList("TRUSTED_PROXIES", nil),
+
+		LoginLockoutThreshold: getEnvInt("LOGIN_LOCKOUT_THRESHOLD", 5),
+		LoginLockoutBase:      getEnvDuration("LOGIN_LOCKOUT_BASE", time.Minute),
+		LoginLockoutMax:       getEnvDuration("LOGIN_LOCKOUT_MAX", 24*time.Hour),
+
+		UploadDailyCount:         getEnvInt("UPLOAD_DAILY_COUNT", 10),
+		UploadDailyCountPerLevel: getEnvInt("UPLOAD_DAILY_COUNT_PER_LEVEL", 5),
+		UploadDailyBytes:         int64(getEnvInt("UPLOAD_DAILY_MB", 200)) << 20,
+		UploadDailyBytesPerLevel: int64(getEnvInt("UPLOAD_DAILY_MB_PER_LEVEL", 100)) << 20,
+
+		AvatarMaxBytes: int64(getEnvInt("AVATAR_MAX_KB", 1024)) << 10,
+
+		AuthBackends:        getEnvList("AUTH_BACKENDS", []string{"local"}),
+		LDAPURL:             getEnv("LDAP_URL", ""),
+		LDAPStartTLS:        getEnvBool("LDAP_START_TLS", false),
+		LDAPBindDN:          getEnv("LDAP_BIND_DN", ""),
+		LDAPBindPassword:    getEnv("LDAP_BIND_PASSWORD", ""),
+		LDAPBaseDN:          getEnv("LDAP_BASE_DN", ""),
+		LDAPUserFilter:      getEnv("LDAP_USER_FILTER", "(&(objectClass=person)(|(sAMAccountName=%s)(mail=%s)))"),
+		LDAPAdminGroups:     getEnvList("LDAP_ADMIN_GROUPS", nil),
+		LDAPModeratorGroups: getEnvList("LDAP_MODERATOR_GROUPS", nil),
+		LDAPLinkByEmail:     getEnvBool("LDAP_LINK_BY_EMAIL", false),
+	}
+
+	if v := os.Getenv("OIDC_PROVIDERS"); v != "" {
+		if err := json.Unmarshal([]byte(v), &cfg.OIDCProviders); err != nil {
+			log.Fatalf("invalid OIDC_PROVIDERS: %v", err)
+		}
 	}
 
 	if err := os.MkdirAll(cfg.UploadDir, 0o755); err != nil {
@@ -38,3 +196,91 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getEnvInt(key string, fallback int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Fatalf("invalid %s: %v", key, err)
+	}
+	return n
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		log.Fatalf("invalid %s: %v", key, err)
+	}
+	return f
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Fatalf("invalid %s: %v", key, err)
+	}
+	return d
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Fatalf("invalid %s: %v", key, err)
+	}
+	return b
+}
+
+func getEnvRate(key string, fallback Rate) Rate {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	n, per, ok := strings.Cut(v, "/")
+	if !ok {
+		log.Fatalf("invalid %s: want <requests>/<duration>, e.g. 10/1m", key)
+	}
+	requests, err := strconv.Atoi(strings.TrimSpace(n))
+	if err != nil {
+		log.Fatalf("invalid %s: %v", key, err)
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(per))
+	if err != nil {
+		log.Fatalf("invalid %s: %v", key, err)
+	}
+	return Rate{Requests: requests, Per: d}
+}
+
+// getEnvList reads a comma separated list. Semicolons are accepted as well
+// since LDAP group DNs contain commas.
+func getEnvList(key string, fallback []string) []string {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	sep := ","
+	if strings.Contains(v, ";") {
+		sep = ";"
+	}
+	var out []string
+	for _, item := range strings.Split(v, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
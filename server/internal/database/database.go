@@ -31,6 +31,11 @@ func New(dsn string) *gorm.DB {
 
 // AutoMigrate creates tables and full-text index for resources.
 func AutoMigrate(db *gorm.DB) error {
+	// Backfills below that must run only once, when their column or table
+	// appears.
+	addsProvisioned := !db.Migrator().HasColumn(&models.UserIdentity{}, "provisioned")
+	addsWatches := !db.Migrator().HasTable(&models.Watch{})
+
 	if err := db.AutoMigrate(
 		&models.User{},
 		&models.Resource{},
@@ -39,7 +44,29 @@ func AutoMigrate(db *gorm.DB) error {
 		&models.DownloadLog{},
 		&models.Report{},
 		&models.Request{},
+		&models.RequestAnswer{},
+		&models.RequestComment{},
+		&models.RequestVote{},
+		&models.BountyContribution{},
+		&models.RequestMatch{},
 		&models.LearningProgress{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.Follow{},
+		&models.DigestSubscription{},
+		&models.SavedSearch{},
+		&models.Watch{},
+		&models.AuditLog{},
+		&models.ModerationItem{},
+		&models.Session{},
+		&models.UserToken{},
+		&models.UserIdentity{},
+		&models.OAuthState{},
+		&models.RecoveryCode{},
+		&models.APIToken{},
+		&models.PointEntry{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
 	); err != nil {
 		return fmt.Errorf("automigrate: %w", err)
 	}
@@ -58,5 +85,106 @@ CREATE INDEX IF NOT EXISTS idx_resources_search_vector ON resources USING GIN (s
 		return fmt.Errorf("ensure fts: %w", err)
 	}
 
+	requestFTS := `
+ALTER TABLE requests
+	ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+		setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+		setweight(to_tsvector('english', coalesce(description, '')), 'B') ||
+		setweight(to_tsvector('english', coalesce(tags, '')), 'C')
+	) STORED;
+CREATE INDEX IF NOT EXISTS idx_requests_search_vector ON requests USING GIN (search_vector);
+`
+	if err := db.Exec(requestFTS).Error; err != nil {
+		return fmt.Errorf("ensure request fts: %w", err)
+	}
+
+	// One pending report per user and resource.
+	reportDedup := `CREATE UNIQUE INDEX IF NOT EXISTS idx_reports_pending_user_resource
+	ON reports (user_id, resource_id) WHERE status = 'pending';`
+	if err := db.Exec(reportDedup).Error; err != nil {
+		return fmt.Errorf("ensure report dedup index: %w", err)
+	}
+
+	// Pending resources and reports created before the moderation queue existed.
+	queueBackfill := `
+INSERT INTO moderation_items (id, kind, target_id, priority, status, escalated, created_at, updated_at)
+SELECT gen_random_uuid(), 'resource', r.id, 0, 'open', false, r.created_at, now()
+FROM resources r
+WHERE r.status = 'pending'
+	AND NOT EXISTS (SELECT 1 FROM moderation_items m WHERE m.kind = 'resource' AND m.target_id = r.id);
+INSERT INTO moderation_items (id, kind, target_id, priority, status, escalated, created_at, updated_at)
+SELECT gen_random_uuid(), 'report', p.id, 10, 'open', false, p.created_at, now()
+FROM reports p
+WHERE p.status = 'pending'
+	AND NOT EXISTS (SELECT 1 FROM moderation_items m WHERE m.kind = 'report' AND m.target_id = p.id);
+`
+	if err := db.Exec(queueBackfill).Error; err != nil {
+		return fmt.Errorf("backfill moderation queue: %w", err)
+	}
+
+	// Balances earned before the points ledger existed become one opening entry.
+	pointsBackfill := `
+INSERT INTO point_entries (id, user_id, delta, balance, reason, ref_type, ref_id, note, created_at)
+SELECT gen_random_uuid(), u.id, u.points, u.points, 'opening_balance', '', '00000000-0000-0000-0000-000000000000', '', now()
+FROM users u
+WHERE u.points <> 0
+	AND NOT EXISTS (SELECT 1 FROM point_entries p WHERE p.user_id = u.id);
+`
+	if err := db.Exec(pointsBackfill).Error; err != nil {
+		return fmt.Errorf("backfill points ledger: %w", err)
+	}
+
+	// Resources approved before approval times were recorded count as
+	// approved when they were last updated.
+	approvedBackfill := `UPDATE resources SET approved_at = updated_at WHERE status <> 'pending' AND status <> 'rejected' AND approved_at IS NULL`
+	if err := db.Exec(approvedBackfill).Error; err != nil {
+		return fmt.Errorf("backfill approval times: %w", err)
+	}
+
+	// Favorites made before watches existed keep notifying about new versions,
+	// unless the user turned that off. Runs only when the table is created so
+	// later unwatches stick.
+	if addsWatches {
+		watchBackfill := `
+INSERT INTO watches (id, user_id, resource_id, source, created_at)
+SELECT DISTINCT ON (f.user_id, f.resource_id) gen_random_uuid(), f.user_id, f.resource_id, 'favorite', f.created_at
+FROM favorites f
+JOIN users u ON u.id = f.user_id
+WHERE u.watch_favorites
+ON CONFLICT (user_id, resource_id) DO NOTHING;
+`
+		if err := db.Exec(watchBackfill).Error; err != nil {
+			return fmt.Errorf("backfill watches: %w", err)
+		}
+	}
+
+	// Identities that created a passwordless account before the flag existed
+	// keep syncing its role.
+	if addsProvisioned {
+		provisionedBackfill := `
+UPDATE user_identities SET provisioned = true
+WHERE user_id IN (SELECT id FROM users WHERE password_hash = '')
+	AND user_id IN (SELECT user_id FROM user_identities GROUP BY user_id HAVING COUNT(*) = 1);
+`
+		if err := db.Exec(provisionedBackfill).Error; err != nil {
+			return fmt.Errorf("backfill provisioned identities: %w", err)
+		}
+	}
+
+	// Audit log is append-only, also for writes that bypass the application.
+	auditImmutable := `
+CREATE OR REPLACE FUNCTION audit_logs_immutable() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+DROP TRIGGER IF EXISTS audit_logs_no_modify ON audit_logs;
+CREATE TRIGGER audit_logs_no_modify BEFORE UPDATE OR DELETE ON audit_logs
+	FOR EACH ROW EXECUTE FUNCTION audit_logs_immutable();
+`
+	if err := db.Exec(auditImmutable).Error; err != nil {
+		return fmt.Errorf("ensure audit trigger: %w", err)
+	}
+
 	return nil
 }
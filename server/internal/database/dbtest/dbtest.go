@@ -0,0 +1,52 @@
+// Package dbtest gives integration tests a migrated PostgreSQL database.
+// Tests using it are skipped unless TEST_DATABASE_URL names a database that
+// may be freely written to.
+package dbtest
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/A-Words/ne-resource-community/server/internal/database"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var (
+	once    sync.Once
+	shared  *gorm.DB
+	openErr error
+)
+
+// Open returns the test database, migrated once per test binary.
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	once.Do(func() {
+		shared, openErr = gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+		if openErr == nil {
+			openErr = database.AutoMigrate(shared)
+		}
+	})
+	if openErr != nil {
+		t.Fatalf("open test database: %v", openErr)
+	}
+	return shared
+}
+
+// Tx returns a transaction on the test database that is rolled back when
+// the test ends, so tests do not see each other's rows.
+func Tx(t testing.TB) *gorm.DB {
+	t.Helper()
+	tx := Open(t).Begin()
+	if tx.Error != nil {
+		t.Fatalf("begin: %v", tx.Error)
+	}
+	t.Cleanup(func() { tx.Rollback() })
+	return tx
+}
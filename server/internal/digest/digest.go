@@ -0,0 +1,324 @@
+package digest
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/mail"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/A-Words/ne-resource-community/server/internal/secretbox"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Digest frequencies.
+const (
+	FrequencyOff    = "off"
+	FrequencyDaily  = "daily"
+	FrequencyWeekly = "weekly"
+)
+
+// Topic kinds users can follow. Each matches the resource field of the same
+// name; tags match any single tag.
+const (
+	KindVendor   = "vendor"
+	KindProtocol = "protocol"
+	KindTag      = "tag"
+	KindScenario = "scenario"
+)
+
+// Kinds lists the followable topic kinds.
+var Kinds = []string{KindVendor, KindProtocol, KindTag, KindScenario}
+
+const (
+	maxResources   = 50 // per digest
+	maxNewVersions = 20
+	// slack lets an hourly job send a daily digest at roughly the same time
+	// each day instead of drifting later.
+	slack = 30 * time.Minute
+	// unsubscribeTTL bounds how long the unsubscribe link of a digest works.
+	unsubscribeTTL = 180 * 24 * time.Hour
+)
+
+// ErrInvalidToken is returned for unsubscribe tokens that do not verify.
+var ErrInvalidToken = errors.New("invalid unsubscribe token")
+
+// ValidKind reports whether kind is a followable topic kind.
+func ValidKind(kind string) bool {
+	for _, k := range Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Period returns how much time one digest of frequency covers.
+func Period(frequency string) time.Duration {
+	switch frequency {
+	case FrequencyDaily:
+		return 24 * time.Hour
+	case FrequencyWeekly:
+		return 7 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+// UnsubscribeKey derives the key that signs unsubscribe tokens. It is
+// separate from the key that signs access tokens.
+func UnsubscribeKey(cfg config.Config) []byte {
+	return secretbox.DeriveKey(cfg.JWTSecret, "digest-unsubscribe")
+}
+
+// UnsubscribeToken returns a token issued at now that turns off userID's
+// digest. It stays valid for unsubscribeTTL so links in older emails keep
+// working for a while.
+func UnsubscribeToken(key []byte, userID uuid.UUID, now time.Time) string {
+	payload := userID.String() + "." + strconv.FormatInt(now.Unix(), 10)
+	return payload + "." + unsubscribeMAC(key, payload)
+}
+
+// ParseUnsubscribeToken verifies token and returns the user it belongs to.
+func ParseUnsubscribeToken(key []byte, token string, now time.Time) (uuid.UUID, error) {
+	i := strings.LastIndex(token, ".")
+	if i < 0 {
+		return uuid.Nil, ErrInvalidToken
+	}
+	payload, mac := token[:i], token[i+1:]
+	if !hmac.Equal([]byte(mac), []byte(unsubscribeMAC(key, payload))) {
+		return uuid.Nil, ErrInvalidToken
+	}
+	id, issued, ok := strings.Cut(payload, ".")
+	if !ok {
+		return uuid.Nil, ErrInvalidToken
+	}
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, ErrInvalidToken
+	}
+	unix, err := strconv.ParseInt(issued, 10, 64)
+	if err != nil {
+		return uuid.Nil, ErrInvalidToken
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age < -time.Minute || age > unsubscribeTTL {
+		return uuid.Nil, ErrInvalidToken
+	}
+	return userID, nil
+}
+
+func unsubscribeMAC(key []byte, payload string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("digest-unsubscribe:" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Unsubscribe turns off userID's digest.
+func Unsubscribe(db *gorm.DB, userID uuid.UUID) error {
+	return db.Model(&models.DigestSubscription{}).Where("user_id = ?", userID).
+		Update("frequency", FrequencyOff).Error
+}
+
+// Item is one resource listed in a digest.
+type Item struct {
+	Title    string
+	Version  string
+	Vendor   string
+	Protocol string
+	Link     string
+}
+
+// Data is passed to the digest email templates.
+type Data struct {
+	DisplayName     string
+	Frequency       string // daily, weekly
+	Resources       []Item // newly approved in followed topics
+	NewVersions     []Item // new versions of watched resources
+	UnsubscribeLink string
+	SettingsLink    string
+}
+
+// Runner returns a job that mails due digests. It is meant to run about
+// hourly; each subscription is claimed before sending so several replicas
+// can run it without sending twice.
+func Runner(db *gorm.DB, sender mail.Sender, cfg config.Config) func(context.Context) error {
+	return func(ctx context.Context) error {
+		db := db.WithContext(ctx)
+		// Postgres keeps microseconds; claim compares against the stored value.
+		now := time.Now().Truncate(time.Microsecond)
+		var due []models.DigestSubscription
+		err := db.Where("(frequency = ? AND (last_sent_at IS NULL OR last_sent_at < ?)) OR (frequency = ? AND (last_sent_at IS NULL OR last_sent_at < ?))",
+			FrequencyDaily, now.Add(-Period(FrequencyDaily)+slack),
+			FrequencyWeekly, now.Add(-Period(FrequencyWeekly)+slack),
+		).Find(&due).Error
+		if err != nil {
+			return fmt.Errorf("load due digests: %w", err)
+		}
+		for _, sub := range due {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if err := send(ctx, db, sender, cfg, sub, now); err != nil {
+				log.Printf("digest for user %s: %v", sub.UserID, err)
+			}
+		}
+		return nil
+	}
+}
+
+// send mails one user's digest covering the time since their last one.
+func send(ctx context.Context, db *gorm.DB, sender mail.Sender, cfg config.Config, sub models.DigestSubscription, now time.Time) error {
+	since := now.Add(-Period(sub.Frequency))
+	if sub.LastSentAt != nil {
+		since = *sub.LastSentAt
+	}
+	claimed, err := claim(db, sub, &now)
+	if err != nil || !claimed {
+		return err
+	}
+
+	data, recipient, err := build(db, cfg, sub, since, now)
+	if err == nil && recipient != "" {
+		var msg mail.Message
+		if msg, err = mail.Render("digest", recipient, data); err == nil {
+			msg.Headers = map[string]string{
+				"List-Unsubscribe":      "<" + data.UnsubscribeLink + ">",
+				"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+			}
+			err = sender.Send(ctx, msg)
+		}
+	}
+	if err != nil {
+		// Put the window back so the next run retries it.
+		if _, rerr := claim(db, models.DigestSubscription{ID: sub.ID, LastSentAt: &now}, sub.LastSentAt); rerr != nil {
+			log.Printf("digest for user %s: reset window: %v", sub.UserID, rerr)
+		}
+		return err
+	}
+	return nil
+}
+
+// claim moves last_sent_at to to unless someone else already moved it.
+func claim(db *gorm.DB, sub models.DigestSubscription, to *time.Time) (bool, error) {
+	q := db.Model(&models.DigestSubscription{}).Where("id = ?", sub.ID)
+	if sub.LastSentAt == nil {
+		q = q.Where("last_sent_at IS NULL")
+	} else {
+		q = q.Where("last_sent_at = ?", *sub.LastSentAt)
+	}
+	res := q.Update("last_sent_at", to)
+	return res.RowsAffected > 0, res.Error
+}
+
+// build collects the digest content. An empty recipient means there is
+// nothing to send.
+func build(db *gorm.DB, cfg config.Config, sub models.DigestSubscription, since, until time.Time) (Data, string, error) {
+	var user models.User
+	if err := db.First(&user, "id = ?", sub.UserID).Error; err != nil {
+		return Data{}, "", err
+	}
+	if user.IsSuspended(until) || (cfg.RequireVerifiedEmail && user.EmailVerifiedAt == nil) {
+		return Data{}, "", nil
+	}
+
+	// New versions of anything the user favorited or watches.
+	var versions []models.Resource
+	if err := db.Where("status = ? AND approved_at > ? AND approved_at <= ?", "approved", since, until).
+		Where("parent_id IN (? UNION ?)",
+			db.Model(&models.Favorite{}).Select("resource_id").Where("user_id = ?", user.ID),
+			db.Model(&models.Watch{}).Select("resource_id").Where("user_id = ?", user.ID)).
+		Where("uploader_id <> ?", user.ID).
+		Scopes(activeUploader(until)).
+		Order("approved_at DESC").Limit(maxNewVersions).
+		Find(&versions).Error; err != nil {
+		return Data{}, "", fmt.Errorf("load new versions: %w", err)
+	}
+
+	var follows []models.Follow
+	if err := db.Where("user_id = ?", user.ID).Find(&follows).Error; err != nil {
+		return Data{}, "", fmt.Errorf("load follows: %w", err)
+	}
+	var resources []models.Resource
+	if cond, args := topicCondition(follows); cond != "" {
+		q := db.Where("status = ? AND approved_at > ? AND approved_at <= ?", "approved", since, until).
+			Where("uploader_id <> ?", user.ID).
+			Scopes(activeUploader(until)).
+			Where(cond, args...)
+		if len(versions) > 0 {
+			ids := make([]uuid.UUID, len(versions))
+			for i, v := range versions {
+				ids[i] = v.ID
+			}
+			q = q.Where("id NOT IN ?", ids)
+		}
+		if err := q.Order("approved_at DESC").Limit(maxResources).Find(&resources).Error; err != nil {
+			return Data{}, "", fmt.Errorf("load followed resources: %w", err)
+		}
+	}
+	if len(resources) == 0 && len(versions) == 0 {
+		return Data{}, "", nil
+	}
+
+	return Data{
+		DisplayName:     user.DisplayName,
+		Frequency:       sub.Frequency,
+		Resources:       items(cfg, resources),
+		NewVersions:     items(cfg, versions),
+		UnsubscribeLink: cfg.PublicBaseURL + "/api/digest/unsubscribe?token=" + url.QueryEscape(UnsubscribeToken(UnsubscribeKey(cfg), user.ID, until)),
+		SettingsLink:    cfg.PublicBaseURL + "/dashboard",
+	}, user.Email, nil
+}
+
+// activeUploader leaves out resources whose uploader is suspended at t, as
+// the public listings do.
+func activeUploader(t time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("uploader_id NOT IN (SELECT id FROM users WHERE suspended_until > ?)", t)
+	}
+}
+
+// topicCondition ORs the followed topics into one SQL condition.
+func topicCondition(follows []models.Follow) (string, []interface{}) {
+	var parts []string
+	var args []interface{}
+	for _, f := range follows {
+		value := strings.ToLower(strings.TrimSpace(f.Value))
+		switch f.Kind {
+		case KindVendor, KindProtocol, KindScenario:
+			parts = append(parts, "lower("+f.Kind+") = ?")
+		case KindTag:
+			parts = append(parts, models.HasTag)
+		default:
+			continue
+		}
+		args = append(args, value)
+	}
+	if len(parts) == 0 {
+		return "", nil
+	}
+	return "(" + strings.Join(parts, " OR ") + ")", args
+}
+
+func items(cfg config.Config, resources []models.Resource) []Item {
+	out := make([]Item, 0, len(resources))
+	for _, r := range resources {
+		out = append(out, Item{
+			Title:    r.Title,
+			Version:  r.Version,
+			Vendor:   r.Vendor,
+			Protocol: r.Protocol,
+			Link:     cfg.PublicBaseURL + "/resources/" + r.ID.String(),
+		})
+	}
+	return out
+}
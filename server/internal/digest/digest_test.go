@@ -0,0 +1,103 @@
+package digest
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/database/dbtest"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestTopicCondition(t *testing.T) {
+	cond, args := topicCondition([]models.Follow{
+		{Kind: KindVendor, Value: " Cisco "},
+		{Kind: KindTag, Value: "BGP"},
+		{Kind: "device", Value: "ignored"},
+		{Kind: KindScenario, Value: "campus"},
+	})
+	want := "(lower(vendor) = ? OR " + models.HasTag + " OR lower(scenario) = ?)"
+	if cond != want {
+		t.Errorf("cond = %s\nwant  %s", cond, want)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"cisco", "bgp", "campus"}) {
+		t.Errorf("args = %v", args)
+	}
+
+	if cond, args := topicCondition(nil); cond != "" || args != nil {
+		t.Errorf("no follows: %q, %v", cond, args)
+	}
+	if cond, _ := topicCondition([]models.Follow{{Kind: "unknown", Value: "x"}}); cond != "" {
+		t.Errorf("unknown kinds only: %q", cond)
+	}
+}
+
+func TestUnsubscribeToken(t *testing.T) {
+	key := UnsubscribeKey(config.Config{JWTSecret: "secret"})
+	userID := uuid.New()
+	issued := time.Now()
+	token := UnsubscribeToken(key, userID, issued)
+
+	if got, err := ParseUnsubscribeToken(key, token, issued.Add(30*24*time.Hour)); err != nil || got != userID {
+		t.Fatalf("Parse = %s, %v", got, err)
+	}
+
+	tampered := strings.Replace(token, userID.String(), uuid.NewString(), 1)
+	cases := []struct {
+		name  string
+		key   []byte
+		token string
+		now   time.Time
+	}{
+		{"expired", key, token, issued.Add(unsubscribeTTL + time.Hour)},
+		{"issued in the future", key, token, issued.Add(-time.Hour)},
+		{"other user", key, tampered, issued},
+		{"other key", UnsubscribeKey(config.Config{JWTSecret: "other"}), token, issued},
+		{"signed with the raw secret", []byte("secret"), token, issued},
+		{"empty", key, "", issued},
+		{"no signature", key, userID.String(), issued},
+	}
+	for _, tc := range cases {
+		if _, err := ParseUnsubscribeToken(tc.key, tc.token, tc.now); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: err = %v, want ErrInvalidToken", tc.name, err)
+		}
+	}
+}
+
+func TestBuildSkipsSuspendedUploaders(t *testing.T) {
+	db := dbtest.Tx(t)
+	now := time.Now()
+	newUser := func(name string, suspendedUntil *time.Time) models.User {
+		t.Helper()
+		u := models.User{Email: name + "-" + uuid.NewString()[:8] + "@example.com", DisplayName: name, Role: models.RoleUser, SuspendedUntil: suspendedUntil}
+		if err := db.Create(&u).Error; err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+	until := now.Add(24 * time.Hour)
+	reader, active, suspended := newUser("reader", nil), newUser("active", nil), newUser("suspended", &until)
+	tag := "tag-" + uuid.NewString()[:8]
+	if err := db.Create(&models.Follow{UserID: reader.ID, Kind: KindTag, Value: tag}).Error; err != nil {
+		t.Fatal(err)
+	}
+	approved := now.Add(-time.Hour)
+	for _, u := range []models.User{active, suspended} {
+		r := models.Resource{Title: "by " + u.DisplayName, Type: "template", Tags: tag, UploaderID: u.ID, Status: "approved", ApprovedAt: &approved}
+		if err := db.Create(&r).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, recipient, err := build(db, config.Config{JWTSecret: "secret"}, models.DigestSubscription{UserID: reader.ID, Frequency: FrequencyDaily}, now.Add(-24*time.Hour), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recipient != reader.Email || len(data.Resources) != 1 || data.Resources[0].Title != "by active" {
+		t.Errorf("recipient %q, resources %+v", recipient, data.Resources)
+	}
+}
@@ -0,0 +1,137 @@
+package events
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/A-Words/ne-resource-community/server/internal/notify"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Event types. They double as notification types.
+const (
+	TypeResourceApproved    = "resource.approved"
+	TypeResourceRejected    = "resource.rejected"
+	TypeResourceReviewed    = "resource.reviewed"
+	TypeResourceNewVersion  = "resource.new_version"
+	TypeLinkBroken          = "resource.link_broken"
+	TypeResourceHidden      = "resource.hidden"
+	TypeResourceUnpublished = "resource.unpublished"
+	TypeReportResolved      = "report.resolved"
+	TypeRequestAnswered     = "request.answered"
+	TypeRequestAccepted     = "request.accepted"
+	TypeRequestClosed       = "request.closed"
+	TypeRequestBountyRaised = "request.bounty_raised"
+	TypeRequestComment      = "request.comment"
+	TypeRequestMatch        = "request.match"
+	TypeSavedSearchMatch    = "search.match"
+	TypeWatchUpdated        = "watch.updated"
+	TypeWatchUnpublished    = "watch.unpublished"
+	TypeWatchLinkBroken     = "watch.link_broken"
+	TypeModerationEscalated = "moderation.escalated"
+	TypeUserWarned          = "user.warned"
+	TypeUserSuspended       = "user.suspended"
+	TypeUserReinstated      = "user.reinstated"
+)
+
+// TypeInfo describes an event type for the notification preferences page.
+type TypeInfo struct {
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	Optional    bool   `json:"optional"` // users may turn it off
+}
+
+// Types lists every event type. Account and moderation notices cannot be
+// turned off.
+var Types = []TypeInfo{
+	{TypeResourceApproved, "Your upload was approved", true},
+	{TypeResourceRejected, "Your upload was rejected", true},
+	{TypeResourceReviewed, "Someone reviewed your resource", true},
+	{TypeResourceNewVersion, "A new version of a watched resource was published", true},
+	{TypeLinkBroken, "The external link of your resource is broken", true},
+	{TypeResourceHidden, "Your resource was hidden after reports", false},
+	{TypeResourceUnpublished, "Your resource was unpublished", false},
+	{TypeReportResolved, "A report you filed was reviewed", true},
+	{TypeRequestAnswered, "Your request received an answer", true},
+	{TypeRequestAccepted, "Your answer was accepted", true},
+	{TypeRequestClosed, "Your request was closed or expired", true},
+	{TypeRequestBountyRaised, "Someone raised the bounty of your request", true},
+	{TypeRequestComment, "New comments on your requests and replies to you", true},
+	{TypeRequestMatch, "A resource may answer a request", true},
+	{TypeSavedSearchMatch, "A new resource matches one of your saved searches", true},
+	{TypeWatchUpdated, "The details of a watched resource changed", true},
+	{TypeWatchUnpublished, "A watched resource was unpublished", true},
+	{TypeWatchLinkBroken, "The external link of a watched resource is broken", true},
+	{TypeModerationEscalated, "Moderation items are overdue", false},
+	{TypeUserWarned, "Your account received a warning", false},
+	{TypeUserSuspended, "Your account was suspended", false},
+	{TypeUserReinstated, "Your account was reinstated", false},
+}
+
+// Optional reports whether users may turn off notifications of type t.
+func Optional(t string) bool {
+	for _, info := range Types {
+		if info.Type == t {
+			return info.Optional
+		}
+	}
+	return false
+}
+
+// Event is something that happened in the domain. Recipients get an in-app
+// notification unless they turned the type off.
+type Event struct {
+	Type       string
+	Recipients []uuid.UUID
+	Title      string
+	Body       string
+	RefType    string // resource, request, report
+	RefID      uuid.UUID
+}
+
+// Subscriber reacts to published events inside the publishing transaction.
+type Subscriber func(tx *gorm.DB, e Event) error
+
+var (
+	mu          sync.RWMutex
+	subscribers []Subscriber
+)
+
+// Subscribe registers s for every event published afterwards.
+func Subscribe(s Subscriber) {
+	mu.Lock()
+	defer mu.Unlock()
+	subscribers = append(subscribers, s)
+}
+
+// Publish notifies the event's recipients and hands it to subscribers. It
+// runs in tx so nothing is sent for work that is rolled back.
+func Publish(tx *gorm.DB, e Event) error {
+	for _, uid := range e.Recipients {
+		if uid == uuid.Nil {
+			continue
+		}
+		if Optional(e.Type) {
+			muted, err := notify.Muted(tx, uid, e.Type)
+			if err != nil {
+				return fmt.Errorf("load notification preferences: %w", err)
+			}
+			if muted {
+				continue
+			}
+		}
+		if err := notify.Send(tx, uid, e.Type, e.Title, e.Body, e.RefType, e.RefID); err != nil {
+			return err
+		}
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, s := range subscribers {
+		if err := s(tx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testFeed() Feed {
+	published := time.Date(2024, 3, 1, 9, 30, 0, 0, time.FixedZone("CST", 8*3600))
+	return Feed{
+		Title:    "Resources",
+		Subtitle: "Newly approved",
+		Link:     "https://example.com/resources",
+		SelfLink: "https://example.com/api/feeds/resources",
+		Updated:  published.Add(time.Hour),
+		Entries: []Entry{
+			{
+				ID:         "urn:uuid:0b5c1c1e-4f7e-4d55-9d0a-1f1e8f0c2a11",
+				Title:      "BGP <route-map> & prefix lists",
+				Link:       "https://example.com/resources/1",
+				Summary:    "Filters for \"edge\" routers",
+				Author:     "Alice",
+				Categories: []string{"cisco", "bgp"},
+				Published:  published,
+				Updated:    published.Add(30 * time.Minute),
+			},
+			{
+				ID:        "urn:uuid:5e0f3a52-1c8b-4d0f-8a43-c6f0a4b0d8e2",
+				Title:     "Anonymous note",
+				Link:      "https://example.com/resources/2",
+				Published: published,
+				Updated:   published,
+			},
+		},
+	}
+}
+
+func TestAtom(t *testing.T) {
+	raw, err := testFeed().Atom()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(raw, []byte(xml.Header)) {
+		t.Error("missing XML declaration")
+	}
+
+	var doc struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		ID      string   `xml:"id"`
+		Title   string   `xml:"title"`
+		Updated string   `xml:"updated"`
+		Links   []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Entries []struct {
+			ID        string `xml:"id"`
+			Title     string `xml:"title"`
+			Published string `xml:"published"`
+			Updated   string `xml:"updated"`
+			Summary   string `xml:"summary"`
+			Author    *struct {
+				Name string `xml:"name"`
+			} `xml:"author"`
+			Categories []struct {
+				Term string `xml:"term,attr"`
+			} `xml:"category"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("parse: %v\n%s", err, raw)
+	}
+	if doc.ID != "https://example.com/api/feeds/resources" || doc.Title != "Resources" || doc.Updated != "2024-03-01T02:30:00Z" {
+		t.Errorf("feed = %+v", doc)
+	}
+	if len(doc.Links) != 2 || doc.Links[0].Rel != "alternate" || doc.Links[1].Rel != "self" || doc.Links[1].Href != doc.ID {
+		t.Errorf("links = %+v", doc.Links)
+	}
+	if len(doc.Entries) != 2 {
+		t.Fatalf("%d entries", len(doc.Entries))
+	}
+	e := doc.Entries[0]
+	if e.Title != "BGP <route-map> & prefix lists" || e.Summary != `Filters for "edge" routers` {
+		t.Errorf("text did not round-trip: %q, %q", e.Title, e.Summary)
+	}
+	if e.Published != "2024-03-01T01:30:00Z" || e.Updated != "2024-03-01T02:00:00Z" {
+		t.Errorf("dates = %s, %s; want UTC RFC 3339", e.Published, e.Updated)
+	}
+	if e.Author == nil || e.Author.Name != "Alice" || len(e.Categories) != 2 || e.Categories[1].Term != "bgp" {
+		t.Errorf("entry = %+v", e)
+	}
+	if doc.Entries[1].Author != nil {
+		t.Error("empty author rendered")
+	}
+}
+
+func TestRSS(t *testing.T) {
+	raw, err := testFeed().RSS()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		XMLName xml.Name `xml:"rss"`
+		Version string   `xml:"version,attr"`
+		Channel struct {
+			Title         string `xml:"title"`
+			Description   string `xml:"description"`
+			LastBuildDate string `xml:"lastBuildDate"`
+			Self          struct {
+				Href string `xml:"href,attr"`
+				Rel  string `xml:"rel,attr"`
+			} `xml:"http://www.w3.org/2005/Atom link"`
+			Items []struct {
+				Title string `xml:"title"`
+				GUID  struct {
+					Value       string `xml:",chardata"`
+					IsPermaLink string `xml:"isPermaLink,attr"`
+				} `xml:"guid"`
+				Description string   `xml:"description"`
+				Creator     string   `xml:"http://purl.org/dc/elements/1.1/ creator"`
+				Categories  []string `xml:"category"`
+				PubDate     string   `xml:"pubDate"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("parse: %v\n%s", err, raw)
+	}
+	ch := doc.Channel
+	if doc.Version != "2.0" || ch.Title != "Resources" || ch.Description != "Newly approved" {
+		t.Errorf("channel = %+v", ch)
+	}
+	if ch.LastBuildDate != "Fri, 01 Mar 2024 02:30:00 +0000" {
+		t.Errorf("lastBuildDate = %q, want RFC 1123 in UTC", ch.LastBuildDate)
+	}
+	if ch.Self.Href != "https://example.com/api/feeds/resources" || ch.Self.Rel != "self" {
+		t.Errorf("atom:link = %+v", ch.Self)
+	}
+	if len(ch.Items) != 2 {
+		t.Fatalf("%d items", len(ch.Items))
+	}
+	item := ch.Items[0]
+	if item.Title != "BGP <route-map> & prefix lists" || item.Creator != "Alice" || len(item.Categories) != 2 {
+		t.Errorf("item = %+v", item)
+	}
+	if item.GUID.Value != "urn:uuid:0b5c1c1e-4f7e-4d55-9d0a-1f1e8f0c2a11" || item.GUID.IsPermaLink != "false" {
+		t.Errorf("guid = %+v", item.GUID)
+	}
+	if item.PubDate != "Fri, 01 Mar 2024 01:30:00 +0000" {
+		t.Errorf("pubDate = %q", item.PubDate)
+	}
+	if strings.Contains(string(raw), "<dc:creator></dc:creator>") || strings.Contains(string(raw), "<description></description>") {
+		t.Errorf("empty optional elements rendered:\n%s", raw)
+	}
+}
+
+func TestRSSDescriptionFallsBackToTitle(t *testing.T) {
+	f := testFeed()
+	f.Subtitle = ""
+	raw, err := f.RSS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(raw), "<description>Resources</description>") {
+		t.Errorf("channel description missing:\n%s", raw)
+	}
+}
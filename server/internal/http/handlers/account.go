@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/apitoken"
+	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/http/middleware"
+	"github.com/A-Words/ne-resource-community/server/internal/mail"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/A-Words/ne-resource-community/server/internal/onetime"
+	"github.com/A-Words/ne-resource-community/server/internal/session"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	verifyEmailTTL   = 24 * time.Hour
+	resetPasswordTTL = time.Hour
+)
+
+type emailLinkData struct {
+	DisplayName string
+	Link        string
+	Hours       int // link lifetime
+}
+
+// tokenMailer mails links that redeem one-time account tokens.
+type tokenMailer struct {
+	db     *gorm.DB
+	cfg    config.Config
+	sender mail.Sender
+}
+
+func newTokenMailer(db *gorm.DB, cfg config.Config) tokenMailer {
+	return tokenMailer{db: db, cfg: cfg, sender: mail.NewSender(cfg)}
+}
+
+// send issues a one-time token and mails the link that redeems it.
+func (m tokenMailer) send(ctx context.Context, user models.User, purpose, template, path string, ttl time.Duration) error {
+	raw, err := onetime.Issue(m.db, user.ID, purpose, ttl)
+	if err != nil {
+		return err
+	}
+	msg, err := mail.Render(template, user.Email, emailLinkData{
+		DisplayName: user.DisplayName,
+		Link:        m.cfg.PublicBaseURL + path + "?token=" + url.QueryEscape(raw),
+		Hours:       int(ttl.Hours()),
+	})
+	if err != nil {
+		return err
+	}
+	return m.sender.Send(ctx, msg)
+}
+
+func (m tokenMailer) sendVerification(ctx context.Context, user models.User) error {
+	return m.send(ctx, user, onetime.PurposeVerifyEmail, "verify_email", "/verify-email", verifyEmailTTL)
+}
+
+func (m tokenMailer) sendPasswordReset(ctx context.Context, user models.User) error {
+	return m.send(ctx, user, onetime.PurposeResetPassword, "reset_password", "/reset-password", resetPasswordTTL)
+}
+
+type tokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// VerifyEmail redeems an email verification token.
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req tokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		token, err := onetime.Consume(tx, req.Token, onetime.PurposeVerifyEmail)
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).
+			Where("id = ? AND email_verified_at IS NULL", token.UserID).
+			Update("email_verified_at", time.Now()).Error
+	})
+	if err != nil {
+		if errors.Is(err, onetime.ErrInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify email"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "email verified"})
+}
+
+// ResendVerification mails a new verification link to the caller.
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	var user models.User
+	if err := h.db.First(&user, "id = ?", uid).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	if user.EmailVerifiedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "email already verified"})
+		return
+	}
+	if err := h.mail.sendVerification(c.Request.Context(), user); err != nil {
+		log.Printf("send verification to %s: %v", user.Email, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to send email"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "verification email sent"})
+}
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ForgotPassword mails a password reset link. It answers the same way
+// whether or not the address is registered.
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req forgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": humanizeValidation(err)})
+		return
+	}
+
+	// Look up and mail in the background so the response time does not
+	// reveal whether the address is registered.
+	go func(email string) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		var user models.User
+		if err := h.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				log.Printf("password reset lookup: %v", err)
+			}
+			return
+		}
+		if err := h.mail.sendPasswordReset(ctx, user); err != nil {
+			log.Printf("send password reset to %s: %v", user.Email, err)
+		}
+	}(req.Email)
+	c.JSON(http.StatusOK, gin.H{"message": "if the address is registered, a reset link has been sent"})
+}
+
+type resetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"newPassword" binding:"required,min=6"`
+}
+
+// ResetPassword sets a new password using a reset token and signs out every
+// existing session.
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req resetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var probe models.User
+	if err := probe.SetPassword(req.NewPassword); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to set password"})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		token, err := onetime.Consume(tx, req.Token, onetime.PurposeResetPassword)
+		if err != nil {
+			return err
+		}
+		// Receiving the reset mail proves ownership of the address as well.
+		if err := tx.Model(&models.User{}).Where("id = ?", token.UserID).Updates(map[string]interface{}{
+			"password_hash":     probe.PasswordHash,
+			"email_verified_at": gorm.Expr("COALESCE(email_verified_at, ?)", time.Now()),
+			"failed_logins":     0,
+			"locked_until":      nil,
+		}).Error; err != nil {
+			return err
+		}
+		if err := apitoken.RevokeAll(tx, token.UserID); err != nil {
+			return err
+		}
+		return session.Invalidate(tx, token.UserID)
+	})
+	if err != nil {
+		if errors.Is(err, onetime.ErrInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset password"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "password has been reset, please log in again"})
+}
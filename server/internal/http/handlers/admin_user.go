@@ -0,0 +1,619 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/apitoken"
+	"github.com/A-Words/ne-resource-community/server/internal/audit"
+	"github.com/A-Words/ne-resource-community/server/internal/authn"
+	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/events"
+	"github.com/A-Words/ne-resource-community/server/internal/http/middleware"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/A-Words/ne-resource-community/server/internal/moderation"
+	"github.com/A-Words/ne-resource-community/server/internal/points"
+	"github.com/A-Words/ne-resource-community/server/internal/session"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxAdminPage caps page sizes of the user admin listings.
+const maxAdminPage = 200
+
+var (
+	errSelfAction   = errors.New("you cannot do this to your own account")
+	errUserNotFound = errors.New("user not found")
+)
+
+// AdminUserHandler lets admins inspect and manage accounts.
+type AdminUserHandler struct {
+	db   *gorm.DB
+	cfg  config.Config
+	mail tokenMailer
+}
+
+func NewAdminUserHandler(db *gorm.DB, cfg config.Config) *AdminUserHandler {
+	return &AdminUserHandler{db: db, cfg: cfg, mail: newTokenMailer(db, cfg)}
+}
+
+type userQuery struct {
+	Search string `form:"q"`      // email or display name
+	Role   string `form:"role"`   // user, moderator, admin
+	Status string `form:"status"` // active, suspended, locked
+	Limit  int    `form:"limit,default=50"`
+	Offset int    `form:"offset,default=0"`
+}
+
+// List searches users.
+func (h *AdminUserHandler) List(c *gin.Context) {
+	var q userQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if q.Limit <= 0 || q.Limit > maxAdminPage {
+		q.Limit = 50
+	}
+
+	now := time.Now()
+	dbq := h.db.Model(&models.User{})
+	if q.Search != "" {
+		pattern := "%" + strings.TrimSpace(q.Search) + "%"
+		dbq = dbq.Where("email ILIKE ? OR display_name ILIKE ?", pattern, pattern)
+	}
+	if q.Role != "" {
+		dbq = dbq.Where("role = ?", q.Role)
+	}
+	switch q.Status {
+	case "active":
+		dbq = dbq.Where("suspended_until IS NULL OR suspended_until <= ?", now)
+	case "suspended":
+		dbq = dbq.Where("suspended_until > ?", now)
+	case "locked":
+		dbq = dbq.Where("locked_until > ?", now)
+	}
+
+	var users []models.User
+	if err := dbq.Order("created_at DESC").Limit(q.Limit).Offset(q.Offset).Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	c.JSON(http.StatusOK, users)
+}
+
+type userActivity struct {
+	Uploads         int64 `json:"uploads"`
+	ApprovedUploads int64 `json:"approvedUploads"`
+	Reviews         int64 `json:"reviews"`
+	ReportsFiled    int64 `json:"reportsFiled"`
+	ReportsReceived int64 `json:"reportsReceived"` // reports against the user's resources
+	Favorites       int64 `json:"favorites"`
+	Downloads       int64 `json:"downloads"`
+}
+
+// Get returns a user with activity counters and linked identities.
+func (h *AdminUserHandler) Get(c *gin.Context) {
+	user, ok := h.loadUser(c)
+	if !ok {
+		return
+	}
+
+	var act userActivity
+	h.db.Model(&models.Resource{}).Where("uploader_id = ?", user.ID).Count(&act.Uploads)
+	h.db.Model(&models.Resource{}).Where("uploader_id = ? AND status = ?", user.ID, "approved").Count(&act.ApprovedUploads)
+	h.db.Model(&models.Review{}).Where("user_id = ?", user.ID).Count(&act.Reviews)
+	h.db.Model(&models.Report{}).Where("user_id = ?", user.ID).Count(&act.ReportsFiled)
+	h.db.Model(&models.Report{}).
+		Where("resource_id IN (?)", h.db.Model(&models.Resource{}).Select("id").Where("uploader_id = ?", user.ID)).
+		Count(&act.ReportsReceived)
+	h.db.Model(&models.Favorite{}).Where("user_id = ?", user.ID).Count(&act.Favorites)
+	h.db.Model(&models.DownloadLog{}).Where("user_id = ?", user.ID).Count(&act.Downloads)
+
+	var identities []models.UserIdentity
+	h.db.Where("user_id = ?", user.ID).Find(&identities)
+
+	c.JSON(http.StatusOK, gin.H{"user": user, "activity": act, "identities": identities})
+}
+
+type linkIdentityRequest struct {
+	Provider string `json:"provider" binding:"required"`        // "ldap" or an OIDC provider name
+	Subject  string `json:"subject" binding:"required,max=255"` // directory DN or IdP subject
+	Email    string `json:"email" binding:"omitempty,email"`
+}
+
+// LinkIdentity links an external identity to an existing account, for logins
+// that were refused because the account was not linked automatically. The
+// account's role stays under admin control.
+func (h *AdminUserHandler) LinkIdentity(c *gin.Context) {
+	var req linkIdentityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.knownProvider(req.Provider) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown identity provider"})
+		return
+	}
+	user, ok := h.loadUser(c)
+	if !ok {
+		return
+	}
+
+	link := models.UserIdentity{UserID: user.ID, Provider: req.Provider, Subject: req.Subject, Email: req.Email}
+	if req.Provider == authn.LDAPProvider {
+		link.Subject = strings.ToLower(req.Subject) // DNs are matched case-insensitively
+	}
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&link).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, c, audit.ActionUserIdentityLink, "user", user.ID.String(), nil,
+			gin.H{"provider": link.Provider, "subject": link.Subject})
+	})
+	if isUniqueViolation(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "identity is already linked to an account"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to link identity"})
+		return
+	}
+	c.JSON(http.StatusCreated, link)
+}
+
+func (h *AdminUserHandler) knownProvider(name string) bool {
+	if name == authn.LDAPProvider {
+		return true
+	}
+	for _, p := range h.cfg.OIDCProviders {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+type pageQuery struct {
+	Limit  int `form:"limit,default=50"`
+	Offset int `form:"offset,default=0"`
+}
+
+// Uploads lists every resource of a user, whatever its status.
+func (h *AdminUserHandler) Uploads(c *gin.Context) {
+	h.listOwned(c, &[]models.Resource{}, "uploader_id")
+}
+
+// Reviews lists the reviews a user wrote.
+func (h *AdminUserHandler) Reviews(c *gin.Context) {
+	h.listOwned(c, &[]models.Review{}, "user_id")
+}
+
+// Reports lists the reports a user filed.
+func (h *AdminUserHandler) Reports(c *gin.Context) {
+	h.listOwned(c, &[]models.Report{}, "user_id")
+}
+
+func (h *AdminUserHandler) listOwned(c *gin.Context, dest interface{}, column string) {
+	var q pageQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	uid, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	if q.Limit <= 0 || q.Limit > maxAdminPage {
+		q.Limit = 50
+	}
+	if err := h.db.Where(column+" = ?", uid).Order("created_at DESC").Limit(q.Limit).Offset(q.Offset).Find(dest).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	c.JSON(http.StatusOK, dest)
+}
+
+type roleRequest struct {
+	Role string `json:"role" binding:"required,oneof=user moderator admin"`
+}
+
+// SetRole changes a user's role. The user is signed out everywhere so new
+// tokens carry the new role.
+func (h *AdminUserHandler) SetRole(c *gin.Context) {
+	var req roleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	user, ok := h.loadOther(c)
+	if !ok {
+		return
+	}
+	if user.Role == req.Role {
+		c.JSON(http.StatusOK, user)
+		return
+	}
+
+	before := user.Role
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("role", req.Role).Error; err != nil {
+			return err
+		}
+		if err := session.Invalidate(tx, user.ID); err != nil {
+			return err
+		}
+		return recordAudit(tx, c, audit.ActionUserRoleChange, "user", user.ID.String(), gin.H{"role": before}, gin.H{"role": req.Role})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to change role"})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+type suspendRequest struct {
+	Reason    string `json:"reason" binding:"required,max=255"`
+	Days      int    `json:"days" binding:"min=0"`
+	Permanent bool   `json:"permanent"` // ban; Days is ignored
+}
+
+// Suspend blocks a user until the given expiry, or permanently. Active
+// sessions end immediately and the user's content leaves public listings.
+func (h *AdminUserHandler) Suspend(c *gin.Context) {
+	var req suspendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.Permanent && req.Days == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "days or permanent is required"})
+		return
+	}
+	user, ok := h.loadOther(c)
+	if !ok {
+		return
+	}
+
+	until := models.BannedUntil
+	if !req.Permanent {
+		until = time.Now().AddDate(0, 0, req.Days)
+	}
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		_, err := suspendUser(tx, c, user, until, req.Reason, func(until time.Time) events.Event {
+			body := "Your account has been banned. Reason: " + req.Reason
+			if until.Before(models.BannedUntil) {
+				body = fmt.Sprintf("Your account is suspended until %s. Reason: %s", until.Format("2006-01-02"), req.Reason)
+			}
+			return events.Event{Title: "Account suspended", Body: body}
+		})
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to suspend user"})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// suspendUser suspends user until the given time inside tx. A longer
+// suspension or ban already in place is kept as it is. Otherwise the user's
+// sessions end, they get the notice built for the new expiry and the change
+// is audited. It returns the expiry in effect.
+func suspendUser(tx *gorm.DB, c *gin.Context, user models.User, until time.Time, reason string, notice func(until time.Time) events.Event) (time.Time, error) {
+	if user.SuspendedUntil != nil && !user.SuspendedUntil.Before(until) {
+		return *user.SuspendedUntil, nil
+	}
+	before := gin.H{"suspendedUntil": user.SuspendedUntil, "suspendReason": user.SuspendReason}
+	if err := tx.Model(&user).Updates(map[string]interface{}{"suspended_until": until, "suspend_reason": reason}).Error; err != nil {
+		return until, err
+	}
+	if err := session.Invalidate(tx, user.ID); err != nil {
+		return until, err
+	}
+	e := notice(until)
+	e.Type = events.TypeUserSuspended
+	e.Recipients = []uuid.UUID{user.ID}
+	if err := events.Publish(tx, e); err != nil {
+		return until, err
+	}
+	return until, recordAudit(tx, c, audit.ActionUserSuspend, "user", user.ID.String(), before,
+		gin.H{"suspendedUntil": until, "suspendReason": reason, "permanent": !until.Before(models.BannedUntil)})
+}
+
+// Unsuspend lifts a suspension or ban.
+func (h *AdminUserHandler) Unsuspend(c *gin.Context) {
+	user, ok := h.loadOther(c)
+	if !ok {
+		return
+	}
+	if !user.IsSuspended(time.Now()) {
+		c.JSON(http.StatusConflict, gin.H{"error": "user is not suspended"})
+		return
+	}
+
+	before := gin.H{"suspendedUntil": user.SuspendedUntil, "suspendReason": user.SuspendReason}
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{"suspended_until": nil, "suspend_reason": ""}).Error; err != nil {
+			return err
+		}
+		if err := events.Publish(tx, events.Event{
+			Type:       events.TypeUserReinstated,
+			Recipients: []uuid.UUID{user.ID},
+			Title:      "Account reinstated",
+			Body:       "Your account suspension has been lifted.",
+		}); err != nil {
+			return err
+		}
+		return recordAudit(tx, c, audit.ActionUserUnsuspend, "user", user.ID.String(), before, nil)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to lift suspension"})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// ResetPassword invalidates the user's password, sessions and API tokens and
+// mails a reset link. Admins never see or choose the new password.
+func (h *AdminUserHandler) ResetPassword(c *gin.Context) {
+	user, ok := h.loadOther(c)
+	if !ok {
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		// An empty hash never matches, so the old password stops working.
+		if err := tx.Model(&user).Update("password_hash", "").Error; err != nil {
+			return err
+		}
+		if err := apitoken.RevokeAll(tx, user.ID); err != nil {
+			return err
+		}
+		if err := session.Invalidate(tx, user.ID); err != nil {
+			return err
+		}
+		return recordAudit(tx, c, audit.ActionUserPasswordReset, "user", user.ID.String(), nil, nil)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset password"})
+		return
+	}
+	if err := h.mail.sendPasswordReset(c.Request.Context(), user); err != nil {
+		log.Printf("send password reset to %s: %v", user.Email, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "password was reset but the reset email could not be sent"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "password reset, a reset link was sent to " + user.Email})
+}
+
+type mergeRequest struct {
+	SourceID string `json:"sourceId" binding:"required,uuid"`
+}
+
+// mergeColumns lists the columns that reference a user and move to the
+// surviving account on merge.
+var mergeColumns = []struct {
+	model  interface{}
+	column string
+}{
+	{&models.Resource{}, "uploader_id"},
+	{&models.Review{}, "user_id"},
+	{&models.Favorite{}, "user_id"},
+	{&models.DownloadLog{}, "user_id"},
+	{&models.LearningProgress{}, "user_id"},
+	{&models.Report{}, "user_id"},
+	{&models.Report{}, "resolved_by_id"},
+	{&models.Request{}, "user_id"},
+	{&models.RequestAnswer{}, "user_id"},
+	{&models.RequestComment{}, "user_id"},
+	{&models.RequestVote{}, "user_id"},
+	{&models.BountyContribution{}, "user_id"},
+	{&models.Notification{}, "user_id"},
+	{&models.UserIdentity{}, "user_id"},
+	{&models.ModerationItem{}, "claimed_by_id"},
+	{&models.ModerationItem{}, "resolved_by_id"},
+	{&models.SavedSearch{}, "user_id"},
+	{&models.Watch{}, "user_id"},
+	{&models.Webhook{}, "created_by_id"},
+}
+
+// mergeDiscard lists per-account credentials and settings that are dropped,
+// not moved.
+var mergeDiscard = []interface{}{
+	&models.Session{}, &models.UserToken{}, &models.APIToken{}, &models.RecoveryCode{},
+	&models.NotificationPreference{}, &models.Follow{}, &models.DigestSubscription{},
+}
+
+// Merge folds the account in sourceId into the account in the URL and
+// deletes the source. Content, history and points move over; credentials and
+// the role of the source are dropped.
+func (h *AdminUserHandler) Merge(c *gin.Context) {
+	var req mergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	sourceID := uuid.MustParse(req.SourceID)
+	if sourceID == targetID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cannot merge an account into itself"})
+		return
+	}
+	if actor, _ := middleware.UserID(c); actor == sourceID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errSelfAction.Error()})
+		return
+	}
+
+	var target models.User
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		var source models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&source, "id = ?", sourceID).Error; err != nil {
+			return errUserNotFound
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&target, "id = ?", targetID).Error; err != nil {
+			return errUserNotFound
+		}
+		before := gin.H{"source": source, "target": target}
+
+		// Rows the target already has would turn into duplicates.
+		if err := tx.Where("user_id = ? AND resource_id IN (?)", sourceID,
+			tx.Model(&models.Favorite{}).Select("resource_id").Where("user_id = ?", targetID)).
+			Delete(&models.Favorite{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ? AND resource_id IN (?)", sourceID,
+			tx.Model(&models.LearningProgress{}).Select("resource_id").Where("user_id = ?", targetID)).
+			Delete(&models.LearningProgress{}).Error; err != nil {
+			return err
+		}
+		targetVotes := tx.Model(&models.RequestVote{}).Select("request_id").Where("user_id = ?", targetID)
+		if err := tx.Model(&models.Request{}).Where("id IN (?) AND id IN (?)",
+			tx.Model(&models.RequestVote{}).Select("request_id").Where("user_id = ?", sourceID), targetVotes).
+			UpdateColumn("vote_count", gorm.Expr("vote_count - 1")).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ? AND request_id IN (?)", sourceID, targetVotes).
+			Delete(&models.RequestVote{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ? AND resource_id IN (?)", sourceID,
+			tx.Model(&models.Watch{}).Select("resource_id").Where("user_id = ?", targetID)).
+			Delete(&models.Watch{}).Error; err != nil {
+			return err
+		}
+		if err := h.dismissDuplicateReports(tx, sourceID, targetID, c); err != nil {
+			return err
+		}
+
+		// The source's identities did not create the target, so they must
+		// not drive its role.
+		if err := tx.Model(&models.UserIdentity{}).Where("user_id = ?", sourceID).
+			Update("provisioned", false).Error; err != nil {
+			return err
+		}
+		for _, mc := range mergeColumns {
+			if err := tx.Model(mc.model).Where(mc.column+" = ?", sourceID).Update(mc.column, targetID).Error; err != nil {
+				return fmt.Errorf("move %s: %w", mc.column, err)
+			}
+		}
+		for _, m := range mergeDiscard {
+			if err := tx.Where("user_id = ?", sourceID).Delete(m).Error; err != nil {
+				return err
+			}
+		}
+
+		// The source's ledger stays behind as history; the target gets one
+		// entry carrying the balance over.
+		if source.Points != 0 {
+			entry, err := points.Apply(tx, points.Entry{
+				UserID:  target.ID,
+				Delta:   source.Points,
+				Reason:  points.ReasonAccountMerge,
+				RefType: "user",
+				RefID:   sourceID,
+				Note:    source.Email,
+			})
+			if err != nil {
+				return err
+			}
+			target.Points = entry.Balance
+		}
+		target.Warnings += source.Warnings
+		if target.EmailVerifiedAt == nil {
+			target.EmailVerifiedAt = source.EmailVerifiedAt
+		}
+		// The target keeps its role; granting the source's role takes an
+		// explicit SetRole.
+		if err := tx.Model(&target).Select("warnings", "email_verified_at").Updates(&target).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&source).Error; err != nil {
+			return err
+		}
+		if err := session.Invalidate(tx, target.ID); err != nil {
+			return err
+		}
+		target.CalculateLevel()
+		return recordAudit(tx, c, audit.ActionUserMerge, "user", target.ID.String(), before, gin.H{"target": target, "mergedFrom": sourceID})
+	})
+	if err != nil {
+		if errors.Is(err, errUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("merge user %s into %s: %v", sourceID, targetID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to merge accounts"})
+		return
+	}
+	c.JSON(http.StatusOK, target)
+}
+
+// dismissDuplicateReports closes pending reports of source on resources the
+// target has also reported, since only one pending report per user and
+// resource may exist.
+func (h *AdminUserHandler) dismissDuplicateReports(tx *gorm.DB, sourceID, targetID uuid.UUID, c *gin.Context) error {
+	var dup []models.Report
+	if err := tx.Where("user_id = ? AND status = ? AND resource_id IN (?)", sourceID, "pending",
+		tx.Model(&models.Report{}).Select("resource_id").Where("user_id = ? AND status = ?", targetID, "pending")).
+		Find(&dup).Error; err != nil {
+		return err
+	}
+	if len(dup) == 0 {
+		return nil
+	}
+	actor, _ := middleware.UserID(c)
+	ids := make([]uuid.UUID, len(dup))
+	for i, r := range dup {
+		ids[i] = r.ID
+	}
+	if err := tx.Model(&models.Report{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+		"status":          "dismissed",
+		"resolution":      resolveDismiss,
+		"resolution_note": "duplicate after account merge",
+		"resolved_by_id":  actor,
+		"resolved_at":     time.Now(),
+	}).Error; err != nil {
+		return err
+	}
+	return moderation.CompleteMany(tx, moderation.KindReport, ids, actor, resolveDismiss)
+}
+
+func (h *AdminUserHandler) loadUser(c *gin.Context) (models.User, bool) {
+	var user models.User
+	uid, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return user, false
+	}
+	if err := h.db.First(&user, "id = ?", uid).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": errUserNotFound.Error()})
+		return user, false
+	}
+	return user, true
+}
+
+// loadOther is loadUser for actions admins may not apply to themselves.
+func (h *AdminUserHandler) loadOther(c *gin.Context) (models.User, bool) {
+	user, ok := h.loadUser(c)
+	if !ok {
+		return user, false
+	}
+	if actor, _ := middleware.UserID(c); actor == user.ID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errSelfAction.Error()})
+		return user, false
+	}
+	return user, true
+}
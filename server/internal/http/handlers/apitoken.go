@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/apitoken"
+	"github.com/A-Words/ne-resource-community/server/internal/http/middleware"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type apiTokenView struct {
+	models.APIToken
+	Scopes []string `json:"scopes"`
+}
+
+func newAPITokenView(t models.APIToken) apiTokenView {
+	return apiTokenView{APIToken: t, Scopes: t.ScopeList()}
+}
+
+// ListAPITokens lists the caller's personal API tokens, newest first.
+func (h *AuthHandler) ListAPITokens(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	var tokens []models.APIToken
+	if err := h.db.Where("user_id = ?", uid).Order("created_at DESC").Find(&tokens).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	views := make([]apiTokenView, 0, len(tokens))
+	for _, t := range tokens {
+		views = append(views, newAPITokenView(t))
+	}
+	c.JSON(http.StatusOK, views)
+}
+
+type createAPITokenRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expiresInDays" binding:"min=0,max=365"` // 0 never expires
+}
+
+// CreateAPIToken issues a token. The secret is only returned in this response.
+func (h *AuthHandler) CreateAPIToken(c *gin.Context) {
+	var req createAPITokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	uid, _ := middleware.UserID(c)
+	role := middleware.Role(c)
+	for _, s := range req.Scopes {
+		if (s == apitoken.ScopeReview && role == models.RoleUser) || (s == apitoken.ScopeAdmin && role != models.RoleAdmin) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "your role cannot grant scope " + s})
+			return
+		}
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		t := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &t
+	}
+	token, raw, err := apitoken.Issue(h.db, uid, req.Name, req.Scopes, expiresAt)
+	switch {
+	case errors.Is(err, apitoken.ErrUnknownScope):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, apitoken.ErrTooMany):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create token"})
+	default:
+		c.JSON(http.StatusCreated, gin.H{"token": raw, "apiToken": newAPITokenView(token)})
+	}
+}
+
+// RevokeAPIToken revokes one of the caller's tokens.
+func (h *AuthHandler) RevokeAPIToken(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	tokenID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid token id"})
+		return
+	}
+	ok, err := apitoken.Revoke(h.db, uid, tokenID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke token"})
+		return
+	}
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "token not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "token revoked"})
+}
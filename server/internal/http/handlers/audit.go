@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/audit"
+	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/http/middleware"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// exportLimit caps the number of rows in a single CSV export.
+const exportLimit = 100000
+
+// maxAuditPage caps the page size of List.
+const maxAuditPage = 200
+
+// AuditHandler exposes the admin audit log.
+type AuditHandler struct {
+	db  *gorm.DB
+	cfg config.Config
+}
+
+func NewAuditHandler(db *gorm.DB, cfg config.Config) *AuditHandler {
+	return &AuditHandler{db: db, cfg: cfg}
+}
+
+type auditQuery struct {
+	ActorID    string    `form:"actorId"`
+	Action     string    `form:"action"`
+	TargetType string    `form:"targetType"`
+	TargetID   string    `form:"targetId"`
+	From       time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To         time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Limit      int       `form:"limit,default=50"`
+	Offset     int       `form:"offset,default=0"`
+}
+
+func (q auditQuery) apply(dbq *gorm.DB) *gorm.DB {
+	if q.ActorID != "" {
+		dbq = dbq.Where("actor_id = ?", q.ActorID)
+	}
+	if q.Action != "" {
+		dbq = dbq.Where("action = ?", q.Action)
+	}
+	if q.TargetType != "" {
+		dbq = dbq.Where("target_type = ?", q.TargetType)
+	}
+	if q.TargetID != "" {
+		dbq = dbq.Where("target_id = ?", q.TargetID)
+	}
+	if !q.From.IsZero() {
+		dbq = dbq.Where("created_at >= ?", q.From)
+	}
+	if !q.To.IsZero() {
+		dbq = dbq.Where("created_at < ?", q.To)
+	}
+	return dbq.Order("created_at DESC")
+}
+
+// List returns audit log entries matching the query filters.
+func (h *AuditHandler) List(c *gin.Context) {
+	var q auditQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if q.Limit <= 0 || q.Limit > maxAuditPage {
+		q.Limit = 50
+	}
+
+	var logs []models.AuditLog
+	if err := q.apply(h.db.Model(&models.AuditLog{})).Limit(q.Limit).Offset(q.Offset).Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	c.JSON(http.StatusOK, logs)
+}
+
+// Export streams the filtered audit log as CSV.
+func (h *AuditHandler) Export(c *gin.Context) {
+	var q auditQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rows, err := q.apply(h.db.Model(&models.AuditLog{})).Limit(exportLimit).Rows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	defer rows.Close()
+
+	filename := "audit-log-" + time.Now().Format("20060102-150405") + ".csv"
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"created_at", "actor_id", "actor", "action", "target_type", "target_id", "before", "after", "ip"})
+	for rows.Next() {
+		var l models.AuditLog
+		if err := h.db.ScanRows(rows, &l); err != nil {
+			log.Printf("audit export: scan row: %v", err)
+			abortStream(c)
+			return
+		}
+		actorID := ""
+		if l.ActorID != nil {
+			actorID = l.ActorID.String()
+		}
+		w.Write([]string{
+			l.CreatedAt.UTC().Format(time.RFC3339), actorID, l.Actor, l.Action,
+			l.TargetType, l.TargetID, l.Before, l.After, l.IP,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("audit export: %v", err)
+		abortStream(c)
+		return
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		log.Printf("audit export: write: %v", err)
+	}
+}
+
+// abortStream fails an export. Once the response is under way the connection
+// is dropped so the client sees a failed download, not a complete-looking file.
+func abortStream(c *gin.Context) {
+	if !c.Writer.Written() {
+		c.Writer.Header().Del("Content-Type")
+		c.Writer.Header().Del("Content-Disposition")
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "export failed"})
+		return
+	}
+	c.Abort()
+	if conn, _, err := c.Writer.Hijack(); err == nil {
+		conn.Close()
+	}
+}
+
+// recordAudit writes an audit entry for the authenticated actor of c using tx.
+func recordAudit(tx *gorm.DB, c *gin.Context, action, targetType, targetID string, before, after interface{}) error {
+	actorID, _ := middleware.UserID(c)
+	var actor models.User
+	if err := tx.Select("email").First(&actor, "id = ?", actorID).Error; err != nil {
+		return fmt.Errorf("load audit actor: %w", err)
+	}
+	return audit.Record(tx, audit.Entry{
+		ActorID:    actorID,
+		Actor:      actor.Email,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Before:     before,
+		After:      after,
+		IP:         c.ClientIP(),
+	})
+}
@@ -2,25 +2,38 @@ package handlers
 
 import (
 	"errors"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/A-Words/ne-resource-community/server/internal/apitoken"
+	"github.com/A-Words/ne-resource-community/server/internal/authn"
 	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/http/middleware"
 	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/A-Words/ne-resource-community/server/internal/secretbox"
+	"github.com/A-Words/ne-resource-community/server/internal/session"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 // AuthHandler provides registration and login endpoints.
 type AuthHandler struct {
-	db  *gorm.DB
-	cfg config.Config
+	db       *gorm.DB
+	cfg      config.Config
+	sessions *session.Manager
+	mail     tokenMailer
+	authn    authn.Authenticator
+	lockout  *authn.Lockout
+	totpBox  *secretbox.Box
 }
 
 func NewAuthHandler(db *gorm.DB, cfg config.Config) *AuthHandler {
-	return &AuthHandler{db: db, cfg: cfg}
+	return &AuthHandler{db: db, cfg: cfg, sessions: session.NewManager(db, cfg), mail: newTokenMailer(db, cfg), authn: authn.New(db, cfg), lockout: authn.NewLockout(db, cfg), totpBox: newTOTPBox(cfg)}
 }
 
 type registerRequest struct {
@@ -54,16 +67,20 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	token, err := h.issueToken(user)
+	if err := h.mail.sendVerification(c.Request.Context(), user); err != nil {
+		log.Printf("send verification to %s: %v", user.Email, err)
+	}
+
+	pair, err := h.sessions.Start(user, c.Request.UserAgent(), c.ClientIP())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to issue token"})
 		return
 	}
-	c.JSON(http.StatusCreated, gin.H{"token": token, "user": user})
+	c.JSON(http.StatusCreated, tokenResponse(pair, user))
 }
 
 type loginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
+	Email    string `json:"email" binding:"required"` // or a directory user name with LDAP
 	Password string `json:"password" binding:"required"`
 }
 
@@ -74,23 +91,48 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	var user models.User
-	if err := h.db.Where("email = ?", req.Email).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+	ctx := c.Request.Context()
+	if wait, locked := h.lockout.Locked(ctx, req.Email); locked {
+		accountLocked(c, wait)
 		return
 	}
-
-	if !user.CheckPassword(req.Password) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+	user, err := h.authn.Authenticate(ctx, req.Email, req.Password)
+	if errors.Is(err, authn.ErrLinkRequired) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		return
 	}
-
-	token, err := h.issueToken(user)
+	var identity *authn.IdentityError
+	switch {
+	case errors.As(err, &identity):
+		// Directory user names are not emails, so Locked above missed them.
+		if err := h.lockout.FailIdentity(ctx, identity); err != nil {
+			log.Printf("login %s: record failure: %v", req.Email, err)
+		}
+		if wait, locked := h.lockout.LockedIdentity(ctx, identity); locked {
+			accountLocked(c, wait)
+			return
+		}
+	case errors.Is(err, authn.ErrInvalidCredentials):
+		if err := h.lockout.FailLogin(ctx, req.Email); err != nil {
+			log.Printf("login %s: record failure: %v", req.Email, err)
+		}
+	case err != nil:
+		log.Printf("login %s: %v", req.Email, err)
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to issue token"})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		return
+	}
+	// Directory logins may use a name other than the email checked above.
+	if wait, locked := h.lockout.LockedUser(user); locked {
+		accountLocked(c, wait)
+		return
+	}
+	if user.IsSuspended(time.Now()) {
+		accountSuspended(c, user)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"token": token, "user": user})
+	h.completeLogin(c, user)
 }
 
 type changePasswordRequest struct {
@@ -127,22 +169,146 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	if err := h.db.Save(&user).Error; err != nil {
+	// A new password signs out every device and revokes API tokens; the
+	// caller gets a fresh session.
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("password_hash", user.PasswordHash).Error; err != nil {
+			return err
+		}
+		if err := apitoken.RevokeAll(tx, user.ID); err != nil {
+			return err
+		}
+		return session.Invalidate(tx, user.ID)
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update password"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "password updated successfully"})
+	user.TokenVersion++
+	pair, err := h.sessions.Start(user, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to issue token"})
+		return
+	}
+	resp := tokenResponse(pair, user)
+	resp["message"] = "password updated successfully"
+	c.JSON(http.StatusOK, resp)
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+// Refresh exchanges a refresh token for a new token pair.
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req refreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pair, err := h.sessions.Refresh(req.RefreshToken, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		if errors.Is(err, session.ErrInvalidToken) || errors.Is(err, session.ErrRevoked) || errors.Is(err, session.ErrReused) || errors.Is(err, session.ErrSuspended) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to refresh token"})
+		return
+	}
+	c.JSON(http.StatusOK, pair)
+}
+
+// Logout revokes the session of the given refresh token.
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req refreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.sessions.Logout(req.RefreshToken); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log out"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
+type sessionView struct {
+	models.Session
+	Current bool `json:"current"`
+}
+
+// ListSessions returns the caller's active sessions.
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	var sessions []models.Session
+	if err := h.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", uid, time.Now()).
+		Order("last_used_at DESC").Find(&sessions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+
+	current := middleware.SessionID(c)
+	views := make([]sessionView, 0, len(sessions))
+	for _, s := range sessions {
+		views = append(views, sessionView{Session: s, Current: s.ID == current})
+	}
+	c.JSON(http.StatusOK, views)
+}
+
+// RevokeSession signs out one of the caller's sessions.
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	sid, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+		return
+	}
+	if err := h.sessions.Revoke(uid, sid); err != nil {
+		if errors.Is(err, session.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke session"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "revoked"})
+}
+
+// RevokeOtherSessions signs out every session of the caller except the current one.
+func (h *AuthHandler) RevokeOtherSessions(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	if err := h.sessions.RevokeAll(uid, middleware.SessionID(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke sessions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "revoked"})
+}
+
+// accountLocked answers a login attempt against a locked account.
+func accountLocked(c *gin.Context, wait time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": "too many failed attempts, account temporarily locked", "retryAfter": int(math.Ceil(wait.Seconds()))})
+}
+
+// accountSuspended answers a login attempt by a suspended user.
+func accountSuspended(c *gin.Context, user models.User) {
+	resp := gin.H{"error": "account suspended", "reason": user.SuspendReason, "suspendedUntil": user.SuspendedUntil}
+	if user.SuspendedUntil.Equal(models.BannedUntil) {
+		resp["suspendedUntil"] = nil
+		resp["permanent"] = true
+	}
+	c.JSON(http.StatusForbidden, resp)
 }
 
-func (h *AuthHandler) issueToken(user models.User) (string, error) {
-	claims := jwt.MapClaims{
-		"sub":  user.ID.String(),
-		"role": user.Role,
-		"exp":  time.Now().Add(24 * time.Hour).Unix(),
+func tokenResponse(pair session.Pair, user models.User) gin.H {
+	return gin.H{
+		"token":        pair.AccessToken,
+		"refreshToken": pair.RefreshToken,
+		"expiresIn":    pair.ExpiresIn,
+		"user":         user,
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(h.cfg.JWTSecret))
 }
 
 func humanizeValidation(err error) string {
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/bounty"
+	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/feed"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const feedSize = 50
+
+type FeedHandler struct {
+	db  *gorm.DB
+	cfg config.Config
+}
+
+func NewFeedHandler(db *gorm.DB, cfg config.Config) *FeedHandler {
+	return &FeedHandler{db: db, cfg: cfg}
+}
+
+// Resources is a feed of the newest approved resources. It accepts the same
+// filters as the resource listing.
+func (h *FeedHandler) Resources(c *gin.Context) {
+	var q resourceQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var resources []models.Resource
+	if err := h.db.Preload("Uploader").
+		Where("status = ?", "approved").
+		Scopes(activeAuthor("uploader_id"), q.filters).
+		Order("approved_at DESC NULLS LAST, created_at DESC").
+		Limit(feedSize).
+		Find(&resources).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	h.write(c, feed.Feed{
+		Title:    "NE Resource Community: new resources",
+		Subtitle: describeFilters(q),
+		Link:     h.cfg.PublicBaseURL + "/",
+		Entries:  h.resourceEntries(resources),
+	})
+}
+
+// Versions is a feed of the published versions of one resource.
+func (h *FeedHandler) Versions(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid resource id"})
+		return
+	}
+	var current models.Resource
+	if err := h.db.Scopes(activeAuthor("uploader_id")).
+		First(&current, "id = ? AND status = ?", id, "approved").Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+
+	var versions []models.Resource
+	if err := h.db.Preload("Uploader").
+		Where("id IN (?)", gorm.Expr(models.VersionChain, current.ID)).
+		Where("status = ?", "approved").
+		Scopes(activeAuthor("uploader_id")).
+		Order("approved_at DESC NULLS LAST, created_at DESC").
+		Limit(feedSize).
+		Find(&versions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	h.write(c, feed.Feed{
+		Title:   fmt.Sprintf("NE Resource Community: versions of %s", current.Title),
+		Link:    h.cfg.PublicBaseURL + "/resources/" + current.ID.String(),
+		Entries: h.resourceEntries(versions),
+	})
+}
+
+// Requests is a feed of open requests, newest first. It accepts the search,
+// vendor, protocol and tag filters of the request listing.
+func (h *FeedHandler) Requests(c *gin.Context) {
+	var q requestQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	q.Status = bounty.StatusOpen
+	var requests []models.Request
+	if err := h.db.Preload("User").
+		Scopes(activeAuthor("user_id"), q.filters).
+		Order("created_at DESC").
+		Limit(feedSize).
+		Find(&requests).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+
+	entries := make([]feed.Entry, 0, len(requests))
+	for _, r := range requests {
+		title := r.Title
+		if r.Bounty > 0 {
+			title = fmt.Sprintf("%s (%d points)", r.Title, r.Bounty)
+		}
+		entries = append(entries, feed.Entry{
+			ID:         "urn:uuid:" + r.ID.String(),
+			Title:      title,
+			Link:       h.cfg.PublicBaseURL + "/requests?id=" + r.ID.String(),
+			Summary:    summarize(r.Description),
+			Author:     r.User.DisplayName,
+			Categories: categories(r.Vendor, r.Protocol, r.Tags),
+			Published:  r.CreatedAt,
+			Updated:    r.UpdatedAt,
+		})
+	}
+	h.write(c, feed.Feed{
+		Title:   "NE Resource Community: open requests",
+		Link:    h.cfg.PublicBaseURL + "/requests",
+		Entries: entries,
+	})
+}
+
+func (h *FeedHandler) resourceEntries(resources []models.Resource) []feed.Entry {
+	entries := make([]feed.Entry, 0, len(resources))
+	for _, r := range resources {
+		published := r.CreatedAt
+		if r.ApprovedAt != nil {
+			published = *r.ApprovedAt
+		}
+		title := r.Title
+		if r.ParentID != nil {
+			title = fmt.Sprintf("%s v%s", r.Title, r.Version)
+		}
+		entries = append(entries, feed.Entry{
+			ID:         "urn:uuid:" + r.ID.String(),
+			Title:      title,
+			Link:       h.cfg.PublicBaseURL + "/resources/" + r.ID.String(),
+			Summary:    summarize(r.Description),
+			Author:     r.Uploader.DisplayName,
+			Categories: categories(r.Type, r.Vendor, r.Protocol, r.Tags),
+			Published:  published,
+			Updated:    r.UpdatedAt,
+		})
+	}
+	return entries
+}
+
+// write renders f as RSS when format=rss and as Atom otherwise.
+func (h *FeedHandler) write(c *gin.Context, f feed.Feed) {
+	f.SelfLink = h.cfg.PublicBaseURL + c.Request.URL.RequestURI()
+	for _, e := range f.Entries {
+		if e.Updated.After(f.Updated) {
+			f.Updated = e.Updated
+		}
+	}
+	if f.Updated.IsZero() {
+		f.Updated = time.Now()
+	}
+
+	render, contentType := f.Atom, feed.ContentTypeAtom
+	if c.Query("format") == "rss" {
+		render, contentType = f.RSS, feed.ContentTypeRSS
+	}
+	body, err := render()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to render feed"})
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=300")
+	c.Data(http.StatusOK, contentType, body)
+}
+
+func describeFilters(q resourceQuery) string {
+	var parts []string
+	for _, f := range []struct{ name, value string }{
+		{"search", q.Search}, {"type", q.Type}, {"vendor", q.Vendor}, {"device", q.Device},
+		{"protocol", q.Protocol}, {"scenario", q.Scenario}, {"tag", q.Tag},
+	} {
+		if f.value != "" {
+			parts = append(parts, f.name+": "+f.value)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// categories turns metadata fields and comma separated tags into feed
+// categories.
+func categories(fields ...string) []string {
+	var out []string
+	for _, f := range fields {
+		for _, v := range strings.Split(f, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				out = append(out, v)
+			}
+		}
+	}
+	return out
+}
+
+func summarize(s string) string {
+	const limit = 500
+	r := []rune(strings.TrimSpace(s))
+	if len(r) <= limit {
+		return string(r)
+	}
+	return string(r[:limit]) + "…"
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/digest"
+	"github.com/A-Words/ne-resource-community/server/internal/http/middleware"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const maxFollows = 100
+
+type FollowHandler struct {
+	db  *gorm.DB
+	cfg config.Config
+}
+
+func NewFollowHandler(db *gorm.DB, cfg config.Config) *FollowHandler {
+	return &FollowHandler{db: db, cfg: cfg}
+}
+
+// List returns the topics the caller follows.
+func (h *FollowHandler) List(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	var follows []models.Follow
+	if err := h.db.Where("user_id = ?", uid).Order("kind, value").Find(&follows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	c.JSON(http.StatusOK, follows)
+}
+
+type followRequest struct {
+	Kind  string `json:"kind" binding:"required"`
+	Value string `json:"value" binding:"required,max=128"`
+}
+
+// Create follows a vendor, protocol, tag or scenario.
+func (h *FollowHandler) Create(c *gin.Context) {
+	var req followRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !digest.ValidKind(req.Kind) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be one of " + strings.Join(digest.Kinds, ", ")})
+		return
+	}
+	value := strings.TrimSpace(req.Value)
+	if value == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "value is required"})
+		return
+	}
+	if req.Kind == digest.KindTag {
+		value = strings.ToLower(value)
+	}
+
+	uid, _ := middleware.UserID(c)
+	var count int64
+	if err := h.db.Model(&models.Follow{}).Where("user_id = ?", uid).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	if count >= maxFollows {
+		c.JSON(http.StatusConflict, gin.H{"error": "too many follows"})
+		return
+	}
+	follow := models.Follow{UserID: uid, Kind: req.Kind, Value: value}
+	res := h.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&follow)
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to follow"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "already following"})
+		return
+	}
+	c.JSON(http.StatusCreated, follow)
+}
+
+// Delete unfollows a topic.
+func (h *FollowHandler) Delete(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid follow id"})
+		return
+	}
+	res := h.db.Where("id = ? AND user_id = ?", id, uid).Delete(&models.Follow{})
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unfollow"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "follow not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "unfollowed"})
+}
+
+// Digest returns the caller's digest frequency; users start with it off.
+func (h *FollowHandler) Digest(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	var sub models.DigestSubscription
+	err := h.db.First(&sub, "user_id = ?", uid).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusOK, models.DigestSubscription{Frequency: digest.FrequencyOff})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	c.JSON(http.StatusOK, sub)
+}
+
+type digestRequest struct {
+	Frequency string `json:"frequency" binding:"required,oneof=off daily weekly"`
+}
+
+// UpdateDigest sets how often the caller receives the digest.
+func (h *FollowHandler) UpdateDigest(c *gin.Context) {
+	var req digestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	uid, _ := middleware.UserID(c)
+	sub := models.DigestSubscription{UserID: uid, Frequency: req.Frequency}
+	if err := h.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"frequency", "updated_at"}),
+	}).Create(&sub).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save digest settings"})
+		return
+	}
+	h.Digest(c)
+}
+
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>退订摘要 / Unsubscribe</title></head>
+<body style="font-family: sans-serif; line-height: 1.6; max-width: 480px; margin: 40px auto;">
+{{if .Done}}
+  <p>已退订摘要邮件。/ You will no longer receive digest emails.</p>
+{{else if .Invalid}}
+  <p>退订链接无效。/ This unsubscribe link is invalid.</p>
+{{else}}
+  <p>确定不再接收资源摘要邮件吗？/ Stop receiving resource digest emails?</p>
+  <form method="post">
+    <input type="hidden" name="token" value="{{.Token}}">
+    <button type="submit">退订 / Unsubscribe</button>
+  </form>
+{{end}}
+</body>
+</html>`))
+
+type unsubscribeView struct {
+	Token   string
+	Done    bool
+	Invalid bool
+}
+
+// UnsubscribePage asks for confirmation so link scanners that prefetch the
+// email link do not unsubscribe anyone.
+func (h *FollowHandler) UnsubscribePage(c *gin.Context) {
+	token := c.Query("token")
+	_, err := digest.ParseUnsubscribeToken(digest.UnsubscribeKey(h.cfg), token, time.Now())
+	h.renderUnsubscribe(c, http.StatusOK, unsubscribeView{Token: token, Invalid: err != nil})
+}
+
+// Unsubscribe turns off the digest for the token's user. It serves both the
+// confirmation form and RFC 8058 one-click requests from mail clients.
+func (h *FollowHandler) Unsubscribe(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		token = c.PostForm("token")
+	}
+	uid, err := digest.ParseUnsubscribeToken(digest.UnsubscribeKey(h.cfg), token, time.Now())
+	if err != nil {
+		h.renderUnsubscribe(c, http.StatusBadRequest, unsubscribeView{Invalid: true})
+		return
+	}
+	if err := digest.Unsubscribe(h.db, uid); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unsubscribe"})
+		return
+	}
+	h.renderUnsubscribe(c, http.StatusOK, unsubscribeView{Done: true})
+}
+
+func (h *FollowHandler) renderUnsubscribe(c *gin.Context, status int, view unsubscribeView) {
+	c.Status(status)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	if err := unsubscribePage.Execute(c.Writer, view); err != nil {
+		c.Error(err)
+	}
+}
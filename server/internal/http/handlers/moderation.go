@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/http/middleware"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/A-Words/ne-resource-community/server/internal/moderation"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ModerationHandler serves the reviewer work queue.
+type ModerationHandler struct {
+	db  *gorm.DB
+	cfg config.Config
+}
+
+func NewModerationHandler(db *gorm.DB, cfg config.Config) *ModerationHandler {
+	return &ModerationHandler{db: db, cfg: cfg}
+}
+
+type queueQuery struct {
+	Kind      string `form:"kind"`      // resource, report
+	Mine      bool   `form:"mine"`      // only items claimed by the caller
+	Escalated bool   `form:"escalated"` // only items past the SLA
+	Limit     int    `form:"limit,default=50"`
+	Offset    int    `form:"offset,default=0"`
+}
+
+// maxQueuePage caps the page size of Queue.
+const maxQueuePage = 200
+
+type queueEntry struct {
+	models.ModerationItem
+	Resource *models.Resource `json:"resource,omitempty"`
+	Report   *models.Report   `json:"report,omitempty"`
+}
+
+// Queue lists open moderation items, highest priority and oldest first.
+func (h *ModerationHandler) Queue(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	var q queueQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if q.Limit <= 0 || q.Limit > maxQueuePage {
+		q.Limit = 50
+	}
+
+	dbq := h.db.Model(&models.ModerationItem{}).Where("status = ?", "open")
+	if q.Kind != "" {
+		dbq = dbq.Where("kind = ?", q.Kind)
+	}
+	if q.Mine {
+		dbq = dbq.Where("claimed_by_id = ? AND claim_expires_at > ?", uid, time.Now())
+	}
+	if q.Escalated {
+		dbq = dbq.Where("escalated = ?", true)
+	}
+
+	var items []models.ModerationItem
+	if err := dbq.Order("priority DESC, created_at ASC").Limit(q.Limit).Offset(q.Offset).Find(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+
+	var resourceIDs, reportIDs []uuid.UUID
+	for _, it := range items {
+		switch it.Kind {
+		case moderation.KindResource:
+			resourceIDs = append(resourceIDs, it.TargetID)
+		case moderation.KindReport:
+			reportIDs = append(reportIDs, it.TargetID)
+		}
+	}
+	resources := map[uuid.UUID]*models.Resource{}
+	if len(resourceIDs) > 0 {
+		var rs []models.Resource
+		h.db.Preload("Uploader").Where("id IN ?", resourceIDs).Find(&rs)
+		for i := range rs {
+			resources[rs[i].ID] = &rs[i]
+		}
+	}
+	reports := map[uuid.UUID]*models.Report{}
+	if len(reportIDs) > 0 {
+		var rs []models.Report
+		h.db.Preload("Resource").Preload("User").Where("id IN ?", reportIDs).Find(&rs)
+		for i := range rs {
+			reports[rs[i].ID] = &rs[i]
+		}
+	}
+
+	entries := make([]queueEntry, 0, len(items))
+	for _, it := range items {
+		entries = append(entries, queueEntry{ModerationItem: it, Resource: resources[it.TargetID], Report: reports[it.TargetID]})
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// Claim locks a queue item for the caller.
+func (h *ModerationHandler) Claim(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	itemID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid item id"})
+		return
+	}
+
+	item, err := moderation.Claim(h.db, itemID, uid, h.cfg.ModerationClaimTTL)
+	switch {
+	case errors.Is(err, moderation.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	case errors.Is(err, moderation.ErrClaimedByOther):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "item": item})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to claim item"})
+	default:
+		c.JSON(http.StatusOK, item)
+	}
+}
+
+// Release gives up the caller's claim on a queue item.
+func (h *ModerationHandler) Release(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	itemID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid item id"})
+		return
+	}
+
+	if err := moderation.Release(h.db, itemID, uid); err != nil {
+		if errors.Is(err, moderation.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no claim held on this item"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to release item"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "released"})
+}
+
+// Stats returns queue size, per-reviewer throughput and turnaround metrics.
+func (h *ModerationHandler) Stats(c *gin.Context) {
+	var q struct {
+		Days int `form:"days,default=30" binding:"min=1"`
+	}
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stats, err := moderation.Stats(h.db, time.Now().AddDate(0, 0, -q.Days))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// moderationError maps queue errors raised while handling an item.
+func moderationError(c *gin.Context, err error, fallback string) {
+	if errors.Is(err, moderation.ErrClaimedByOther) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/events"
+	"github.com/A-Words/ne-resource-community/server/internal/http/middleware"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/A-Words/ne-resource-community/server/internal/notify"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NotificationHandler serves the caller's in-app notifications.
+type NotificationHandler struct {
+	db  *gorm.DB
+	cfg config.Config
+}
+
+func NewNotificationHandler(db *gorm.DB, cfg config.Config) *NotificationHandler {
+	return &NotificationHandler{db: db, cfg: cfg}
+}
+
+type notificationQuery struct {
+	Unread bool   `form:"unread"`
+	Type   string `form:"type"`
+	Limit  int    `form:"limit,default=20"`
+	Offset int    `form:"offset,default=0"`
+}
+
+// List returns the caller's notifications, newest first.
+func (h *NotificationHandler) List(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	var q notificationQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if q.Limit <= 0 || q.Limit > 100 {
+		q.Limit = 20
+	}
+
+	dbq := h.db.Model(&models.Notification{}).Where("user_id = ?", uid)
+	if q.Unread {
+		dbq = dbq.Where("read_at IS NULL")
+	}
+	if q.Type != "" {
+		dbq = dbq.Where("type = ?", q.Type)
+	}
+	var total int64
+	if err := dbq.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	var items []models.Notification
+	if err := dbq.Order("created_at DESC").Limit(q.Limit).Offset(q.Offset).Find(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "items": items})
+}
+
+// UnreadCount returns how many notifications the caller has not read.
+func (h *NotificationHandler) UnreadCount(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	var count int64
+	if err := h.db.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", uid).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"unread": count})
+}
+
+// MarkRead marks one of the caller's notifications as read.
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	res := h.db.Model(&models.Notification{}).
+		Where("id = ? AND user_id = ?", c.Param("id"), uid).
+		Update("read_at", gorm.Expr("COALESCE(read_at, ?)", time.Now()))
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update notification"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "notification not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "marked as read"})
+}
+
+// MarkAllRead marks every unread notification of the caller as read.
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	res := h.db.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", uid).
+		Update("read_at", time.Now())
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update notifications"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"updated": res.RowsAffected})
+}
+
+type notificationPreference struct {
+	events.TypeInfo
+	Enabled bool `json:"enabled"`
+}
+
+// Preferences lists every notification type with the caller's choice.
+func (h *NotificationHandler) Preferences(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	h.writePreferences(c, uid)
+}
+
+// UpdatePreferences turns notification types on or off, e.g.
+// {"resource.reviewed": false}. Mandatory types cannot be turned off.
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	var req map[string]bool
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	for kind := range req {
+		if !events.Optional(kind) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "notification type cannot be changed: " + kind})
+			return
+		}
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		for kind, enabled := range req {
+			if err := notify.SetPreference(tx, uid, kind, enabled); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save preferences"})
+		return
+	}
+	h.writePreferences(c, uid)
+}
+
+func (h *NotificationHandler) writePreferences(c *gin.Context, uid uuid.UUID) {
+	chosen, err := notify.Preferences(h.db, uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load preferences"})
+		return
+	}
+	out := make([]notificationPreference, 0, len(events.Types))
+	for _, info := range events.Types {
+		enabled, ok := chosen[info.Type]
+		out = append(out, notificationPreference{TypeInfo: info, Enabled: !ok || enabled || !info.Optional})
+	}
+	c.JSON(http.StatusOK, out)
+}
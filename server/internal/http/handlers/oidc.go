@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/authn"
+	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/A-Words/ne-resource-community/server/internal/oidc"
+	"github.com/A-Words/ne-resource-community/server/internal/session"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// oauthStateTTL bounds how long a user may take at the IdP login page.
+const oauthStateTTL = 10 * time.Minute
+
+var (
+	errEmailNotVerified = errors.New("identity provider did not verify the email address")
+	errLinkRequired     = errors.New("a local account with this email already exists")
+)
+
+// Error codes sent to the frontend callback page. IdP and database messages
+// are only logged; the redirect URL ends up in browser history.
+const (
+	oidcErrDenied           = "access_denied"
+	oidcErrExpired          = "login_expired"
+	oidcErrVerification     = "verification_failed"
+	oidcErrEmailNotVerified = "email_not_verified"
+	oidcErrSuspended        = "account_suspended"
+	oidcErrLinkRequired     = "link_required"
+	oidcErrServer           = "server_error"
+)
+
+// OIDCHandler implements single sign-on through OpenID Connect providers.
+type OIDCHandler struct {
+	db        *gorm.DB
+	cfg       config.Config
+	sessions  *session.Manager
+	providers map[string]*oidc.Provider
+	order     []string
+}
+
+func NewOIDCHandler(db *gorm.DB, cfg config.Config) *OIDCHandler {
+	h := &OIDCHandler{db: db, cfg: cfg, sessions: session.NewManager(db, cfg), providers: map[string]*oidc.Provider{}}
+	for _, p := range cfg.OIDCProviders {
+		h.providers[p.Name] = oidc.NewProvider(p, nil)
+		h.order = append(h.order, p.Name)
+	}
+	return h
+}
+
+// Providers lists the configured identity providers for the login page.
+func (h *OIDCHandler) Providers(c *gin.Context) {
+	list := make([]gin.H, 0, len(h.order))
+	for _, name := range h.order {
+		p := h.providers[name].Config()
+		display := p.DisplayName
+		if display == "" {
+			display = p.Name
+		}
+		list = append(list, gin.H{"name": p.Name, "displayName": display, "loginUrl": "/api/auth/oidc/" + p.Name + "/login"})
+	}
+	c.JSON(http.StatusOK, list)
+}
+
+// Login starts the authorization code flow with PKCE.
+func (h *OIDCHandler) Login(c *gin.Context) {
+	p, ok := h.providers[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown identity provider"})
+		return
+	}
+
+	st := models.OAuthState{
+		State:        oidc.RandomString(),
+		Provider:     p.Config().Name,
+		Nonce:        oidc.RandomString(),
+		CodeVerifier: oidc.RandomString(),
+		ExpiresAt:    time.Now().Add(oauthStateTTL),
+	}
+	authURL, err := p.AuthCodeURL(c.Request.Context(), st.State, st.Nonce, st.CodeVerifier)
+	if err != nil {
+		log.Printf("oidc login %s: %v", st.Provider, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "identity provider unavailable"})
+		return
+	}
+	if err := h.db.Create(&st).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to start login"})
+		return
+	}
+	// Opportunistically drop abandoned flows.
+	h.db.Where("expires_at < ?", time.Now()).Delete(&models.OAuthState{})
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback finishes the flow, links or provisions the local account and sends
+// the browser back to the frontend with tokens in the URL fragment.
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	p, ok := h.providers[c.Param("provider")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown identity provider"})
+		return
+	}
+	if e := c.Query("error"); e != "" {
+		log.Printf("oidc callback %s: %s: %s", p.Config().Name, e, c.Query("error_description"))
+		h.fail(c, oidcErrDenied)
+		return
+	}
+
+	var states []models.OAuthState
+	err := h.db.Clauses(clause.Returning{}).
+		Where("state = ? AND provider = ? AND expires_at > ?", c.Query("state"), p.Config().Name, time.Now()).
+		Delete(&states).Error
+	if err != nil || len(states) == 0 {
+		if err != nil {
+			log.Printf("oidc callback %s: load state: %v", p.Config().Name, err)
+		}
+		h.fail(c, oidcErrExpired)
+		return
+	}
+	st := states[0]
+
+	identity, err := p.Exchange(c.Request.Context(), c.Query("code"), st.CodeVerifier, st.Nonce)
+	if err != nil {
+		log.Printf("oidc callback %s: %v", st.Provider, err)
+		h.fail(c, oidcErrVerification)
+		return
+	}
+
+	user, err := h.linkUser(p, identity)
+	if errors.Is(err, errEmailNotVerified) {
+		h.fail(c, oidcErrEmailNotVerified)
+		return
+	}
+	if errors.Is(err, errLinkRequired) {
+		h.fail(c, oidcErrLinkRequired)
+		return
+	}
+	if err != nil {
+		log.Printf("oidc link %s/%s: %v", st.Provider, identity.Subject, err)
+		h.fail(c, oidcErrServer)
+		return
+	}
+	if user.IsSuspended(time.Now()) {
+		h.fail(c, oidcErrSuspended)
+		return
+	}
+
+	// Accounts with 2FA continue at /auth/2fa/verify like a password login.
+	if user.TwoFactorEnabled() {
+		challenge, err := h.sessions.Challenge(user)
+		if err != nil {
+			log.Printf("oidc challenge %s: %v", user.ID, err)
+			h.fail(c, oidcErrServer)
+			return
+		}
+		h.redirect(c, url.Values{"mfaRequired": {"true"}, "mfaToken": {challenge}})
+		return
+	}
+	pair, err := h.sessions.Start(user, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		log.Printf("oidc session %s: %v", user.ID, err)
+		h.fail(c, oidcErrServer)
+		return
+	}
+	h.redirect(c, url.Values{
+		"token":        {pair.AccessToken},
+		"refreshToken": {pair.RefreshToken},
+		"expiresIn":    {strconv.Itoa(pair.ExpiresIn)},
+	})
+}
+
+// linkUser resolves the local user for an IdP identity: an existing link,
+// else a new account. An existing account with the same email is only linked
+// when the provider sets linkByEmail, the local address was verified and the
+// account is not staff; otherwise the login is refused with errLinkRequired,
+// as for LDAP. Group based role mapping is applied on every login.
+func (h *OIDCHandler) linkUser(p *oidc.Provider, id oidc.Identity) (models.User, error) {
+	provider := p.Config().Name
+	var user models.User
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var link models.UserIdentity
+		err := tx.Where("provider = ? AND subject = ?", provider, id.Subject).First(&link).Error
+		switch {
+		case err == nil:
+			if err := tx.First(&user, "id = ?", link.UserID).Error; err != nil {
+				return err
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if id.Email == "" || !id.EmailVerified {
+				return errEmailNotVerified
+			}
+			err := tx.Where("LOWER(email) = ?", strings.ToLower(id.Email)).First(&user).Error
+			provisioned := false
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				now := time.Now()
+				user = models.User{Email: id.Email, DisplayName: displayNameOf(id), Role: models.RoleUser, EmailVerifiedAt: &now}
+				if err := tx.Create(&user).Error; err != nil {
+					return err
+				}
+				provisioned = true
+			case err != nil:
+				return err
+			case !p.Config().LinkByEmail || user.EmailVerifiedAt == nil || user.Role != models.RoleUser:
+				log.Printf("oidc: refusing to link %s/%s to existing account %s", provider, id.Subject, user.ID)
+				return errLinkRequired
+			}
+			link = models.UserIdentity{UserID: user.ID, Provider: provider, Subject: id.Subject, Email: id.Email, Provisioned: provisioned}
+			if err := tx.Create(&link).Error; err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+		if err := tx.Model(&link).Update("last_login_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		role, mapped := p.MapRole(id.Groups)
+		if !mapped {
+			return nil
+		}
+		return authn.SyncRole(tx, link, &user, role, "oidc:"+provider, id.Groups)
+	})
+	return user, err
+}
+
+// redirect sends the browser to the frontend callback page. Tokens travel in
+// the fragment so they never reach server logs.
+func (h *OIDCHandler) redirect(c *gin.Context, v url.Values) {
+	c.Redirect(http.StatusFound, h.cfg.PublicBaseURL+"/auth/callback#"+v.Encode())
+}
+
+// fail redirects with one of the oidcErr codes.
+func (h *OIDCHandler) fail(c *gin.Context, code string) {
+	h.redirect(c, url.Values{"error": {code}})
+}
+
+func displayNameOf(id oidc.Identity) string {
+	if id.Name != "" {
+		return id.Name
+	}
+	if at := strings.Index(id.Email, "@"); at > 0 {
+		return id.Email[:at]
+	}
+	return id.Email
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/A-Words/ne-resource-community/server/internal/audit"
+	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/http/middleware"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/A-Words/ne-resource-community/server/internal/points"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type PointsHandler struct {
+	db  *gorm.DB
+	cfg config.Config
+}
+
+func NewPointsHandler(db *gorm.DB, cfg config.Config) *PointsHandler {
+	return &PointsHandler{db: db, cfg: cfg}
+}
+
+// History returns the caller's balance and ledger entries, newest first.
+func (h *PointsHandler) History(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	var q pageQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if q.Limit <= 0 || q.Limit > 100 {
+		q.Limit = 50
+	}
+
+	var user models.User
+	if err := h.db.Select("id", "points").First(&user, "id = ?", uid).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	var total int64
+	var entries []models.PointEntry
+	query := h.db.Model(&models.PointEntry{}).Where("user_id = ?", uid)
+	if reason := c.Query("reason"); reason != "" {
+		query = query.Where("reason = ?", reason)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	if err := query.Order("created_at DESC, id").Limit(q.Limit).Offset(q.Offset).Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"balance": user.Points, "total": total, "entries": entries})
+}
+
+// CheckBalances lists users whose cached balance disagrees with the ledger.
+func (h *PointsHandler) CheckBalances(c *gin.Context) {
+	mismatches, err := points.Check(h.db)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"mismatches": mismatches})
+}
+
+// Reconcile resets mismatched cached balances to their ledger sums.
+func (h *PointsHandler) Reconcile(c *gin.Context) {
+	var fixed []points.Mismatch
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if fixed, err = points.Reconcile(tx); err != nil || len(fixed) == 0 {
+			return err
+		}
+		return recordAudit(tx, c, audit.ActionPointsReconcile, "points", "", nil, gin.H{"fixed": fixed})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reconcile balances"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"fixed": fixed})
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"bytes"
+	"image"
+	_ "image/gif" // register decoders for avatar validation
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/http/middleware"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/A-Words/ne-resource-community/server/internal/profile"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Avatars are stored in AvatarDir below the upload directory and served
+// statically under AvatarURLPrefix.
+const (
+	AvatarDir       = "avatars"
+	AvatarURLPrefix = "/uploads/" + AvatarDir + "/"
+)
+
+const avatarMaxSide = 2048
+
+var avatarExts = map[string]string{"png": ".png", "jpeg": ".jpg", "gif": ".gif"}
+
+// ProfileHandler serves public profiles and lets users edit their own.
+type ProfileHandler struct {
+	db  *gorm.DB
+	cfg config.Config
+}
+
+func NewProfileHandler(db *gorm.DB, cfg config.Config) *ProfileHandler {
+	return &ProfileHandler{db: db, cfg: cfg}
+}
+
+// Get returns the public profile of a user.
+func (h *ProfileHandler) Get(c *gin.Context) {
+	var user models.User
+	err := h.db.Scopes(activeAuthor("id")).First(&user, "id = ?", c.Param("id")).Error
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	contrib, err := profile.Load(h.db, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"profile": user.Public(), "contributions": contrib, "badges": profile.Badges(user, contrib)})
+}
+
+// Uploads lists the approved resources of a user.
+func (h *ProfileHandler) Uploads(c *gin.Context) {
+	var q pageQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if q.Limit <= 0 || q.Limit > 100 {
+		q.Limit = 50
+	}
+	var resources []models.Resource
+	err := h.db.Preload("Uploader").Scopes(activeAuthor("uploader_id")).
+		Where("uploader_id = ? AND status = ?", c.Param("id"), "approved").
+		Order("created_at DESC").Limit(q.Limit).Offset(q.Offset).
+		Find(&resources).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	c.JSON(http.StatusOK, resources)
+}
+
+// Me returns the caller's full profile, including private fields.
+func (h *ProfileHandler) Me(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	var user models.User
+	if err := h.db.First(&user, "id = ?", uid).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	contrib, err := profile.Load(h.db, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user": user, "contributions": contrib, "badges": profile.Badges(user, contrib)})
+}
+
+type profileUpdateRequest struct {
+	DisplayName *string `json:"displayName" binding:"omitempty,min=1,max=64"`
+	Bio         *string `json:"bio" binding:"omitempty,max=500"`
+}
+
+// Update edits the caller's display name and bio. Omitted fields are kept.
+func (h *ProfileHandler) Update(c *gin.Context) {
+	var req profileUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	updates := map[string]interface{}{}
+	if req.DisplayName != nil {
+		name := strings.TrimSpace(*req.DisplayName)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "display name cannot be blank"})
+			return
+		}
+		updates["display_name"] = name
+	}
+	if req.Bio != nil {
+		updates["bio"] = strings.TrimSpace(*req.Bio)
+	}
+
+	uid, _ := middleware.UserID(c)
+	var user models.User
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if len(updates) > 0 {
+			if err := tx.Model(&models.User{}).Where("id = ?", uid).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		return tx.First(&user, "id = ?", uid).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update profile"})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+// UploadAvatar replaces the caller's avatar with a PNG, JPEG or GIF image.
+func (h *ProfileHandler) UploadAvatar(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	file, err := c.FormFile("avatar")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "avatar file is required"})
+		return
+	}
+	if file.Size > h.cfg.AvatarMaxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "avatar is too large"})
+		return
+	}
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open file"})
+		return
+	}
+	defer src.Close()
+	data, err := io.ReadAll(io.LimitReader(src, h.cfg.AvatarMaxBytes+1))
+	if err != nil || int64(len(data)) > h.cfg.AvatarMaxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "avatar is too large"})
+		return
+	}
+
+	// Trust the decoded image, not the file name or declared content type.
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	ext, ok := avatarExts[format]
+	if err != nil || !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "avatar must be a PNG, JPEG or GIF image"})
+		return
+	}
+	if cfg.Width > avatarMaxSide || cfg.Height > avatarMaxSide {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "avatar dimensions must not exceed 2048x2048"})
+		return
+	}
+
+	dir := filepath.Join(h.cfg.UploadDir, AvatarDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save avatar"})
+		return
+	}
+	name := uuid.NewString() + ext
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save avatar"})
+		return
+	}
+	h.setAvatar(c, uid, AvatarURLPrefix+name)
+}
+
+// DeleteAvatar removes the caller's avatar.
+func (h *ProfileHandler) DeleteAvatar(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	h.setAvatar(c, uid, "")
+}
+
+// setAvatar stores url as the avatar of uid and removes the previous file.
+func (h *ProfileHandler) setAvatar(c *gin.Context, uid uuid.UUID, url string) {
+	var user models.User
+	if err := h.db.First(&user, "id = ?", uid).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	previous := user.AvatarURL
+	if err := h.db.Model(&user).Update("avatar_url", url).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update avatar"})
+		return
+	}
+	user.AvatarURL = url
+	if strings.HasPrefix(previous, AvatarURLPrefix) {
+		os.Remove(filepath.Join(h.cfg.UploadDir, AvatarDir, path.Base(previous)))
+	}
+	c.JSON(http.StatusOK, user)
+}
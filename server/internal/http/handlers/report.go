@@ -0,0 +1,471 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/audit"
+	"github.com/A-Words/ne-resource-community/server/internal/events"
+	"github.com/A-Words/ne-resource-community/server/internal/http/middleware"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/A-Words/ne-resource-community/server/internal/moderation"
+	"github.com/A-Words/ne-resource-community/server/internal/watch"
+	"github.com/A-Words/ne-resource-community/server/internal/webhook"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Report resolution actions.
+const (
+	resolveDismiss      = "dismiss"
+	resolveUnpublish    = "unpublish"
+	resolveEditMetadata = "edit_metadata"
+	resolveWarn         = "warn"
+	resolveSuspend      = "suspend"
+)
+
+type reportReq struct {
+	Category string `json:"category" binding:"omitempty,oneof=malware copyright wrong_metadata broken_link spam leaked_credentials other"`
+	Reason   string `json:"reason"`
+}
+
+// isUniqueViolation reports whether err comes from a unique index.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.Is(err, gorm.ErrDuplicatedKey) || (errors.As(err, &pgErr) && pgErr.Code == "23505")
+}
+
+// ReportResource allows users to report a resource.
+func (h *ResourceHandler) ReportResource(c *gin.Context) {
+	uid, ok := middleware.UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	id := c.Param("id")
+	rid, err := uuid.Parse(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid resource id"})
+		return
+	}
+
+	var req reportReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Category == "" {
+		req.Category = models.ReportOther
+	}
+	if req.Category == models.ReportOther && req.Reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required"})
+		return
+	}
+
+	var resource models.Resource
+	if err := h.db.First(&resource, "id = ?", rid).Error; err != nil || !visible(c, resource) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+
+	var count int64
+	h.db.Model(&models.Report{}).Where("user_id = ? AND resource_id = ? AND status = ?", uid, rid, "pending").Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "you have already reported this resource"})
+		return
+	}
+
+	report := models.Report{
+		UserID:     uid,
+		ResourceID: rid,
+		Category:   req.Category,
+		Reason:     req.Reason,
+		Status:     "pending",
+	}
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&report).Error; err != nil {
+			return err
+		}
+		if err := moderation.Enqueue(tx, moderation.KindReport, report.ID, moderation.ReportPriority(report.Category)); err != nil {
+			return err
+		}
+		if err := h.hideIfOverThreshold(tx, &resource); err != nil {
+			return err
+		}
+		hooked := report
+		hooked.Resource = resource
+		return webhook.Enqueue(tx, webhook.EventReportCreated, hooked)
+	})
+	if isUniqueViolation(err) {
+		// A concurrent request filed the same report first.
+		c.JSON(http.StatusConflict, gin.H{"error": "you have already reported this resource"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to submit report"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "report submitted"})
+}
+
+// hideIfOverThreshold takes an approved resource offline once enough distinct
+// users have pending reports against it. A moderator decides on restoring it.
+func (h *ResourceHandler) hideIfOverThreshold(tx *gorm.DB, resource *models.Resource) error {
+	if h.cfg.ReportHideThreshold <= 0 || resource.Status != "approved" {
+		return nil
+	}
+	var reporters int64
+	if err := tx.Model(&models.Report{}).
+		Where("resource_id = ? AND status = ?", resource.ID, "pending").
+		Distinct("user_id").Count(&reporters).Error; err != nil {
+		return err
+	}
+	if reporters < int64(h.cfg.ReportHideThreshold) {
+		return nil
+	}
+
+	if err := tx.Model(resource).Update("status", "hidden").Error; err != nil {
+		return err
+	}
+	if err := audit.Record(tx, audit.Entry{
+		Actor:      "system:report-threshold",
+		Action:     audit.ActionResourceAutoHide,
+		TargetType: "resource",
+		TargetID:   resource.ID.String(),
+		Before:     gin.H{"status": "approved"},
+		After:      gin.H{"status": "hidden", "reporters": reporters},
+	}); err != nil {
+		return err
+	}
+	body := fmt.Sprintf("\"%s\" received %d reports and is hidden until a moderator reviews it.", resource.Title, reporters)
+	return events.Publish(tx, events.Event{
+		Type:       events.TypeResourceHidden,
+		Recipients: []uuid.UUID{resource.UploaderID},
+		Title:      "Resource hidden pending review",
+		Body:       body,
+		RefType:    "resource",
+		RefID:      resource.ID,
+	})
+}
+
+// AdminListReports returns all pending reports.
+func (h *ResourceHandler) AdminListReports(c *gin.Context) {
+	var reports []models.Report
+	// Preload Resource and User to show details
+	dbq := h.db.Preload("Resource").Preload("User").Where("status = ?", "pending")
+	if category := c.Query("category"); category != "" {
+		dbq = dbq.Where("category = ?", category)
+	}
+	if err := dbq.Order("created_at ASC").Find(&reports).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch reports"})
+		return
+	}
+	c.JSON(http.StatusOK, reports)
+}
+
+type metadataPatch struct {
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	Type        *string `json:"type"`
+	Vendor      *string `json:"vendor"`
+	DeviceModel *string `json:"deviceModel"`
+	Protocol    *string `json:"protocol"`
+	Scenario    *string `json:"scenario"`
+	Tags        *string `json:"tags"`
+}
+
+// columns returns the non-nil fields as a column update map.
+func (p metadataPatch) columns() map[string]interface{} {
+	cols := map[string]interface{}{}
+	set := func(col string, v *string) {
+		if v != nil {
+			cols[col] = *v
+		}
+	}
+	set("title", p.Title)
+	set("description", p.Description)
+	set("type", p.Type)
+	set("vendor", p.Vendor)
+	set("device_model", p.DeviceModel)
+	set("protocol", p.Protocol)
+	set("scenario", p.Scenario)
+	set("tags", p.Tags)
+	return cols
+}
+
+// notifyMetadataChange tells a resource's watchers which fields changed.
+func notifyMetadataChange(tx *gorm.DB, resource models.Resource, cols map[string]interface{}) error {
+	fields := make([]string, 0, len(cols))
+	for col := range cols {
+		fields = append(fields, strings.ReplaceAll(col, "_", " "))
+	}
+	sort.Strings(fields)
+	return watch.Notify(tx, resource.ID, resource.UploaderID, events.Event{
+		Type:    events.TypeWatchUpdated,
+		Title:   "Watched resource updated",
+		Body:    fmt.Sprintf("The %s of \"%s\" changed.", strings.Join(fields, ", "), resource.Title),
+		RefType: "resource",
+		RefID:   resource.ID,
+	})
+}
+
+type resolveReportReq struct {
+	Action      string         `json:"action" binding:"omitempty,oneof=dismiss unpublish edit_metadata warn suspend"`
+	Note        string         `json:"note"`
+	Metadata    *metadataPatch `json:"metadata"`    // edit_metadata only
+	SuspendDays int            `json:"suspendDays"` // suspend only, defaults to 7
+}
+
+// maxSuspendDays caps suspensions handed out through report resolution;
+// longer ones and bans go through the admin user routes.
+const maxSuspendDays = 90
+
+var (
+	errReportSettled = errors.New("report was already settled")
+	errStaffTarget   = errors.New("only admins can suspend moderators and admins")
+)
+
+// AdminResolveReport settles a report with an enforcement action. The action
+// applies to every pending report on the same resource; all changes commit
+// together and each reporter is told the outcome.
+func (h *ResourceHandler) AdminResolveReport(c *gin.Context) {
+	var req resolveReportReq
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Action == "" {
+		req.Action = resolveDismiss
+	}
+	if req.Action == resolveEditMetadata && (req.Metadata == nil || len(req.Metadata.columns()) == 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "metadata is required for edit_metadata"})
+		return
+	}
+	if req.SuspendDays <= 0 {
+		req.SuspendDays = 7
+	}
+	if req.SuspendDays > maxSuspendDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("suspendDays must be at most %d", maxSuspendDays)})
+		return
+	}
+
+	id := c.Param("id")
+	var report models.Report
+	if err := h.db.First(&report, "id = ?", id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+
+	reviewerID, _ := middleware.UserID(c)
+	var resource models.Resource
+	now := time.Now()
+	status := "resolved"
+	if req.Action == resolveDismiss {
+		status = "dismissed"
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the resource, then the report, so concurrent resolutions of
+		// this report or its siblings run one after the other.
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&resource, "id = ?", report.ResourceID).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&report, "id = ?", report.ID).Error; err != nil {
+			return err
+		}
+		if report.Status != "pending" {
+			return errReportSettled
+		}
+		before := gin.H{"status": report.Status, "resourceStatus": resource.Status}
+
+		if err := moderation.Complete(tx, moderation.KindReport, report.ID, reviewerID, req.Action); err != nil {
+			return err
+		}
+
+		var siblings []models.Report
+		if err := tx.Where("resource_id = ? AND status = ?", resource.ID, "pending").Find(&siblings).Error; err != nil {
+			return err
+		}
+		ids := make([]uuid.UUID, 0, len(siblings))
+		for _, r := range siblings {
+			ids = append(ids, r.ID)
+		}
+		if err := moderation.CompleteMany(tx, moderation.KindReport, ids, reviewerID, req.Action); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Report{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"status":          status,
+			"resolution":      req.Action,
+			"resolution_note": req.Note,
+			"resolved_by_id":  reviewerID,
+			"resolved_at":     now,
+		}).Error; err != nil {
+			return err
+		}
+
+		if err := h.applyResolution(tx, c, &resource, req); err != nil {
+			return err
+		}
+
+		after := gin.H{"status": status, "action": req.Action, "note": req.Note, "resourceStatus": resource.Status, "reports": len(ids)}
+		if err := recordAudit(tx, c, audit.ActionReportResolve, "report", report.ID.String(), before, after); err != nil {
+			return err
+		}
+
+		body := fmt.Sprintf("Your report on \"%s\" was reviewed. Outcome: %s.", resource.Title, resolutionText(req.Action))
+		if req.Note != "" {
+			body += " " + req.Note
+		}
+		for _, r := range siblings {
+			if err := events.Publish(tx, events.Event{
+				Type:       events.TypeReportResolved,
+				Recipients: []uuid.UUID{r.UserID},
+				Title:      "Your report was reviewed",
+				Body:       body,
+				RefType:    "report",
+				RefID:      r.ID,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, errReportSettled):
+		c.JSON(http.StatusConflict, gin.H{"error": "report is already " + report.Status})
+		return
+	case errors.Is(err, errStaffTarget):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		moderationError(c, err, "failed to update report")
+		return
+	}
+
+	h.db.Preload("Resource").First(&report, "id = ?", report.ID)
+	c.JSON(http.StatusOK, report)
+}
+
+// applyResolution performs the enforcement action on the reported resource
+// and its uploader inside tx. Only admins may suspend staff uploaders.
+func (h *ResourceHandler) applyResolution(tx *gorm.DB, c *gin.Context, resource *models.Resource, req resolveReportReq) error {
+	// Content that was hidden by the report threshold comes back unless the
+	// action takes it down.
+	restore := func() error {
+		if resource.Status != "hidden" {
+			return nil
+		}
+		resource.Status = "approved"
+		return tx.Model(resource).Update("status", resource.Status).Error
+	}
+	unpublish := func() error {
+		resource.Status = "unpublished"
+		if err := tx.Model(resource).Update("status", resource.Status).Error; err != nil {
+			return err
+		}
+		body := fmt.Sprintf("\"%s\" was unpublished after a report review.", resource.Title)
+		if req.Note != "" {
+			body += " " + req.Note
+		}
+		if err := events.Publish(tx, events.Event{
+			Type:       events.TypeResourceUnpublished,
+			Recipients: []uuid.UUID{resource.UploaderID},
+			Title:      "Resource unpublished",
+			Body:       body,
+			RefType:    "resource",
+			RefID:      resource.ID,
+		}); err != nil {
+			return err
+		}
+		return watch.Notify(tx, resource.ID, resource.UploaderID, events.Event{
+			Type:    events.TypeWatchUnpublished,
+			Title:   "Watched resource unpublished",
+			Body:    fmt.Sprintf("\"%s\" is no longer available.", resource.Title),
+			RefType: "resource",
+			RefID:   resource.ID,
+		})
+	}
+
+	switch req.Action {
+	case resolveDismiss:
+		return restore()
+
+	case resolveEditMetadata:
+		if err := tx.Model(resource).Updates(req.Metadata.columns()).Error; err != nil {
+			return err
+		}
+		if err := notifyMetadataChange(tx, *resource, req.Metadata.columns()); err != nil {
+			return err
+		}
+		return restore()
+
+	case resolveUnpublish:
+		return unpublish()
+
+	case resolveWarn:
+		if err := tx.Model(&models.User{}).Where("id = ?", resource.UploaderID).
+			UpdateColumn("warnings", gorm.Expr("warnings + 1")).Error; err != nil {
+			return err
+		}
+		body := fmt.Sprintf("You received a warning for \"%s\".", resource.Title)
+		if req.Note != "" {
+			body += " " + req.Note
+		}
+		if err := events.Publish(tx, events.Event{
+			Type:       events.TypeUserWarned,
+			Recipients: []uuid.UUID{resource.UploaderID},
+			Title:      "Account warning",
+			Body:       body,
+			RefType:    "resource",
+			RefID:      resource.ID,
+		}); err != nil {
+			return err
+		}
+		return restore()
+
+	case resolveSuspend:
+		var uploader models.User
+		if err := tx.First(&uploader, "id = ?", resource.UploaderID).Error; err != nil {
+			return err
+		}
+		if (uploader.Role == models.RoleAdmin || uploader.Role == models.RoleModerator) && middleware.Role(c) != models.RoleAdmin {
+			return errStaffTarget
+		}
+		_, err := suspendUser(tx, c, uploader, time.Now().AddDate(0, 0, req.SuspendDays), req.Note, func(until time.Time) events.Event {
+			return events.Event{
+				Title:   "Account suspended",
+				Body:    fmt.Sprintf("Your account is suspended until %s because of \"%s\".", until.Format("2006-01-02"), resource.Title),
+				RefType: "resource",
+				RefID:   resource.ID,
+			}
+		})
+		if err != nil {
+			return err
+		}
+		return unpublish()
+	}
+	return nil
+}
+
+func resolutionText(action string) string {
+	switch action {
+	case resolveUnpublish:
+		return "the resource was unpublished"
+	case resolveEditMetadata:
+		return "the resource details were corrected"
+	case resolveWarn:
+		return "the uploader was warned"
+	case resolveSuspend:
+		return "the uploader was suspended and the resource unpublished"
+	default:
+		return "no violation found"
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/database/dbtest"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func resolveReport(t *testing.T, db *gorm.DB, reviewer models.User, report models.Report, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = gin.Params{{Key: "id", Value: report.ID.String()}}
+	c.Set("userID", reviewer.ID)
+	c.Set("role", reviewer.Role)
+	NewResourceHandler(db, config.Config{}).AdminResolveReport(c)
+	return w
+}
+
+func TestResolveReportSuspendKeepsBan(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := dbtest.Tx(t)
+	moderator := newTestUser(t, db, "moderator", models.RoleModerator)
+	reporter := newTestUser(t, db, "reporter", models.RoleUser)
+	uploader := newTestUser(t, db, "uploader", models.RoleUser)
+	db.Model(&uploader).Updates(map[string]interface{}{"suspended_until": models.BannedUntil, "suspend_reason": "spam"})
+
+	resource := models.Resource{Title: "reported", Type: "template", UploaderID: uploader.ID, Status: "approved"}
+	if err := db.Create(&resource).Error; err != nil {
+		t.Fatal(err)
+	}
+	report := models.Report{UserID: reporter.ID, ResourceID: resource.ID, Reason: "spam"}
+	if err := db.Create(&report).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if w := resolveReport(t, db, moderator, report, `{"action":"suspend","suspendDays":7}`); w.Code != http.StatusOK {
+		t.Fatalf("status %d, body %s", w.Code, w.Body)
+	}
+	var got models.User
+	db.First(&got, "id = ?", uploader.ID)
+	if got.SuspendedUntil == nil || !got.SuspendedUntil.Equal(models.BannedUntil) || got.SuspendReason != "spam" {
+		t.Fatalf("ban was changed to %v (%q)", got.SuspendedUntil, got.SuspendReason)
+	}
+
+	if w := resolveReport(t, db, moderator, report, `{"action":"dismiss"}`); w.Code != http.StatusConflict {
+		t.Fatalf("second resolution: status %d, want 409", w.Code)
+	}
+}
+
+func TestResolveReportSuspendRefusesStaffForModerators(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := dbtest.Tx(t)
+	moderator := newTestUser(t, db, "moderator", models.RoleModerator)
+	reporter := newTestUser(t, db, "reporter", models.RoleUser)
+	staff := newTestUser(t, db, "staff", models.RoleModerator)
+
+	resource := models.Resource{Title: "reported", Type: "template", UploaderID: staff.ID, Status: "approved"}
+	if err := db.Create(&resource).Error; err != nil {
+		t.Fatal(err)
+	}
+	report := models.Report{UserID: reporter.ID, ResourceID: resource.ID, Reason: "spam"}
+	if err := db.Create(&report).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if w := resolveReport(t, db, moderator, report, `{"action":"suspend"}`); w.Code != http.StatusForbidden {
+		t.Fatalf("status %d, want 403", w.Code)
+	}
+	var got models.User
+	db.First(&got, "id = ?", staff.ID)
+	if got.SuspendedUntil != nil {
+		t.Fatalf("staff uploader suspended until %v", got.SuspendedUntil)
+	}
+}
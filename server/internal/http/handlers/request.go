@@ -1,15 +1,29 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/A-Words/ne-resource-community/server/internal/audit"
+	"github.com/A-Words/ne-resource-community/server/internal/bounty"
 	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/events"
 	"github.com/A-Words/ne-resource-community/server/internal/http/middleware"
 	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/A-Words/ne-resource-community/server/internal/points"
+	"github.com/A-Words/ne-resource-community/server/internal/webhook"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var errOwnVote = errors.New("cannot vote on your own request")
+
 type RequestHandler struct {
 	db  *gorm.DB
 	cfg config.Config
@@ -22,6 +36,9 @@ func NewRequestHandler(db *gorm.DB, cfg config.Config) *RequestHandler {
 type requestCreateReq struct {
 	Title       string `json:"title" binding:"required"`
 	Description string `json:"description"`
+	Vendor      string `json:"vendor" binding:"max=128"`
+	Protocol    string `json:"protocol" binding:"max=128"`
+	Tags        string `json:"tags" binding:"max=512"`
 	Bounty      int    `json:"bounty"`
 }
 
@@ -38,33 +55,48 @@ func (h *RequestHandler) Create(c *gin.Context) {
 		return
 	}
 
-	// Check if user has enough points if bounty > 0
-	if req.Bounty > 0 {
-		var user models.User
-		if err := h.db.First(&user, "id = ?", userID).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch user"})
-			return
-		}
-		if user.Points < req.Bounty {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "insufficient points"})
-			return
-		}
-		// Deduct points immediately
-		if err := h.db.Model(&user).UpdateColumn("points", gorm.Expr("points - ?", req.Bounty)).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to deduct points"})
-			return
-		}
+	if req.Bounty < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bounty must not be negative"})
+		return
 	}
 
 	request := models.Request{
 		Title:       req.Title,
 		Description: req.Description,
+		Vendor:      req.Vendor,
+		Protocol:    req.Protocol,
+		Tags:        req.Tags,
 		Bounty:      req.Bounty,
 		UserID:      userID,
-		Status:      "open",
+		Status:      bounty.StatusOpen,
+		ExpiresAt:   bounty.ExpiresAt(time.Now(), h.cfg.RequestExpiry),
 	}
 
-	if err := h.db.Create(&request).Error; err != nil {
+	// The bounty is held from the requester's balance as soon as the request exists.
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&request).Error; err != nil {
+			return err
+		}
+		if err := webhook.Enqueue(tx, webhook.EventRequestCreated, request); err != nil {
+			return err
+		}
+		if req.Bounty == 0 {
+			return nil
+		}
+		_, err := points.Apply(tx, points.Entry{
+			UserID:  userID,
+			Delta:   -req.Bounty,
+			Reason:  points.ReasonBountyEscrow,
+			RefType: "request",
+			RefID:   request.ID,
+		})
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, points.ErrInsufficient) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "insufficient points"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create request"})
 		return
 	}
@@ -72,11 +104,329 @@ func (h *RequestHandler) Create(c *gin.Context) {
 	c.JSON(http.StatusCreated, request)
 }
 
+type requestQuery struct {
+	Search   string `form:"search"`
+	Status   string `form:"status"` // open, fulfilled, closed, cancelled, expired
+	Vendor   string `form:"vendor"`
+	Protocol string `form:"protocol"`
+	Tag      string `form:"tag"`
+	Sort     string `form:"sort"` // "newest", "votes", "bounty"; relevance when searching
+	Limit    int    `form:"limit,default=20"`
+	Offset   int    `form:"offset,default=0"`
+}
+
+// filters applies the listing filters; it is shared by List and the feeds.
+func (q requestQuery) filters(dbq *gorm.DB) *gorm.DB {
+	if q.Status != "" {
+		dbq = dbq.Where("status = ?", q.Status)
+	}
+	if q.Vendor != "" {
+		dbq = dbq.Where("vendor ILIKE ?", contains(q.Vendor))
+	}
+	if q.Protocol != "" {
+		dbq = dbq.Where("protocol ILIKE ?", contains(q.Protocol))
+	}
+	if q.Tag != "" {
+		dbq = dbq.Where(models.HasTag, strings.ToLower(strings.TrimSpace(q.Tag)))
+	}
+	if q.Search != "" {
+		dbq = dbq.Where("search_vector @@ websearch_to_tsquery('english', ?)", q.Search)
+	}
+	return dbq
+}
+
+// List returns requests with filters and full-text search.
 func (h *RequestHandler) List(c *gin.Context) {
+	var q requestQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if q.Limit <= 0 || q.Limit > 100 {
+		q.Limit = 20
+	}
+
+	dbq := h.db.Model(&models.Request{}).Preload("User").Scopes(activeAuthor("user_id"), q.filters)
+
+	switch {
+	case q.Sort == "votes":
+		dbq = dbq.Order("vote_count DESC, created_at DESC")
+	case q.Sort == "bounty":
+		dbq = dbq.Order("bounty DESC, created_at DESC")
+	case q.Search != "" && q.Sort == "":
+		dbq = dbq.Clauses(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "ts_rank(search_vector, websearch_to_tsquery('english', ?)) DESC, created_at DESC",
+			Vars:               []interface{}{q.Search},
+			WithoutParentheses: true,
+		}})
+	default:
+		dbq = dbq.Order("created_at DESC")
+	}
+
 	var requests []models.Request
-	if err := h.db.Preload("User").Order("created_at DESC").Find(&requests).Error; err != nil {
+	if err := dbq.Limit(q.Limit).Offset(q.Offset).Find(&requests).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
 		return
 	}
 	c.JSON(http.StatusOK, requests)
 }
+
+// Get returns a single request by id.
+func (h *RequestHandler) Get(c *gin.Context) {
+	requestID, ok := parseRequestID(c)
+	if !ok {
+		return
+	}
+	var request models.Request
+	if err := h.db.Preload("User").Scopes(activeAuthor("user_id")).First(&request, "id = ?", requestID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	c.JSON(http.StatusOK, request)
+}
+
+// Vote toggles the caller's upvote on a request.
+func (h *RequestHandler) Vote(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	requestID, ok := parseRequestID(c)
+	if !ok {
+		return
+	}
+
+	var voted bool
+	var count int
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var request models.Request
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "user_id", "vote_count").First(&request, "id = ?", requestID).Error; err != nil {
+			return bounty.ErrNotFound
+		}
+		if request.UserID == uid {
+			return errOwnVote
+		}
+		res := tx.Where("request_id = ? AND user_id = ?", requestID, uid).Delete(&models.RequestVote{})
+		if res.Error != nil {
+			return res.Error
+		}
+		delta := -1
+		if res.RowsAffected == 0 {
+			if err := tx.Create(&models.RequestVote{RequestID: requestID, UserID: uid}).Error; err != nil {
+				return err
+			}
+			voted, delta = true, 1
+		}
+		count = request.VoteCount + delta
+		return tx.Model(&request).UpdateColumn("vote_count", count).Error
+	})
+	if err != nil {
+		if errors.Is(err, errOwnVote) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		bountyError(c, err, "failed to vote")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"voted": voted, "voteCount": count})
+}
+
+type topUpReq struct {
+	Amount int `json:"amount" binding:"required,min=1"`
+}
+
+// TopUp adds the caller's points to the bounty of an open request. Top-ups
+// are refunded if no answer is accepted.
+func (h *RequestHandler) TopUp(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	requestID, ok := parseRequestID(c)
+	if !ok {
+		return
+	}
+	var req topUpReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var request models.Request
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		request, err = bounty.TopUp(tx, requestID, uid, req.Amount)
+		return err
+	})
+	if err != nil {
+		bountyError(c, err, "failed to add to bounty")
+		return
+	}
+	c.JSON(http.StatusOK, request)
+}
+
+// ListAnswers returns the answers submitted to a request, oldest first.
+// Answers whose resource the caller may not see are left out.
+func (h *RequestHandler) ListAnswers(c *gin.Context) {
+	var answers []models.RequestAnswer
+	if err := h.db.Preload("User").Preload("Resource").Preload("Resource.Uploader").
+		Where("request_id = ?", c.Param("id")).Order("created_at").Find(&answers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	shown := make([]models.RequestAnswer, 0, len(answers))
+	for _, a := range answers {
+		if visible(c, a.Resource) {
+			shown = append(shown, a)
+		}
+	}
+	c.JSON(http.StatusOK, shown)
+}
+
+type answerReq struct {
+	ResourceID string `json:"resourceId" binding:"required,uuid"`
+	Note       string `json:"note" binding:"max=2000"`
+}
+
+// Answer submits an existing resource as an answer to an open request. New
+// uploads are submitted by passing requestId to the resource upload.
+func (h *RequestHandler) Answer(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	requestID, ok := parseRequestID(c)
+	if !ok {
+		return
+	}
+	var req answerReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var answer models.RequestAnswer
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		answer, err = bounty.Submit(tx, requestID, uid, uuid.MustParse(req.ResourceID), req.Note)
+		return err
+	})
+	if err != nil {
+		bountyError(c, err, "failed to submit answer")
+		return
+	}
+	c.JSON(http.StatusCreated, answer)
+}
+
+// Accept accepts an answer to the caller's request and pays the bounty to
+// its submitter.
+func (h *RequestHandler) Accept(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	requestID, ok := parseRequestID(c)
+	if !ok {
+		return
+	}
+	answerID, err := uuid.Parse(c.Param("answerId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid answer id"})
+		return
+	}
+
+	var request models.Request
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		request, err = bounty.Accept(tx, requestID, answerID, uid)
+		return err
+	})
+	if err != nil {
+		bountyError(c, err, "failed to accept answer")
+		return
+	}
+	c.JSON(http.StatusOK, request)
+}
+
+// Close withdraws the caller's open request and refunds its bounty.
+func (h *RequestHandler) Close(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	requestID, ok := parseRequestID(c)
+	if !ok {
+		return
+	}
+
+	var request models.Request
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		request, err = bounty.Withdraw(tx, requestID, uid)
+		return err
+	})
+	if err != nil {
+		bountyError(c, err, "failed to close request")
+		return
+	}
+	c.JSON(http.StatusOK, request)
+}
+
+type cancelRequestReq struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// AdminCancel withdraws an open request on behalf of staff, refunds the
+// bounty and tells the requester why.
+func (h *RequestHandler) AdminCancel(c *gin.Context) {
+	requestID, ok := parseRequestID(c)
+	if !ok {
+		return
+	}
+	var req cancelRequestReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var request models.Request
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if request, err = bounty.Close(tx, requestID, bounty.StatusCancelled); err != nil {
+			return err
+		}
+		body := fmt.Sprintf("Your request \"%s\" was cancelled by a moderator: %s", request.Title, req.Reason)
+		if err := events.Publish(tx, events.Event{
+			Type:       events.TypeRequestClosed,
+			Recipients: []uuid.UUID{request.UserID},
+			Title:      "Request cancelled",
+			Body:       body,
+			RefType:    "request",
+			RefID:      request.ID,
+		}); err != nil {
+			return err
+		}
+		return recordAudit(tx, c, audit.ActionRequestCancel, "request", request.ID.String(),
+			gin.H{"status": bounty.StatusOpen}, gin.H{"status": request.Status, "reason": req.Reason, "refunded": request.Bounty})
+	})
+	if err != nil {
+		bountyError(c, err, "failed to cancel request")
+		return
+	}
+	c.JSON(http.StatusOK, request)
+}
+
+func parseRequestID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request id"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// bountyError maps request lifecycle errors to responses.
+func bountyError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, bounty.ErrNotFound), errors.Is(err, bounty.ErrAnswerNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, bounty.ErrNotRequester), errors.Is(err, bounty.ErrNotUploader):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, bounty.ErrNotOpen), errors.Is(err, bounty.ErrDuplicateAnswer), errors.Is(err, bounty.ErrNotApproved):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, bounty.ErrOwnRequest), errors.Is(err, bounty.ErrResourceUnavailable), errors.Is(err, points.ErrInsufficient):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		log.Printf("request lifecycle: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
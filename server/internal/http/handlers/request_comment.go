@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/A-Words/ne-resource-community/server/internal/audit"
+	"github.com/A-Words/ne-resource-community/server/internal/bounty"
+	"github.com/A-Words/ne-resource-community/server/internal/events"
+	"github.com/A-Words/ne-resource-community/server/internal/http/middleware"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	errCommentNotFound  = errors.New("comment not found")
+	errNotCommentAuthor = errors.New("only the author or staff can delete this comment")
+)
+
+// ListComments returns the comments of a request as a tree, oldest first.
+// Replies to comments of suspended users move to the top level.
+func (h *RequestHandler) ListComments(c *gin.Context) {
+	var comments []models.RequestComment
+	if err := h.db.Preload("User").Scopes(activeAuthor("user_id")).
+		Where("request_id = ?", c.Param("id")).Order("created_at").Find(&comments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	c.JSON(http.StatusOK, commentTree(comments))
+}
+
+func commentTree(comments []models.RequestComment) []models.RequestComment {
+	present := make(map[uuid.UUID]bool, len(comments))
+	for _, rc := range comments {
+		present[rc.ID] = true
+	}
+	children := make(map[uuid.UUID][]models.RequestComment)
+	var roots []models.RequestComment
+	for _, rc := range comments {
+		if rc.ParentID != nil && present[*rc.ParentID] {
+			children[*rc.ParentID] = append(children[*rc.ParentID], rc)
+		} else {
+			roots = append(roots, rc)
+		}
+	}
+	var attach func([]models.RequestComment) []models.RequestComment
+	attach = func(level []models.RequestComment) []models.RequestComment {
+		for i := range level {
+			level[i].Replies = attach(children[level[i].ID])
+		}
+		return level
+	}
+	return attach(roots)
+}
+
+type commentReq struct {
+	Body     string `json:"body" binding:"required,max=5000"`
+	ParentID string `json:"parentId" binding:"omitempty,uuid"`
+}
+
+// CreateComment adds a comment, or a reply when parentId is given, and tells
+// the requester and the parent's author.
+func (h *RequestHandler) CreateComment(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	requestID, ok := parseRequestID(c)
+	if !ok {
+		return
+	}
+	var req commentReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	comment := models.RequestComment{RequestID: requestID, UserID: uid, Body: req.Body}
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var request models.Request
+		if err := tx.First(&request, "id = ?", requestID).Error; err != nil {
+			return bounty.ErrNotFound
+		}
+		recipients := map[uuid.UUID]string{request.UserID: "New comment on your request"}
+		if req.ParentID != "" {
+			var parent models.RequestComment
+			if err := tx.First(&parent, "id = ? AND request_id = ?", req.ParentID, requestID).Error; err != nil {
+				return errCommentNotFound
+			}
+			comment.ParentID = &parent.ID
+			recipients[parent.UserID] = "New reply to your comment"
+		}
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&request).UpdateColumn("comment_count", gorm.Expr("comment_count + 1")).Error; err != nil {
+			return err
+		}
+
+		delete(recipients, uid)
+		body := fmt.Sprintf("A new comment was posted on \"%s\".", request.Title)
+		for recipient, title := range recipients {
+			if err := events.Publish(tx, events.Event{
+				Type:       events.TypeRequestComment,
+				Recipients: []uuid.UUID{recipient},
+				Title:      title,
+				Body:       body,
+				RefType:    "request",
+				RefID:      request.ID,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errCommentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		bountyError(c, err, "failed to save comment")
+		return
+	}
+	h.db.Preload("User").First(&comment, "id = ?", comment.ID)
+	c.JSON(http.StatusCreated, comment)
+}
+
+// DeleteComment removes a comment. Comments with replies keep their place
+// in the thread with the body cleared. Staff removals are audited.
+func (h *RequestHandler) DeleteComment(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	staff := middleware.Role(c) == models.RoleAdmin || middleware.Role(c) == models.RoleModerator
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var comment models.RequestComment
+		if err := tx.First(&comment, "id = ? AND request_id = ?", c.Param("commentId"), c.Param("id")).Error; err != nil {
+			return errCommentNotFound
+		}
+		if comment.UserID != uid && !staff {
+			return errNotCommentAuthor
+		}
+
+		var replies int64
+		if err := tx.Model(&models.RequestComment{}).Where("parent_id = ?", comment.ID).Count(&replies).Error; err != nil {
+			return err
+		}
+		if replies > 0 {
+			if err := tx.Model(&comment).Updates(map[string]interface{}{"body": "", "deleted": true}).Error; err != nil {
+				return err
+			}
+		} else {
+			if err := tx.Delete(&comment).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Request{}).Where("id = ?", comment.RequestID).
+				UpdateColumn("comment_count", gorm.Expr("GREATEST(comment_count - 1, 0)")).Error; err != nil {
+				return err
+			}
+		}
+		if comment.UserID == uid {
+			return nil
+		}
+		return recordAudit(tx, c, audit.ActionRequestCommentDelete, "request_comment", comment.ID.String(),
+			gin.H{"body": comment.Body, "userId": comment.UserID}, nil)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errCommentNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, errNotCommentAuthor):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete comment"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "comment deleted"})
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/A-Words/ne-resource-community/server/internal/bounty"
+	"github.com/A-Words/ne-resource-community/server/internal/http/middleware"
+	"github.com/A-Words/ne-resource-community/server/internal/matcher"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var (
+	errMatchNotFound = errors.New("suggestion not found")
+	errNotUploader   = errors.New("only the uploader can link this resource")
+)
+
+// Suggestions lists approved resources the matcher found for a request,
+// best first.
+func (h *RequestHandler) Suggestions(c *gin.Context) {
+	requestID, ok := parseRequestID(c)
+	if !ok {
+		return
+	}
+	visible := h.db.Model(&models.Resource{}).Select("id").Where("status = ?", "approved").Scopes(activeAuthor("uploader_id"))
+	var matches []models.RequestMatch
+	if err := h.db.Preload("Resource").Preload("Resource.Uploader").
+		Where("request_id = ? AND status = ? AND resource_id IN (?)", requestID, matcher.StatusSuggested, visible).
+		Order("score DESC").Find(&matches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	c.JSON(http.StatusOK, matches)
+}
+
+// DismissSuggestion hides a suggestion from the requester's list.
+func (h *RequestHandler) DismissSuggestion(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var match models.RequestMatch
+		if err := tx.Preload("Request").First(&match, "id = ? AND request_id = ?", c.Param("matchId"), c.Param("id")).Error; err != nil {
+			return errMatchNotFound
+		}
+		if match.Request.UserID != uid {
+			return bounty.ErrNotRequester
+		}
+		return tx.Model(&match).Update("status", matcher.StatusDismissed).Error
+	})
+	if err != nil {
+		if errors.Is(err, errMatchNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		bountyError(c, err, "failed to dismiss suggestion")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "suggestion dismissed"})
+}
+
+// MyMatches lists open requests the caller's resources were matched to.
+func (h *RequestHandler) MyMatches(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	var matches []models.RequestMatch
+	if err := h.db.Preload("Request").Preload("Request.User").Preload("Resource").
+		Where("status = ?", matcher.StatusSuggested).
+		Where("resource_id IN (?)", h.db.Model(&models.Resource{}).Select("id").Where("uploader_id = ?", uid)).
+		Where("request_id IN (?)", h.db.Model(&models.Request{}).Select("id").Where("status = ?", bounty.StatusOpen).Scopes(activeAuthor("user_id"))).
+		Order("score DESC").Find(&matches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	c.JSON(http.StatusOK, matches)
+}
+
+type submitMatchReq struct {
+	Note string `json:"note" binding:"max=2000"`
+}
+
+// SubmitMatch links the caller's matched resource as an answer to the
+// request in one step.
+func (h *RequestHandler) SubmitMatch(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	var req submitMatchReq
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	var answer models.RequestAnswer
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var match models.RequestMatch
+		if err := tx.Preload("Resource").First(&match, "id = ?", c.Param("id")).Error; err != nil {
+			return errMatchNotFound
+		}
+		if match.Resource.UploaderID != uid {
+			return errNotUploader
+		}
+		var err error
+		answer, err = bounty.Submit(tx, match.RequestID, uid, match.ResourceID, req.Note)
+		return err
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errMatchNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, errNotUploader):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			bountyError(c, err, "failed to submit answer")
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, answer)
+}
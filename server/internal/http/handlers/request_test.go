@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/database/dbtest"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func newTestUser(t *testing.T, db *gorm.DB, name, role string) models.User {
+	t.Helper()
+	user := models.User{Email: name + "-" + uuid.NewString()[:8] + "@example.com", DisplayName: name, Role: role}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestListAnswersHidesUnapprovedResources(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := dbtest.Tx(t)
+	requester := newTestUser(t, db, "requester", models.RoleUser)
+	uploader := newTestUser(t, db, "uploader", models.RoleUser)
+	moderator := newTestUser(t, db, "moderator", models.RoleModerator)
+	req := models.Request{Title: "Need a config", UserID: requester.ID, Status: "open"}
+	if err := db.Create(&req).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range []string{"approved", "pending", "rejected"} {
+		res := models.Resource{Title: status, Type: "template", UploaderID: uploader.ID, Status: status}
+		if err := db.Create(&res).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Create(&models.RequestAnswer{RequestID: req.ID, ResourceID: res.ID, UserID: uploader.ID}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h := NewRequestHandler(db, config.Config{})
+	cases := []struct {
+		name   string
+		caller *models.User
+		want   int
+	}{
+		{"anonymous", nil, 1},
+		{"requester", &requester, 1},
+		{"uploader", &uploader, 3},
+		{"moderator", &moderator, 3},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			c.Params = gin.Params{{Key: "id", Value: req.ID.String()}}
+			if tc.caller != nil {
+				c.Set("userID", tc.caller.ID)
+				c.Set("role", tc.caller.Role)
+			}
+			h.ListAnswers(c)
+
+			var answers []models.RequestAnswer
+			if err := json.Unmarshal(w.Body.Bytes(), &answers); err != nil {
+				t.Fatalf("status %d, body %s", w.Code, w.Body)
+			}
+			if len(answers) != tc.want {
+				t.Errorf("%d answers, want %d", len(answers), tc.want)
+			}
+			for _, a := range answers {
+				if tc.want == 1 && a.Resource.Status != "approved" {
+					t.Errorf("answer with %s resource shown", a.Resource.Status)
+				}
+			}
+		})
+	}
+}
+
+func TestRequestTagFilterMatchesWholeTags(t *testing.T) {
+	db := dbtest.Tx(t)
+	owner := newTestUser(t, db, "requester", models.RoleUser)
+	marker := uuid.NewString()
+	for _, tags := range []string{"BGP, ospf", "ebgp", "mpls,bgp-ls", " bgp "} {
+		if err := db.Create(&models.Request{Title: marker, Tags: tags, UserID: owner.ID, Status: "open"}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []models.Request
+	err := requestQuery{Tag: " bgp"}.filters(db.Model(&models.Request{})).
+		Where("title = ?", marker).Order("tags").Find(&got).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Tags != " bgp " || got[1].Tags != "BGP, ospf" {
+		t.Errorf("matched %+v", got)
+	}
+}
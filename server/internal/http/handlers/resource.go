@@ -3,26 +3,40 @@ package handlers
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/A-Words/ne-resource-community/server/internal/audit"
+	"github.com/A-Words/ne-resource-community/server/internal/bounty"
 	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/events"
 	"github.com/A-Words/ne-resource-community/server/internal/http/middleware"
+	"github.com/A-Words/ne-resource-community/server/internal/linkcheck"
+	"github.com/A-Words/ne-resource-community/server/internal/matcher"
 	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/A-Words/ne-resource-community/server/internal/moderation"
+	"github.com/A-Words/ne-resource-community/server/internal/points"
 	"github.com/A-Words/ne-resource-community/server/internal/scanner"
+	"github.com/A-Words/ne-resource-community/server/internal/watch"
+	"github.com/A-Words/ne-resource-community/server/internal/webhook"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ResourceHandler manages resource CRUD and search.
 type ResourceHandler struct {
-	db      *gorm.DB
-	cfg     config.Config
-	scanner scanner.Scanner
+	db           *gorm.DB
+	cfg          config.Config
+	scanner      scanner.Scanner
+	mirrorClient *http.Client
 }
 
 func NewResourceHandler(db *gorm.DB, cfg config.Config) *ResourceHandler {
@@ -37,7 +51,11 @@ func NewResourceHandler(db *gorm.DB, cfg config.Config) *ResourceHandler {
 	} else {
 		s = &scanner.NoOpScanner{}
 	}
-	return &ResourceHandler{db: db, cfg: cfg, scanner: s}
+	mirrorClient := linkcheck.NewClient(linkcheck.ClientOptions{
+		Timeout:      cfg.MirrorTimeout,
+		MaxRedirects: cfg.MirrorMaxRedirects,
+	})
+	return &ResourceHandler{db: db, cfg: cfg, scanner: s, mirrorClient: mirrorClient}
 }
 
 type resourceCreateReq struct {
@@ -52,6 +70,7 @@ type resourceCreateReq struct {
 	ParentID     string `form:"parentId"`     // Optional
 	Version      string `form:"version"`      // Optional, default 1.0
 	ExternalLink string `form:"externalLink"` // Optional
+	RequestID    string `form:"requestId"`    // Optional, submits the upload as an answer
 }
 
 // Create handles multipart upload, stores file locally, and records resource metadata.
@@ -68,93 +87,82 @@ func (h *ResourceHandler) Create(c *gin.Context) {
 		return
 	}
 
-	var diskPath, fileName, contentType, fileHash string
-
-	if req.ExternalLink != "" {
-		// If external link is provided, we skip file upload checks
-		// We can add basic URL validation here if needed
-	} else {
-		file, err := c.FormFile("file")
+	var requestID uuid.UUID
+	if req.RequestID != "" {
+		id, err := uuid.Parse(req.RequestID)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "file or external link is required"})
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request id"})
 			return
 		}
+		requestID = id
+	}
 
-		// 1. Format Check (Simple extension check)
-		ext := strings.ToLower(filepath.Ext(file.Filename))
-		allowedExts := map[string]bool{
-			".pdf": true, ".docx": true, ".doc": true, ".txt": true, ".md": true,
-			".zip": true, ".rar": true, ".7z": true,
-			".pcap": true, ".pcapng": true, ".gns3": true, ".pkt": true,
-			".mp4": true,
+	// Only the parent's uploader or staff may publish a new version of it;
+	// anyone else would reach its watchers under someone else's resource.
+	var parentID *uuid.UUID
+	if req.ParentID != "" {
+		pid, err := uuid.Parse(req.ParentID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid parent id"})
+			return
 		}
-		if !allowedExts[ext] {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported file format"})
+		var parent models.Resource
+		if err := h.db.Select("id", "uploader_id").First(&parent, "id = ?", pid).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "parent resource not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+			}
 			return
 		}
-
-		// 2. Duplicate Check (Calculate Hash)
-		src, err := file.Open()
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open file"})
+		if parent.UploaderID != userID && !middleware.IsStaff(c) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only the uploader of the parent resource can add a version"})
 			return
 		}
-		defer src.Close()
+		parentID = &pid
+	}
 
-		// Virus Scan
-		safe, threat, err := h.scanner.Scan(src)
-		if err != nil {
-			// Log the error but maybe don't block upload if scanner is down?
-			// For security, we should probably block.
-			// But since we have NoOpScanner fallback in NewResourceHandler, this error here means
-			// the scanner was initialized but failed during scan (e.g. connection lost).
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "virus scan failed"})
+	var diskPath, fileName, contentType, fileHash string
+	var fileSize int64
+
+	req.ExternalLink = strings.TrimSpace(req.ExternalLink)
+	if req.ExternalLink != "" {
+		// External links skip file checks but must point to a public http(s) address.
+		if err := linkcheck.ValidateURL(c.Request.Context(), req.ExternalLink); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
-		if !safe {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("virus detected: %s", threat)})
+		if !h.checkUploadQuota(c, userID, 1, 0) {
 			return
 		}
-
-		// Reset file pointer
-		if _, err := src.Seek(0, 0); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset file pointer"})
+	} else {
+		file, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file or external link is required"})
 			return
 		}
-
-		hash := sha256.New()
-		if _, err := io.Copy(hash, src); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to calculate hash"})
+		if !h.checkUploadQuota(c, userID, 1, file.Size) {
 			return
 		}
-		fileHash = hex.EncodeToString(hash.Sum(nil))
 
-		// Reset file pointer for saving
-		src.Seek(0, 0)
-
-		var existing models.Resource
-		if err := h.db.Where("file_hash = ?", fileHash).First(&existing).Error; err == nil {
-			c.JSON(http.StatusConflict, gin.H{"error": "duplicate resource detected", "resourceId": existing.ID})
+		src, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open file"})
 			return
 		}
+		defer src.Close()
 
-		safeName := fmt.Sprintf("%s%s", uuid.NewString(), ext)
-		diskPath = filepath.Join(h.cfg.UploadDir, safeName)
-		if err := c.SaveUploadedFile(file, diskPath); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save file"})
+		stored, ok := h.ingestFile(c, src, file.Filename, uuid.Nil)
+		if !ok {
 			return
 		}
+		diskPath = stored.Path
+		fileHash = stored.Hash
+		fileSize = stored.Size
 		fileName = file.Filename
 		contentType = file.Header.Get("Content-Type")
 	}
 
-	var parentID *uuid.UUID
-	if req.ParentID != "" {
-		if pid, err := uuid.Parse(req.ParentID); err == nil {
-			parentID = &pid
-		}
-	}
-
 	version := req.Version
 	if version == "" {
 		version = "1.0"
@@ -173,6 +181,7 @@ func (h *ResourceHandler) Create(c *gin.Context) {
 		FileName:     fileName,
 		ContentType:  contentType,
 		FileHash:     fileHash,
+		FileSize:     fileSize,
 		ExternalLink: req.ExternalLink,
 		Status:       "pending", // Default to pending for audit
 		UploaderID:   userID,
@@ -180,18 +189,138 @@ func (h *ResourceHandler) Create(c *gin.Context) {
 		Version:      version,
 	}
 
-	if err := h.db.Create(&resource).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save resource"})
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&resource).Error; err != nil {
+			return err
+		}
+		if err := moderation.Enqueue(tx, moderation.KindResource, resource.ID, moderation.PriorityResource); err != nil {
+			return err
+		}
+		if requestID == uuid.Nil {
+			return nil
+		}
+		_, err := bounty.Submit(tx, requestID, userID, resource.ID, "")
+		return err
+	})
+	if err != nil {
+		bountyError(c, err, "failed to save resource")
 		return
 	}
 
-	// Award points for contribution (Only after approval? Or now? Let's keep it now for simplicity, or maybe move to approval)
-	// For better quality control, points should be awarded after approval.
-	// h.db.Model(&models.User{}).Where("id = ?", userID).UpdateColumn("points", gorm.Expr("points + ?", 10))
-
 	c.JSON(http.StatusCreated, resource)
 }
 
+// allowedExts lists the file formats accepted for uploads and mirrors.
+var allowedExts = map[string]bool{
+	".pdf": true, ".docx": true, ".doc": true, ".txt": true, ".md": true,
+	".zip": true, ".rar": true, ".7z": true,
+	".pcap": true, ".pcapng": true, ".gns3": true, ".pkt": true,
+	".mp4": true,
+}
+
+type storedFile struct {
+	Path string
+	Hash string
+	Size int64
+}
+
+// ingestError is an upload pipeline failure and the response it maps to.
+type ingestError struct {
+	status int
+	body   gin.H
+}
+
+func (e *ingestError) Error() string {
+	msg, _ := e.body["error"].(string)
+	return msg
+}
+
+func ingestFailed(status int, msg string) *ingestError {
+	return &ingestError{status: status, body: gin.H{"error": msg}}
+}
+
+// ingestFile runs the upload pipeline on src for a request. On failure the
+// error response has already been written and ok is false.
+func (h *ResourceHandler) ingestFile(c *gin.Context, src io.ReadSeeker, filename string, self uuid.UUID) (storedFile, bool) {
+	stored, err := h.ingest(src, filename, self, h.progress(c, filename))
+	if err != nil {
+		c.JSON(err.status, err.body)
+		return storedFile{}, false
+	}
+	return stored, true
+}
+
+// ingest runs the upload pipeline (format check, virus scan, hashing,
+// duplicate check) on src and stores it in the upload directory. self is
+// excluded from the duplicate check.
+func (h *ResourceHandler) ingest(src io.ReadSeeker, filename string, self uuid.UUID, progress progressReporter) (stored storedFile, ierr *ingestError) {
+	defer func() {
+		if ierr != nil {
+			progress.stage(stageFailed, 0, 0)
+		}
+	}()
+
+	// 1. Format Check (Simple extension check)
+	ext := strings.ToLower(filepath.Ext(filename))
+	if !allowedExts[ext] {
+		return storedFile{}, ingestFailed(http.StatusBadRequest, "unsupported file format")
+	}
+
+	// 2. Virus Scan
+	progress.stage(stageScanning, 0, 0)
+	safe, threat, err := h.scanner.Scan(src)
+	if err != nil {
+		// Since we have NoOpScanner fallback in NewResourceHandler, this error here means
+		// the scanner was initialized but failed during scan (e.g. connection lost).
+		return storedFile{}, ingestFailed(http.StatusInternalServerError, "virus scan failed")
+	}
+	if !safe {
+		return storedFile{}, ingestFailed(http.StatusBadRequest, fmt.Sprintf("virus detected: %s", threat))
+	}
+
+	// 3. Duplicate Check (Calculate Hash)
+	progress.stage(stageHashing, 0, 0)
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return storedFile{}, ingestFailed(http.StatusInternalServerError, "failed to reset file pointer")
+	}
+	hash := sha256.New()
+	size, err := io.Copy(hash, src)
+	if err != nil {
+		return storedFile{}, ingestFailed(http.StatusInternalServerError, "failed to calculate hash")
+	}
+	fileHash := hex.EncodeToString(hash.Sum(nil))
+
+	var existing models.Resource
+	if err := h.db.Where("file_hash = ? AND id <> ?", fileHash, self).First(&existing).Error; err == nil {
+		return storedFile{}, &ingestError{status: http.StatusConflict, body: gin.H{"error": "duplicate resource detected", "resourceId": existing.ID}}
+	}
+
+	// 4. Save
+	progress.stage(stageStoring, size, size)
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return storedFile{}, ingestFailed(http.StatusInternalServerError, "failed to reset file pointer")
+	}
+	diskPath := filepath.Join(h.cfg.UploadDir, uuid.NewString()+ext)
+	if err := saveFile(src, diskPath); err != nil {
+		return storedFile{}, ingestFailed(http.StatusInternalServerError, "failed to save file")
+	}
+	progress.stage(stageStored, size, size)
+	return storedFile{Path: diskPath, Hash: fileHash, Size: size}, nil
+}
+
+func saveFile(src io.Reader, path string) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		os.Remove(path)
+		return err
+	}
+	return out.Close()
+}
+
 type resourceQuery struct {
 	Search   string `form:"search"`
 	Type     string `form:"type"`
@@ -205,55 +334,67 @@ type resourceQuery struct {
 	Offset   int    `form:"offset,default=0"`
 }
 
-// List returns resources with filters and full-text search.
-func (h *ResourceHandler) List(c *gin.Context) {
-	var q resourceQuery
-	if err := c.ShouldBindQuery(&q); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	dbq := h.db.Model(&models.Resource{}).
-		Preload("Uploader").
-		Where("status = ?", "approved") // Only show approved resources
-
-	if q.Sort == "downloads" {
-		dbq = dbq.Order("download_count DESC")
-	} else {
-		dbq = dbq.Order("created_at DESC")
-	}
-
-	dbq = dbq.Limit(q.Limit).
-		Offset(q.Offset)
-
+// filters applies the listing filters; it is shared by List and the feeds.
+func (q resourceQuery) filters(dbq *gorm.DB) *gorm.DB {
 	if q.Type != "" {
 		dbq = dbq.Where("type = ?", q.Type)
 	}
 	if q.Vendor != "" {
-		dbq = dbq.Where("vendor ILIKE ?", "%"+q.Vendor+"%")
+		dbq = dbq.Where("vendor ILIKE ?", contains(q.Vendor))
 	}
 	if q.Device != "" {
-		dbq = dbq.Where("device_model ILIKE ?", "%"+q.Device+"%")
+		dbq = dbq.Where("device_model ILIKE ?", contains(q.Device))
 	}
 	if q.Protocol != "" {
-		dbq = dbq.Where("protocol ILIKE ?", "%"+q.Protocol+"%")
+		dbq = dbq.Where("protocol ILIKE ?", contains(q.Protocol))
 	}
 	if q.Scenario != "" {
-		dbq = dbq.Where("scenario ILIKE ?", "%"+q.Scenario+"%")
+		dbq = dbq.Where("scenario ILIKE ?", contains(q.Scenario))
 	}
 	if q.Tag != "" {
-		tag := strings.ToLower(q.Tag)
-		dbq = dbq.Where("LOWER(tags) LIKE ?", "%"+tag+"%")
+		dbq = dbq.Where("LOWER(tags) LIKE ?", contains(strings.ToLower(q.Tag)))
 	}
 	if q.Search != "" {
 		// Split search query by space to support multiple keywords
 		keywords := strings.Fields(q.Search)
 		for _, keyword := range keywords {
-			pattern := "%" + keyword + "%"
+			pattern := contains(keyword)
 			dbq = dbq.Where("title ILIKE ? OR description ILIKE ? OR tags ILIKE ? OR vendor ILIKE ? OR device_model ILIKE ?",
 				pattern, pattern, pattern, pattern, pattern)
 		}
 	}
+	return dbq
+}
+
+// likeEscaper escapes the LIKE wildcards so user input matches literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// contains returns a LIKE pattern matching values that contain s.
+func contains(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
+
+// List returns resources with filters and full-text search.
+func (h *ResourceHandler) List(c *gin.Context) {
+	var q resourceQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dbq := h.db.Model(&models.Resource{}).
+		Preload("Uploader").
+		Where("status = ?", "approved"). // Only show approved resources
+		Scopes(activeAuthor("uploader_id"), q.filters)
+
+	if q.Sort == "downloads" {
+		dbq = dbq.Order("download_count DESC")
+	} else {
+		dbq = dbq.Order("created_at DESC")
+	}
+
+	dbq = dbq.Limit(q.Limit).
+		Offset(q.Offset)
 
 	var resources []models.Resource
 	if err := dbq.Find(&resources).Error; err != nil {
@@ -264,11 +405,21 @@ func (h *ResourceHandler) List(c *gin.Context) {
 	c.JSON(http.StatusOK, resources)
 }
 
+// visible reports whether the caller may see resource. Only approved
+// resources are public; the uploader and staff also see the rest.
+func visible(c *gin.Context, resource models.Resource) bool {
+	if resource.Status == "approved" {
+		return true
+	}
+	uid, _ := middleware.UserID(c)
+	return uid == resource.UploaderID || middleware.IsStaff(c)
+}
+
 // Get returns a single resource by id.
 func (h *ResourceHandler) Get(c *gin.Context) {
 	id := c.Param("id")
 	var resource models.Resource
-	if err := h.db.Preload("Uploader").First(&resource, "id = ?", id).Error; err != nil {
+	if err := h.db.Preload("Uploader").Scopes(activeAuthor("uploader_id")).First(&resource, "id = ?", id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
 			return
@@ -276,6 +427,10 @@ func (h *ResourceHandler) Get(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
 		return
 	}
+	if !visible(c, resource) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
 	c.JSON(http.StatusOK, resource)
 }
 
@@ -297,7 +452,7 @@ func (h *ResourceHandler) GetVersions(c *gin.Context) {
 	// and the resource where ID = current.ParentID (parent).
 
 	var current models.Resource
-	if err := h.db.First(&current, "id = ?", id).Error; err != nil {
+	if err := h.db.First(&current, "id = ?", id).Error; err != nil || !visible(c, current) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
 		return
 	}
@@ -309,27 +464,33 @@ func (h *ResourceHandler) GetVersions(c *gin.Context) {
 	// Add parent if exists
 	if current.ParentID != nil {
 		var parent models.Resource
-		if err := h.db.First(&parent, "id = ?", *current.ParentID).Error; err == nil {
+		if err := h.db.Scopes(activeAuthor("uploader_id")).
+			First(&parent, "id = ? AND status = 'approved'", *current.ParentID).Error; err == nil {
 			versions = append(versions, parent)
 		}
 	}
 
 	// Add children (newer versions)
 	var children []models.Resource
-	h.db.Where("parent_id = ? AND status = 'approved'", current.ID).Find(&children)
+	h.db.Scopes(activeAuthor("uploader_id")).Where("parent_id = ? AND status = 'approved'", current.ID).Find(&children)
 	versions = append(versions, children...)
 
 	c.JSON(http.StatusOK, versions)
 }
 
-// Download increments counters and streams the file.
+// Download increments counters and streams the file. It is the only way
+// stored files are served, so unpublished and hidden files stay private.
 func (h *ResourceHandler) Download(c *gin.Context) {
 	id := c.Param("id")
 	var resource models.Resource
-	if err := h.db.First(&resource, "id = ?", id).Error; err != nil {
+	if err := h.db.Scopes(activeAuthor("uploader_id")).First(&resource, "id = ?", id).Error; err != nil || !visible(c, resource) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
 		return
 	}
+	if resource.FilePath == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "resource has no stored file"})
+		return
+	}
 
 	h.db.Model(&resource).UpdateColumn("download_count", gorm.Expr("download_count + 1"))
 	if uid, ok := middleware.UserID(c); ok {
@@ -366,19 +527,49 @@ func (h *ResourceHandler) Review(c *gin.Context) {
 	}
 
 	rev := models.Review{ResourceID: resource.ID, UserID: uid, Score: req.Score, Comment: req.Comment}
-	if err := h.db.Create(&rev).Error; err != nil {
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&rev).Error; err != nil {
+			return err
+		}
+		// Award points for interaction: once per resource, and not for
+		// reviewing one's own upload.
+		if resource.UploaderID != uid {
+			if err := points.ApplyOnce(tx, points.Entry{
+				UserID:  uid,
+				Delta:   points.Review,
+				Reason:  points.ReasonReview,
+				RefType: "resource",
+				RefID:   resource.ID,
+			}); err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&resource).Updates(map[string]interface{}{
+			"rating_count":   gorm.Expr("rating_count + 1"),
+			"rating_average": gorm.Expr("((rating_average * rating_count) + ?) / (rating_count + 1)", req.Score),
+		}).Error; err != nil {
+			return err
+		}
+		if err := webhook.Enqueue(tx, webhook.EventReviewCreated, gin.H{"review": rev, "resource": resource}); err != nil {
+			return err
+		}
+		if resource.UploaderID == uid {
+			return nil
+		}
+		return events.Publish(tx, events.Event{
+			Type:       events.TypeResourceReviewed,
+			Recipients: []uuid.UUID{resource.UploaderID},
+			Title:      "New review",
+			Body:       fmt.Sprintf("\"%s\" received a %d-star review.", resource.Title, req.Score),
+			RefType:    "resource",
+			RefID:      resource.ID,
+		})
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save review"})
 		return
 	}
 
-	// Award points for interaction
-	h.db.Model(&models.User{}).Where("id = ?", uid).UpdateColumn("points", gorm.Expr("points + ?", 2))
-
-	h.db.Model(&resource).Updates(map[string]interface{}{
-		"rating_count":   gorm.Expr("rating_count + 1"),
-		"rating_average": gorm.Expr("((rating_average * rating_count) + ?) / (rating_count + 1)", req.Score),
-	})
-
 	c.JSON(http.StatusCreated, rev)
 }
 
@@ -386,13 +577,15 @@ func (h *ResourceHandler) Review(c *gin.Context) {
 func (h *ResourceHandler) Recommend(c *gin.Context) {
 	id := c.Param("id")
 	var resource models.Resource
-	if err := h.db.First(&resource, "id = ?", id).Error; err != nil {
+	if err := h.db.First(&resource, "id = ?", id).Error; err != nil || !visible(c, resource) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
 		return
 	}
 
 	var related []models.Resource
-	h.db.Where("id <> ? AND (vendor = ? OR type = ?)", resource.ID, resource.Vendor, resource.Type).
+	h.db.Scopes(activeAuthor("uploader_id")).
+		Where("status = ?", "approved").
+		Where("id <> ? AND (vendor = ? OR type = ?)", resource.ID, resource.Vendor, resource.Type).
 		Order("rating_average DESC, download_count DESC").
 		Limit(5).
 		Find(&related)
@@ -414,17 +607,37 @@ func (h *ResourceHandler) ToggleFavorite(c *gin.Context) {
 		return
 	}
 
-	var fav models.Favorite
-	result := h.db.Where("user_id = ? AND resource_id = ?", uid, rid).First(&fav)
-	if result.Error == nil {
-		// Exists, delete it
-		h.db.Delete(&fav)
-		c.JSON(http.StatusOK, gin.H{"status": "removed"})
-	} else {
+	status := "added"
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		var fav models.Favorite
+		result := tx.Where("user_id = ? AND resource_id = ?", uid, rid).First(&fav)
+		if result.Error == nil {
+			// Exists, delete it along with the watch it implied
+			status = "removed"
+			if err := tx.Delete(&fav).Error; err != nil {
+				return err
+			}
+			return watch.RemoveFavorite(tx, uid, rid)
+		}
 		// Not exists, create it
-		h.db.Create(&models.Favorite{UserID: uid, ResourceID: rid})
-		c.JSON(http.StatusOK, gin.H{"status": "added"})
+		if err := tx.Create(&models.Favorite{UserID: uid, ResourceID: rid}).Error; err != nil {
+			return err
+		}
+		var user models.User
+		if err := tx.Select("watch_favorites").First(&user, "id = ?", uid).Error; err != nil {
+			return err
+		}
+		if !user.WatchFavorites {
+			return nil
+		}
+		_, err := watch.Add(tx, uid, rid, watch.SourceFavorite)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update favorite"})
+		return
 	}
+	c.JSON(http.StatusOK, gin.H{"status": status})
 }
 
 // ListFavorites returns resources favorited by the user.
@@ -491,52 +704,18 @@ func (h *ResourceHandler) ListDownloads(c *gin.Context) {
 
 // --- Quality Control & Admin ---
 
-// ReportResource allows users to report a resource.
-func (h *ResourceHandler) ReportResource(c *gin.Context) {
-	uid, ok := middleware.UserID(c)
-	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-		return
-	}
-	id := c.Param("id")
-	rid, err := uuid.Parse(id)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid resource id"})
-		return
-	}
-
-	var req struct {
-		Reason string `json:"reason" binding:"required"`
-	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	report := models.Report{
-		UserID:     uid,
-		ResourceID: rid,
-		Reason:     req.Reason,
-		Status:     "pending",
-	}
-	if err := h.db.Create(&report).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to submit report"})
-		return
-	}
-	c.JSON(http.StatusCreated, gin.H{"message": "report submitted"})
-}
-
 // AdminListPending returns resources waiting for audit.
 func (h *ResourceHandler) AdminListPending(c *gin.Context) {
-	// In real app, check if user is admin
 	var resources []models.Resource
-	if err := h.db.Preload("Uploader").Where("status = ?", "pending").Find(&resources).Error; err != nil {
+	if err := h.db.Preload("Uploader").Where("status = ?", "pending").Order("created_at ASC").Find(&resources).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
 		return
 	}
 	c.JSON(http.StatusOK, resources)
 }
 
+var errNotPending = errors.New("only pending resources can be reviewed")
+
 // AdminAuditResource approves or rejects a resource.
 func (h *ResourceHandler) AdminAuditResource(c *gin.Context) {
 	id := c.Param("id")
@@ -554,51 +733,128 @@ func (h *ResourceHandler) AdminAuditResource(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
 		return
 	}
+	// Hidden and unpublished resources go through report resolution instead.
+	if resource.Status != "pending" {
+		c.JSON(http.StatusConflict, gin.H{"error": errNotPending.Error()})
+		return
+	}
 
+	reviewerID, _ := middleware.UserID(c)
+	before := gin.H{"status": resource.Status, "rejectReason": resource.RejectReason}
+	action := audit.ActionResourceApprove
+	firstApproval := false
 	if req.Action == "approve" {
 		resource.Status = "approved"
-		// Award points now
-		h.db.Model(&models.User{}).Where("id = ?", resource.UploaderID).UpdateColumn("points", gorm.Expr("points + ?", 10))
+		if resource.ApprovedAt == nil {
+			firstApproval = true
+			now := time.Now()
+			resource.ApprovedAt = &now
+		}
 	} else {
 		resource.Status = "rejected"
 		resource.RejectReason = req.Reason
+		action = audit.ActionResourceReject
 	}
 
-	if err := h.db.Save(&resource).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update status"})
-		return
-	}
-
-	c.JSON(http.StatusOK, resource)
-}
-
-// AdminListReports returns all pending reports.
-func (h *ResourceHandler) AdminListReports(c *gin.Context) {
-	var reports []models.Report
-	// Preload Resource and User to show details
-	if err := h.db.Preload("Resource").Preload("User").Where("status = ?", "pending").Find(&reports).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch reports"})
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var current models.Resource
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("status").
+			First(&current, "id = ?", resource.ID).Error; err != nil {
+			return err
+		}
+		if current.Status != "pending" {
+			return errNotPending
+		}
+		if err := moderation.Complete(tx, moderation.KindResource, resource.ID, reviewerID, req.Action); err != nil {
+			return err
+		}
+		if err := tx.Save(&resource).Error; err != nil {
+			return err
+		}
+		if req.Action == "approve" {
+			// Re-approving a resource does not pay again.
+			if err := points.ApplyOnce(tx, points.Entry{
+				UserID:  resource.UploaderID,
+				Delta:   points.UploadApproved,
+				Reason:  points.ReasonUploadApproved,
+				RefType: "resource",
+				RefID:   resource.ID,
+			}); err != nil {
+				return err
+			}
+			if _, err := matcher.Run(tx, resource, h.cfg.MatchMinScore); err != nil {
+				return err
+			}
+		}
+		if firstApproval {
+			if err := matchSavedSearches(tx, resource); err != nil {
+				return err
+			}
+		}
+		if err := publishAuditOutcome(tx, resource, firstApproval); err != nil {
+			return err
+		}
+		event := webhook.EventResourceApproved
+		if resource.Status == "rejected" {
+			event = webhook.EventResourceRejected
+		}
+		if err := webhook.Enqueue(tx, event, resource); err != nil {
+			return err
+		}
+		after := gin.H{"status": resource.Status, "rejectReason": resource.RejectReason}
+		return recordAudit(tx, c, action, "resource", resource.ID.String(), before, after)
+	})
+	if errors.Is(err, errNotPending) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, reports)
-}
-
-// AdminResolveReport marks a report as resolved.
-func (h *ResourceHandler) AdminResolveReport(c *gin.Context) {
-	id := c.Param("id")
-	var report models.Report
-	if err := h.db.First(&report, "id = ?", id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	if err != nil {
+		moderationError(c, err, "failed to update status")
 		return
 	}
 
-	report.Status = "resolved"
-	if err := h.db.Save(&report).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update report"})
-		return
-	}
+	c.JSON(http.StatusOK, resource)
+}
 
-	c.JSON(http.StatusOK, report)
+// publishAuditOutcome tells the uploader how their resource was reviewed and,
+// the first time a new version is approved, the users watching any version
+// in its chain.
+func publishAuditOutcome(tx *gorm.DB, resource models.Resource, firstApproval bool) error {
+	if resource.Status == "rejected" {
+		body := fmt.Sprintf("\"%s\" was rejected.", resource.Title)
+		if resource.RejectReason != "" {
+			body += " Reason: " + resource.RejectReason
+		}
+		return events.Publish(tx, events.Event{
+			Type:       events.TypeResourceRejected,
+			Recipients: []uuid.UUID{resource.UploaderID},
+			Title:      "Upload rejected",
+			Body:       body,
+			RefType:    "resource",
+			RefID:      resource.ID,
+		})
+	}
+
+	if err := events.Publish(tx, events.Event{
+		Type:       events.TypeResourceApproved,
+		Recipients: []uuid.UUID{resource.UploaderID},
+		Title:      "Upload approved",
+		Body:       fmt.Sprintf("\"%s\" is now published.", resource.Title),
+		RefType:    "resource",
+		RefID:      resource.ID,
+	}); err != nil {
+		return err
+	}
+	if resource.ParentID == nil || !firstApproval {
+		return nil
+	}
+	return watch.NotifyChain(tx, resource.ID, resource.UploaderID, events.Event{
+		Type:    events.TypeResourceNewVersion,
+		Title:   "New version available",
+		Body:    fmt.Sprintf("Version %s of \"%s\" was published.", resource.Version, resource.Title),
+		RefType: "resource",
+		RefID:   resource.ID,
+	})
 }
 
 // GetPopularTags returns a list of popular tags.
@@ -616,6 +872,7 @@ func (h *ResourceHandler) GetPopularTags(c *gin.Context) {
 			SELECT unnest(string_to_array(tags, ',')) as tag
 			FROM resources
 			WHERE status = 'approved' AND tags != ''
+				AND uploader_id NOT IN (SELECT id FROM users WHERE suspended_until > NOW())
 		) t
 		WHERE trim(tag) != ''
 		GROUP BY tag
@@ -712,3 +969,11 @@ func (h *ResourceHandler) GetProgress(c *gin.Context) {
 
 	c.JSON(http.StatusOK, progress)
 }
+
+// activeAuthor hides content whose author (in column) is suspended from
+// public listings.
+func activeAuthor(column string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(column+" NOT IN (SELECT id FROM users WHERE suspended_until > ?)", time.Now())
+	}
+}
@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/audit"
+	"github.com/A-Words/ne-resource-community/server/internal/http/middleware"
+	"github.com/A-Words/ne-resource-community/server/internal/linkcheck"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/A-Words/ne-resource-community/server/internal/moderation"
+	"github.com/A-Words/ne-resource-community/server/internal/quota"
+	"github.com/A-Words/ne-resource-community/server/internal/textutil"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Mirror states stored on models.Resource.
+const (
+	mirrorQueued  = "queued"
+	mirrorRunning = "running"
+	mirrorDone    = "done"
+	mirrorFailed  = "failed"
+)
+
+var (
+	errMirrorBusy  = errors.New("a mirror of this resource is already in progress")
+	errMirrorStale = errors.New("resource changed while mirroring")
+)
+
+// Mirror queues a copy of the external link of a resource into local storage
+// so it survives link rot. RunMirrors does the download; the original link is
+// kept as provenance.
+func (h *ResourceHandler) Mirror(c *gin.Context) {
+	uid, ok := middleware.UserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var resource models.Resource
+	if err := h.db.First(&resource, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+	if resource.UploaderID != uid && middleware.Role(c) != models.RoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only the uploader or an admin can mirror this resource"})
+		return
+	}
+	if resource.Status != "pending" && resource.Status != "approved" {
+		c.JSON(http.StatusConflict, gin.H{"error": "only pending or published resources can be mirrored"})
+		return
+	}
+	if resource.ExternalLink == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "resource has no external link"})
+		return
+	}
+	if resource.FilePath != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "resource is already stored locally"})
+		return
+	}
+	if err := linkcheck.ValidateURL(c.Request.Context(), resource.ExternalLink); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// The size is unknown until the download; refuse early if nothing is left.
+	if !h.checkUploadQuota(c, uid, 0, 0) {
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&models.Resource{}).
+			Where("id = ? AND mirror_status NOT IN ?", resource.ID, []string{mirrorQueued, mirrorRunning}).
+			Updates(map[string]interface{}{"mirror_status": mirrorQueued, "mirror_error": "", "mirror_by_id": uid})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errMirrorBusy
+		}
+		if resource.UploaderID == uid {
+			return nil
+		}
+		// Admin acting on someone else's resource.
+		return recordAudit(tx, c, audit.ActionResourceMirror, "resource", resource.ID.String(),
+			nil, gin.H{"source": resource.ExternalLink})
+	})
+	if errors.Is(err, errMirrorBusy) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to queue mirror"})
+		return
+	}
+
+	h.db.First(&resource, "id = ?", resource.ID)
+	c.JSON(http.StatusAccepted, resource)
+}
+
+// RunMirrors downloads queued mirrors one at a time. Each is claimed with a
+// lease longer than MirrorTimeout so several replicas can run the job and a
+// mirror whose worker died is picked up again.
+func (h *ResourceHandler) RunMirrors(ctx context.Context) error {
+	for ctx.Err() == nil {
+		resource, ok, err := h.claimMirror(ctx)
+		if err != nil {
+			return fmt.Errorf("claim mirror: %w", err)
+		}
+		if !ok {
+			return nil
+		}
+		err = h.mirror(ctx, resource)
+		if err == nil || ctx.Err() != nil {
+			// An interrupted mirror is retried once its lease runs out.
+			continue
+		}
+		log.Printf("mirror %s: %v", resource.ID, err)
+		if err := h.db.Model(&models.Resource{}).
+			Where("id = ? AND mirror_status = ?", resource.ID, mirrorRunning).
+			Updates(map[string]interface{}{
+				"mirror_status": mirrorFailed,
+				"mirror_error":  textutil.Truncate(err.Error(), 255),
+				"mirror_lease":  nil,
+			}).Error; err != nil {
+			return fmt.Errorf("record mirror failure: %w", err)
+		}
+	}
+	return nil
+}
+
+func (h *ResourceHandler) claimMirror(ctx context.Context) (models.Resource, bool, error) {
+	var resource models.Resource
+	found := false
+	err := h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("mirror_status = ? OR (mirror_status = ? AND mirror_lease < ?)", mirrorQueued, mirrorRunning, now).
+			Order("updated_at").
+			First(&resource).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		found = true
+		return tx.Model(&resource).Updates(map[string]interface{}{
+			"mirror_status": mirrorRunning,
+			"mirror_lease":  now.Add(h.cfg.MirrorTimeout + time.Minute),
+		}).Error
+	})
+	return resource, found, err
+}
+
+// mirror downloads and stores one claimed resource. Content added to a
+// published resource has not been reviewed, so the resource goes back to the
+// moderation queue.
+func (h *ResourceHandler) mirror(ctx context.Context, resource models.Resource) error {
+	requester := resource.UploaderID
+	if resource.MirrorByID != nil {
+		requester = *resource.MirrorByID
+	}
+	if err := linkcheck.ValidateURL(ctx, resource.ExternalLink); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, resource.ExternalLink, nil)
+	if err != nil {
+		return errors.New("invalid external link")
+	}
+	resp, err := h.mirrorClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("upstream returned status %d", resp.StatusCode)
+	}
+	if resp.ContentLength > h.cfg.MirrorMaxBytes {
+		return errors.New("remote file exceeds the mirror size limit")
+	}
+
+	tmp, err := os.CreateTemp(h.cfg.UploadDir, "mirror-*")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	fileName := mirrorFileName(resp)
+	// Clients match mirror progress by the resource id.
+	progress := progressReporter{h: h, userID: requester, uploadID: resource.ID.String(), fileName: fileName}
+	progress.stage(stageDownloading, 0, resp.ContentLength)
+	body := &countingReader{r: resp.Body, p: progress, total: resp.ContentLength, last: time.Now()}
+
+	// Content-Length can be missing or wrong, so enforce the limit while copying.
+	n, err := io.Copy(tmp, io.LimitReader(body, h.cfg.MirrorMaxBytes+1))
+	if err != nil {
+		progress.stage(stageFailed, n, resp.ContentLength)
+		return fmt.Errorf("download interrupted: %w", err)
+	}
+	if n > h.cfg.MirrorMaxBytes {
+		progress.stage(stageFailed, n, resp.ContentLength)
+		return errors.New("remote file exceeds the mirror size limit")
+	}
+	if err := h.mirrorQuota(requester, n); err != nil {
+		progress.stage(stageFailed, n, resp.ContentLength)
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("reset file pointer: %w", err)
+	}
+
+	stored, ierr := h.ingest(tmp, fileName, resource.ID, progress)
+	if ierr != nil {
+		return ierr
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		var current models.Resource
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, "id = ?", resource.ID).Error; err != nil {
+			return err
+		}
+		if current.MirrorStatus != mirrorRunning || current.FilePath != "" ||
+			(current.Status != "pending" && current.Status != "approved") {
+			return errMirrorStale
+		}
+		updates := map[string]interface{}{
+			"file_path":     stored.Path,
+			"file_name":     fileName,
+			"content_type":  resp.Header.Get("Content-Type"),
+			"file_hash":     stored.Hash,
+			"file_size":     stored.Size,
+			"mirrored_at":   time.Now(),
+			"mirror_status": mirrorDone,
+			"mirror_error":  "",
+			"mirror_lease":  nil,
+		}
+		if current.Status == "approved" {
+			updates["status"] = "pending"
+			if err := moderation.Enqueue(tx, moderation.KindResource, current.ID, moderation.PriorityResource); err != nil {
+				return err
+			}
+		}
+		return tx.Model(&current).Updates(updates).Error
+	})
+	if err != nil {
+		os.Remove(stored.Path)
+		return err
+	}
+	return nil
+}
+
+// mirrorQuota checks the requester's upload quota for a finished download.
+func (h *ResourceHandler) mirrorQuota(uid uuid.UUID, size int64) error {
+	var user models.User
+	if err := h.db.First(&user, "id = ?", uid).Error; err != nil {
+		return fmt.Errorf("load user: %w", err)
+	}
+	used, err := quota.Used(h.db, uid)
+	if err != nil {
+		return fmt.Errorf("load upload quota: %w", err)
+	}
+	if !quota.For(h.cfg, user).Allows(used, 0, size) {
+		return errors.New("daily upload quota exceeded")
+	}
+	return nil
+}
+
+// mirrorFileName prefers the server-supplied attachment name and falls back to
+// the last path segment of the final (post-redirect) URL.
+func mirrorFileName(resp *http.Response) string {
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		if name := path.Base(params["filename"]); name != "." && name != "/" {
+			return name
+		}
+	}
+	return path.Base(resp.Request.URL.Path)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"io"
+	"log"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/http/middleware"
+	"github.com/A-Words/ne-resource-community/server/internal/realtime"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Upload pipeline stages streamed to the uploader.
+const (
+	stageDownloading = "downloading" // mirror only
+	stageScanning    = "scanning"
+	stageHashing     = "hashing"
+	stageStoring     = "storing"
+	stageStored      = "stored"
+	stageFailed      = "failed"
+)
+
+// uploadProgress is streamed to the uploader as an upload.progress event.
+// Clients correlate events with their request through the optional uploadId
+// query or form field.
+type uploadProgress struct {
+	UploadID string `json:"uploadId,omitempty"`
+	FileName string `json:"fileName"`
+	Stage    string `json:"stage"`
+	Bytes    int64  `json:"bytes,omitempty"`
+	Total    int64  `json:"total,omitempty"` // -1 when unknown
+}
+
+type progressReporter struct {
+	h        *ResourceHandler
+	userID   uuid.UUID
+	uploadID string
+	fileName string
+}
+
+func (h *ResourceHandler) progress(c *gin.Context, fileName string) progressReporter {
+	uid, _ := middleware.UserID(c)
+	uploadID := c.Query("uploadId")
+	if uploadID == "" {
+		uploadID = c.PostForm("uploadId")
+	}
+	return progressReporter{h: h, userID: uid, uploadID: uploadID, fileName: fileName}
+}
+
+// stage reports a pipeline step. Failures are only logged; progress is a
+// convenience and must not fail the upload.
+func (p progressReporter) stage(stage string, bytes, total int64) {
+	if p.userID == uuid.Nil {
+		return
+	}
+	msg := uploadProgress{UploadID: p.uploadID, FileName: p.fileName, Stage: stage, Bytes: bytes, Total: total}
+	if err := realtime.ToUser(p.h.db, p.userID, "upload.progress", msg); err != nil {
+		log.Printf("upload progress: %v", err)
+	}
+}
+
+// progressInterval throttles byte counts during downloads.
+const progressInterval = time.Second
+
+// countingReader reports bytes read through p at most once per
+// progressInterval.
+type countingReader struct {
+	r     io.Reader
+	p     progressReporter
+	total int64
+	n     int64
+	last  time.Time
+}
+
+func (cr *countingReader) Read(b []byte) (int, error) {
+	n, err := cr.r.Read(b)
+	cr.n += int64(n)
+	if now := time.Now(); now.Sub(cr.last) >= progressInterval {
+		cr.last = now
+		cr.p.stage(stageDownloading, cr.n, cr.total)
+	}
+	return n, err
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/A-Words/ne-resource-community/server/internal/http/middleware"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/A-Words/ne-resource-community/server/internal/quota"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// UploadQuota returns the caller's upload allowance and current usage.
+func (h *ResourceHandler) UploadQuota(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	limits, used, ok := h.uploadQuota(c, uid)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"limits": limits, "used": used, "windowHours": int(quota.Window.Hours())})
+}
+
+// checkUploadQuota rejects an upload of files new resources totalling size
+// bytes that would exceed the caller's quota. On failure the response has
+// already been written.
+func (h *ResourceHandler) checkUploadQuota(c *gin.Context, uid uuid.UUID, files int, size int64) bool {
+	limits, used, ok := h.uploadQuota(c, uid)
+	if !ok {
+		return false
+	}
+	if !limits.Allows(used, files, size) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "daily upload quota exceeded", "limits": limits, "used": used})
+		return false
+	}
+	return true
+}
+
+func (h *ResourceHandler) uploadQuota(c *gin.Context, uid uuid.UUID) (quota.Limits, quota.Usage, bool) {
+	var user models.User
+	if err := h.db.First(&user, "id = ?", uid).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return quota.Limits{}, quota.Usage{}, false
+	}
+	used, err := quota.Used(h.db, uid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load upload quota"})
+		return quota.Limits{}, quota.Usage{}, false
+	}
+	return quota.For(h.cfg, user), used, true
+}
@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/events"
+	"github.com/A-Words/ne-resource-community/server/internal/http/middleware"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const maxSavedSearches = 50
+
+type savedSearchRequest struct {
+	Name     string `json:"name" binding:"required,max=100"`
+	Search   string `json:"search" binding:"max=255"`
+	Type     string `json:"type" binding:"max=64"`
+	Vendor   string `json:"vendor" binding:"max=128"`
+	Device   string `json:"device" binding:"max=128"`
+	Protocol string `json:"protocol" binding:"max=128"`
+	Scenario string `json:"scenario" binding:"max=128"`
+	Tag      string `json:"tag" binding:"max=64"`
+	Sort     string `json:"sort" binding:"omitempty,oneof=newest downloads"`
+	Alert    bool   `json:"alert"`
+}
+
+func (r savedSearchRequest) apply(s *models.SavedSearch) {
+	s.Name = strings.TrimSpace(r.Name)
+	s.Search = strings.TrimSpace(r.Search)
+	s.Type = strings.TrimSpace(r.Type)
+	s.Vendor = strings.TrimSpace(r.Vendor)
+	s.Device = strings.TrimSpace(r.Device)
+	s.Protocol = strings.TrimSpace(r.Protocol)
+	s.Scenario = strings.TrimSpace(r.Scenario)
+	s.Tag = strings.TrimSpace(r.Tag)
+	s.Sort = r.Sort
+	s.Alert = r.Alert
+}
+
+// savedQuery turns a saved search into the query List runs.
+func savedQuery(s models.SavedSearch) resourceQuery {
+	return resourceQuery{
+		Search:   s.Search,
+		Type:     s.Type,
+		Vendor:   s.Vendor,
+		Device:   s.Device,
+		Protocol: s.Protocol,
+		Scenario: s.Scenario,
+		Tag:      s.Tag,
+		Sort:     s.Sort,
+	}
+}
+
+func (q resourceQuery) empty() bool {
+	return q.Search == "" && q.Type == "" && q.Vendor == "" && q.Device == "" &&
+		q.Protocol == "" && q.Scenario == "" && q.Tag == ""
+}
+
+// ListSavedSearches returns the caller's saved searches.
+func (h *ResourceHandler) ListSavedSearches(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	var searches []models.SavedSearch
+	if err := h.db.Where("user_id = ?", uid).Order("created_at").Find(&searches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	c.JSON(http.StatusOK, searches)
+}
+
+// CreateSavedSearch saves a named resource query.
+func (h *ResourceHandler) CreateSavedSearch(c *gin.Context) {
+	var req savedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	uid, _ := middleware.UserID(c)
+	search := models.SavedSearch{UserID: uid}
+	req.apply(&search)
+	if savedQuery(search).empty() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a saved search needs at least one filter"})
+		return
+	}
+
+	var count int64
+	if err := h.db.Model(&models.SavedSearch{}).Where("user_id = ?", uid).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	if count >= maxSavedSearches {
+		c.JSON(http.StatusConflict, gin.H{"error": "too many saved searches"})
+		return
+	}
+	if err := h.db.Create(&search).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save search"})
+		return
+	}
+	c.JSON(http.StatusCreated, search)
+}
+
+// UpdateSavedSearch replaces the name, filters and alert setting.
+func (h *ResourceHandler) UpdateSavedSearch(c *gin.Context) {
+	search, ok := h.loadSavedSearch(c)
+	if !ok {
+		return
+	}
+	var req savedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.apply(&search)
+	if savedQuery(search).empty() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a saved search needs at least one filter"})
+		return
+	}
+	if err := h.db.Model(&search).
+		Select("name", "search", "type", "vendor", "device", "protocol", "scenario", "tag", "sort", "alert").
+		Updates(&search).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update search"})
+		return
+	}
+	c.JSON(http.StatusOK, search)
+}
+
+// DeleteSavedSearch removes a saved search.
+func (h *ResourceHandler) DeleteSavedSearch(c *gin.Context) {
+	search, ok := h.loadSavedSearch(c)
+	if !ok {
+		return
+	}
+	if err := h.db.Delete(&search).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete search"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "search deleted"})
+}
+
+// RunSavedSearch runs a saved search like GET /api/resources and resets its
+// new-match counter.
+func (h *ResourceHandler) RunSavedSearch(c *gin.Context) {
+	search, ok := h.loadSavedSearch(c)
+	if !ok {
+		return
+	}
+	var page pageQuery
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if page.Limit <= 0 || page.Limit > 100 {
+		page.Limit = 20
+	}
+
+	q := savedQuery(search)
+	dbq := h.db.Model(&models.Resource{}).
+		Preload("Uploader").
+		Where("status = ?", "approved").
+		Scopes(activeAuthor("uploader_id"), q.filters)
+	if q.Sort == "downloads" {
+		dbq = dbq.Order("download_count DESC")
+	} else {
+		dbq = dbq.Order("created_at DESC")
+	}
+	var resources []models.Resource
+	if err := dbq.Limit(page.Limit).Offset(page.Offset).Find(&resources).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+
+	now := time.Now()
+	if err := h.db.Model(&search).Updates(map[string]interface{}{"new_matches": 0, "last_run_at": now}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update search"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"search": search, "items": resources})
+}
+
+func (h *ResourceHandler) loadSavedSearch(c *gin.Context) (models.SavedSearch, bool) {
+	uid, _ := middleware.UserID(c)
+	var search models.SavedSearch
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid search id"})
+		return search, false
+	}
+	if err := h.db.First(&search, "id = ? AND user_id = ?", id, uid).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "search not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		}
+		return search, false
+	}
+	return search, true
+}
+
+// savedSearchMatchBatch bounds how many saved searches are evaluated in one
+// query, keeping the bind parameters well below the PostgreSQL limit.
+const savedSearchMatchBatch = 100
+
+// matchSavedSearches checks a newly approved resource against every saved
+// search with alerts on and notifies their owners. Searches whose type, vendor,
+// device, protocol, scenario or tag cannot match the resource are dropped in
+// SQL; the rest are evaluated against the resource with the filters List uses,
+// a batch at a time in one query.
+func matchSavedSearches(tx *gorm.DB, resource models.Resource) error {
+	var candidates []models.SavedSearch
+	if err := tx.Where("alert AND user_id <> ?", resource.UploaderID).
+		Where("type = '' OR type = ?", resource.Type).
+		Where("vendor = '' OR strpos(lower(?), lower(vendor)) > 0", resource.Vendor).
+		Where("device = '' OR strpos(lower(?), lower(device)) > 0", resource.DeviceModel).
+		Where("protocol = '' OR strpos(lower(?), lower(protocol)) > 0", resource.Protocol).
+		Where("scenario = '' OR strpos(lower(?), lower(scenario)) > 0", resource.Scenario).
+		Where("tag = '' OR strpos(lower(?), lower(tag)) > 0", resource.Tags).
+		Find(&candidates).Error; err != nil {
+		return fmt.Errorf("load saved searches: %w", err)
+	}
+
+	hits := make(map[uuid.UUID]bool)
+	for start := 0; start < len(candidates); start += savedSearchMatchBatch {
+		batch := candidates[start:min(start+savedSearchMatchBatch, len(candidates))]
+		parts := make([]string, len(batch))
+		args := make([]interface{}, len(batch))
+		for i, s := range batch {
+			parts[i] = "(?)"
+			args[i] = tx.Model(&models.Resource{}).Select("CAST(? AS uuid)", s.ID).
+				Where("id = ?", resource.ID).Scopes(savedQuery(s).filters)
+		}
+		var ids []uuid.UUID
+		if err := tx.Raw(strings.Join(parts, " UNION ALL "), args...).Scan(&ids).Error; err != nil {
+			return fmt.Errorf("match saved searches: %w", err)
+		}
+		for _, id := range ids {
+			hits[id] = true
+		}
+	}
+
+	matched := make(map[uuid.UUID][]models.SavedSearch)
+	for _, s := range candidates {
+		if hits[s.ID] {
+			matched[s.UserID] = append(matched[s.UserID], s)
+		}
+	}
+
+	now := time.Now()
+	for uid, searches := range matched {
+		ids := make([]uuid.UUID, len(searches))
+		names := make([]string, len(searches))
+		for i, s := range searches {
+			ids[i] = s.ID
+			names[i] = "\"" + s.Name + "\""
+		}
+		if err := tx.Model(&models.SavedSearch{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"new_matches":   gorm.Expr("new_matches + 1"),
+			"last_match_at": now,
+		}).Error; err != nil {
+			return err
+		}
+		if err := events.Publish(tx, events.Event{
+			Type:       events.TypeSavedSearchMatch,
+			Recipients: []uuid.UUID{uid},
+			Title:      "New match for your saved search",
+			Body:       fmt.Sprintf("\"%s\" matches your saved search %s.", resource.Title, strings.Join(names, ", ")),
+			RefType:    "resource",
+			RefID:      resource.ID,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
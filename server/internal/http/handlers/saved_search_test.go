@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/A-Words/ne-resource-community/server/internal/database/dbtest"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+)
+
+func TestContainsEscapesWildcards(t *testing.T) {
+	cases := map[string]string{
+		"cisco":   "%cisco%",
+		"50%":     `%50\%%`,
+		"nx_os":   `%nx\_os%`,
+		`c:\tmp`:  `%c:\\tmp%`,
+		"":        "%%",
+		"a%_b\\c": `%a\%\_b\\c%`,
+	}
+	for in, want := range cases {
+		if got := contains(in); got != want {
+			t.Errorf("contains(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestMatchSavedSearchesUsesListFilters(t *testing.T) {
+	db := dbtest.Tx(t)
+	uploader := newTestUser(t, db, "uploader", models.RoleUser)
+	watcher := newTestUser(t, db, "watcher", models.RoleUser)
+	searches := map[string]models.SavedSearch{
+		"vendor substring": {Vendor: "cisc"},
+		"wildcard vendor":  {Vendor: "c_sco"},
+		"percent vendor":   {Vendor: "%"},
+		"keyword":          {Search: "route-map"},
+		"other keyword":    {Search: "firewall"},
+		"type and tag":     {Type: "template", Tag: "BGP"},
+		"wrong type":       {Type: "tool", Tag: "bgp"},
+		"alerts off":       {Vendor: "cisco"},
+	}
+	for name, s := range searches {
+		s.Name, s.UserID, s.Alert = name, watcher.ID, name != "alerts off"
+		if err := db.Create(&s).Error; err != nil {
+			t.Fatal(err)
+		}
+		searches[name] = s
+	}
+	own := models.SavedSearch{Name: "own upload", UserID: uploader.ID, Vendor: "cisco", Alert: true}
+	if err := db.Create(&own).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	res := models.Resource{Title: "BGP route-map samples", Type: "template", Vendor: "Cisco", Tags: "bgp,ios", UploaderID: uploader.ID, Status: "approved"}
+	if err := db.Create(&res).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := matchSavedSearches(db, res); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]bool{"vendor substring": true, "keyword": true, "type and tag": true}
+	for name, s := range searches {
+		var got models.SavedSearch
+		db.First(&got, "id = ?", s.ID)
+		if matched := got.NewMatches > 0; matched != want[name] {
+			t.Errorf("%s: matched = %v, want %v", name, matched, want[name])
+		}
+	}
+	db.First(&own, "id = ?", own.ID)
+	if own.NewMatches != 0 {
+		t.Error("uploader alerted about their own resource")
+	}
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/apitoken"
+	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/http/middleware"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/A-Words/ne-resource-community/server/internal/realtime"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	streamHeartbeat = 25 * time.Second // keeps idle connections open through proxies
+	streamRecheck   = time.Minute      // how often the caller's credential is validated again
+)
+
+// StreamHandler pushes real-time events to the browser over Server-Sent
+// Events.
+type StreamHandler struct {
+	db      *gorm.DB
+	cfg     config.Config
+	hub     *realtime.Hub
+	recheck func(*gin.Context) error
+}
+
+func NewStreamHandler(db *gorm.DB, cfg config.Config, hub *realtime.Hub) *StreamHandler {
+	return &StreamHandler{db: db, cfg: cfg, hub: hub, recheck: middleware.Recheck(db, cfg)}
+}
+
+// Stream sends the caller's notifications and upload progress and, for
+// staff, moderation queue changes until the client disconnects. The stream
+// ends with an "expired" event once the access token expires or is revoked,
+// or the caller's role changes; clients reconnect with a fresh token.
+func (h *StreamHandler) Stream(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	client := h.hub.Subscribe(uid, h.staffFeed(c))
+	defer h.hub.Unsubscribe(client)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // disable proxy buffering in nginx
+	c.Status(http.StatusOK)
+	c.SSEvent("ready", gin.H{"userId": uid})
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	recheck := time.NewTicker(streamRecheck)
+	defer recheck.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case m, ok := <-client.C:
+			if !ok {
+				return false // server shutting down
+			}
+			c.SSEvent(m.Event, m.Data)
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": ping\n\n")
+			return err == nil
+		case <-recheck.C:
+			if err := h.recheck(c); err != nil {
+				c.SSEvent("expired", gin.H{"error": err.Error()})
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// staffFeed reports whether the caller may see moderation events, following
+// the same rules as the admin routes.
+func (h *StreamHandler) staffFeed(c *gin.Context) bool {
+	role := middleware.Role(c)
+	if role != models.RoleAdmin && role != models.RoleModerator {
+		return false
+	}
+	if !middleware.HasScope(c, apitoken.ScopeReview) {
+		return false
+	}
+	if !h.cfg.Require2FAForStaff {
+		return true
+	}
+	uid, _ := middleware.UserID(c)
+	var count int64
+	h.db.Model(&models.User{}).Where("id = ? AND totp_enabled_at IS NOT NULL", uid).Count(&count)
+	return count > 0
+}
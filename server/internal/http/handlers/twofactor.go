@@ -0,0 +1,378 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/apitoken"
+	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/http/middleware"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/A-Words/ne-resource-community/server/internal/secretbox"
+	"github.com/A-Words/ne-resource-community/server/internal/session"
+	"github.com/A-Words/ne-resource-community/server/internal/totp"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	totpIssuer        = "NE Resource Community"
+	recoveryCodeCount = 10
+)
+
+var errBadSecondFactor = errors.New("invalid verification code")
+
+// newTOTPBox returns the box TOTP secrets are sealed with at rest.
+func newTOTPBox(cfg config.Config) *secretbox.Box {
+	master := cfg.TOTPEncryptionKey
+	if master == "" {
+		master = cfg.JWTSecret
+	}
+	box, err := secretbox.New(secretbox.DeriveKey(master, "totp-secret"))
+	if err != nil {
+		panic(err) // derived keys always have a valid size
+	}
+	return box
+}
+
+// SealTOTPSecrets encrypts TOTP secrets stored in plaintext before secrets
+// were sealed. It runs at startup and is a no-op once all are sealed.
+func (h *AuthHandler) SealTOTPSecrets() error {
+	var users []models.User
+	if err := h.db.Select("id", "totp_secret").
+		Where("totp_secret <> '' AND totp_secret NOT LIKE 'enc:%'").Find(&users).Error; err != nil {
+		return err
+	}
+	for _, u := range users {
+		sealed, err := h.totpBox.Seal(u.TOTPSecret)
+		if err != nil {
+			return err
+		}
+		if err := h.db.Model(&models.User{}).Where("id = ? AND totp_secret = ?", u.ID, u.TOTPSecret).
+			Update("totp_secret", sealed).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// totpSecret returns the user's TOTP secret in plaintext.
+func (h *AuthHandler) totpSecret(user models.User) (string, error) {
+	return h.totpBox.Open(user.TOTPSecret)
+}
+
+// completeLogin finishes a successful first factor: users with 2FA receive a
+// challenge token for /auth/2fa/verify, everyone else a session.
+func (h *AuthHandler) completeLogin(c *gin.Context, user models.User) {
+	if user.TwoFactorEnabled() {
+		challenge, err := h.sessions.Challenge(user)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to issue token"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"mfaRequired": true, "mfaToken": challenge})
+		return
+	}
+
+	pair, err := h.sessions.Start(user, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to issue token"})
+		return
+	}
+	if err := h.lockout.Reset(c.Request.Context(), user.ID); err != nil {
+		log.Printf("reset lockout of %s: %v", user.ID, err)
+	}
+	c.JSON(http.StatusOK, tokenResponse(pair, user))
+}
+
+type verifyTwoFactorRequest struct {
+	MFAToken string `json:"mfaToken" binding:"required"`
+	Code     string `json:"code" binding:"required"` // TOTP or recovery code
+}
+
+// VerifyTwoFactor completes a login that answered with mfaRequired.
+func (h *AuthHandler) VerifyTwoFactor(c *gin.Context) {
+	var req verifyTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	uid, version, err := h.sessions.ParseChallenge(req.MFAToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "login expired, please sign in again"})
+		return
+	}
+	var user models.User
+	if err := h.db.First(&user, "id = ?", uid).Error; err != nil || user.TokenVersion != version || !user.TwoFactorEnabled() {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "login expired, please sign in again"})
+		return
+	}
+
+	if wait, locked := h.lockout.LockedUser(user); locked {
+		accountLocked(c, wait)
+		return
+	}
+	if user.IsSuspended(time.Now()) {
+		accountSuspended(c, user)
+		return
+	}
+
+	if err := h.checkSecondFactor(h.db, &user, req.Code); err != nil {
+		if errors.Is(err, errBadSecondFactor) {
+			if err := h.lockout.Fail(c.Request.Context(), user.ID); err != nil {
+				log.Printf("record failed second factor of %s: %v", user.ID, err)
+			}
+		}
+		secondFactorError(c, err)
+		return
+	}
+	pair, err := h.sessions.Start(user, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to issue token"})
+		return
+	}
+	if err := h.lockout.Reset(c.Request.Context(), user.ID); err != nil {
+		log.Printf("reset lockout of %s: %v", user.ID, err)
+	}
+	c.JSON(http.StatusOK, tokenResponse(pair, user))
+}
+
+// TwoFactorStatus reports whether the caller has 2FA and how many recovery
+// codes remain.
+func (h *AuthHandler) TwoFactorStatus(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	var remaining int64
+	h.db.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", user.ID).Count(&remaining)
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":            user.TwoFactorEnabled(),
+		"enabledAt":          user.TOTPEnabledAt,
+		"recoveryCodesLeft":  remaining,
+		"requiredForAccount": h.cfg.Require2FAForStaff && user.Role != models.RoleUser,
+	})
+}
+
+// SetupTwoFactor generates a new TOTP secret. It only takes effect after
+// EnableTwoFactor confirms a code from the authenticator app.
+func (h *AuthHandler) SetupTwoFactor(c *gin.Context) {
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	if user.TwoFactorEnabled() {
+		c.JSON(http.StatusConflict, gin.H{"error": "two-factor authentication is already enabled"})
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate secret"})
+		return
+	}
+	sealed, err := h.totpBox.Seal(secret)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save secret"})
+		return
+	}
+	if err := h.db.Model(&user).Updates(map[string]interface{}{"totp_secret": sealed, "totp_last_step": 0}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save secret"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"secret":          secret,
+		"provisioningUri": totp.ProvisioningURI(totpIssuer, user.Email, secret),
+	})
+}
+
+type twoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// EnableTwoFactor confirms enrollment with a current code and returns the
+// recovery codes. They are shown only once. Other sessions and all personal
+// access tokens are revoked, since they were obtained without the second
+// factor.
+func (h *AuthHandler) EnableTwoFactor(c *gin.Context) {
+	var req twoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	if user.TwoFactorEnabled() {
+		c.JSON(http.StatusConflict, gin.H{"error": "two-factor authentication is already enabled"})
+		return
+	}
+	if user.TOTPSecret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "call setup first"})
+		return
+	}
+	secret, err := h.totpSecret(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read secret"})
+		return
+	}
+	step, valid := totp.Validate(secret, req.Code, time.Now())
+	if !valid {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": errBadSecondFactor.Error()})
+		return
+	}
+
+	var codes []string
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{"totp_enabled_at": time.Now(), "totp_last_step": step}).Error; err != nil {
+			return err
+		}
+		if err := session.RevokeOthers(tx, user.ID, middleware.SessionID(c)); err != nil {
+			return err
+		}
+		if err := apitoken.RevokeAll(tx, user.ID); err != nil {
+			return err
+		}
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to enable two-factor authentication"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"enabled": true, "recoveryCodes": codes})
+}
+
+// DisableTwoFactor turns 2FA off after checking a current or recovery code.
+func (h *AuthHandler) DisableTwoFactor(c *gin.Context) {
+	var req twoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	if !user.TwoFactorEnabled() {
+		c.JSON(http.StatusConflict, gin.H{"error": "two-factor authentication is not enabled"})
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := h.checkSecondFactor(tx, &user, req.Code); err != nil {
+			return err
+		}
+		if err := tx.Model(&user).Updates(map[string]interface{}{"totp_secret": "", "totp_enabled_at": nil, "totp_last_step": 0}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error
+	})
+	if err != nil {
+		secondFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"enabled": false})
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes of the caller.
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req twoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	user, ok := h.currentUser(c)
+	if !ok {
+		return
+	}
+	if !user.TwoFactorEnabled() {
+		c.JSON(http.StatusConflict, gin.H{"error": "two-factor authentication is not enabled"})
+		return
+	}
+
+	var codes []string
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := h.checkSecondFactor(tx, &user, req.Code); err != nil {
+			return err
+		}
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user)
+		return err
+	})
+	if err != nil {
+		secondFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recoveryCodes": codes})
+}
+
+// checkSecondFactor accepts a TOTP code newer than the last one used, or an
+// unused recovery code, and burns it.
+func (h *AuthHandler) checkSecondFactor(tx *gorm.DB, user *models.User, code string) error {
+	secret, err := h.totpSecret(*user)
+	if err != nil {
+		return err
+	}
+	if step, ok := totp.Validate(secret, code, time.Now()); ok {
+		res := tx.Model(&models.User{}).
+			Where("id = ? AND totp_last_step < ?", user.ID, step).
+			Update("totp_last_step", step)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return errBadSecondFactor // replayed code
+		}
+		return nil
+	}
+
+	res := tx.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, totp.HashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errBadSecondFactor
+	}
+	return nil
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, user models.User) ([]string, error) {
+	codes, err := totp.RecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	rows := make([]models.RecoveryCode, len(codes))
+	for i, code := range codes {
+		rows[i] = models.RecoveryCode{UserID: user.ID, CodeHash: totp.HashRecoveryCode(code)}
+	}
+	return codes, tx.Create(&rows).Error
+}
+
+func (h *AuthHandler) currentUser(c *gin.Context) (models.User, bool) {
+	uid, _ := middleware.UserID(c)
+	var user models.User
+	if err := h.db.First(&user, "id = ?", uid).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return user, false
+	}
+	return user, true
+}
+
+func secondFactorError(c *gin.Context, err error) {
+	if errors.Is(err, errBadSecondFactor) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify code"})
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/A-Words/ne-resource-community/server/internal/http/middleware"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/A-Words/ne-resource-community/server/internal/watch"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const maxWatches = 500
+
+// ListWatches returns the resources the caller watches, newest first.
+// Resources the caller may no longer see, as in Get, are listed without
+// their details.
+func (h *ResourceHandler) ListWatches(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	var page pageQuery
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if page.Limit <= 0 || page.Limit > 100 {
+		page.Limit = 50
+	}
+
+	q := h.db.Model(&models.Watch{}).Where("user_id = ?", uid)
+	if source := c.Query("source"); source != "" {
+		q = q.Where("source = ?", source)
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	var watches []models.Watch
+	if err := q.Preload("Resource", activeAuthor("uploader_id")).Order("created_at DESC").
+		Limit(page.Limit).Offset(page.Offset).Find(&watches).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	for i := range watches {
+		if r := watches[i].Resource; r != nil && !visible(c, *r) {
+			watches[i].Resource = nil
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"items": watches, "total": total})
+}
+
+type watchRequest struct {
+	ResourceID uuid.UUID `json:"resourceId" binding:"required"`
+}
+
+// CreateWatch watches a published resource.
+func (h *ResourceHandler) CreateWatch(c *gin.Context) {
+	var req watchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	uid, _ := middleware.UserID(c)
+
+	var resource models.Resource
+	if err := h.db.First(&resource, "id = ? AND status = ?", req.ResourceID, "approved").Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "resource not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		}
+		return
+	}
+	var count int64
+	if err := h.db.Model(&models.Watch{}).Where("user_id = ?", uid).Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	if count >= maxWatches {
+		c.JSON(http.StatusConflict, gin.H{"error": "too many watched resources"})
+		return
+	}
+
+	created, err := watch.Add(h.db, uid, resource.ID, watch.SourceManual)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to watch resource"})
+		return
+	}
+	var w models.Watch
+	if err := h.db.First(&w, "user_id = ? AND resource_id = ?", uid, resource.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	w.Resource = &resource
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, w)
+}
+
+// DeleteWatch stops watching a resource, whatever created the watch. The
+// favorite, if any, stays.
+func (h *ResourceHandler) DeleteWatch(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	rid, err := uuid.Parse(c.Param("resourceId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid resource id"})
+		return
+	}
+	res := h.db.Where("user_id = ? AND resource_id = ?", uid, rid).Delete(&models.Watch{})
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unwatch resource"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "watch not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "unwatched"})
+}
+
+// WatchSettings returns whether favoriting also watches a resource.
+func (h *ResourceHandler) WatchSettings(c *gin.Context) {
+	uid, _ := middleware.UserID(c)
+	var user models.User
+	if err := h.db.Select("watch_favorites").First(&user, "id = ?", uid).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"watchFavorites": user.WatchFavorites})
+}
+
+type watchSettingsRequest struct {
+	WatchFavorites *bool `json:"watchFavorites" binding:"required"`
+}
+
+// UpdateWatchSettings turns favorite-implied watching on or off. Existing
+// watches are kept; turning it off only affects later favorites.
+func (h *ResourceHandler) UpdateWatchSettings(c *gin.Context) {
+	var req watchSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	uid, _ := middleware.UserID(c)
+	if err := h.db.Model(&models.User{}).Where("id = ?", uid).
+		Update("watch_favorites", *req.WatchFavorites).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save watch settings"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"watchFavorites": *req.WatchFavorites})
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/database/dbtest"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestListWatchesHidesInvisibleResources(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := dbtest.Tx(t)
+	watcher := newTestUser(t, db, "watcher", models.RoleUser)
+	uploader := newTestUser(t, db, "uploader", models.RoleUser)
+	suspended := newTestUser(t, db, "suspended", models.RoleUser)
+	until := time.Now().Add(time.Hour)
+	db.Model(&suspended).Update("suspended_until", until)
+
+	resources := []models.Resource{
+		{Title: "approved", Type: "template", UploaderID: uploader.ID, Status: "approved"},
+		{Title: "rejected", Type: "template", UploaderID: uploader.ID, Status: "rejected"},
+		{Title: "by suspended", Type: "template", UploaderID: suspended.ID, Status: "approved"},
+	}
+	for i := range resources {
+		if err := db.Create(&resources[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Create(&models.Watch{UserID: watcher.ID, ResourceID: resources[i].ID, Source: "manual"}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	c.Set("userID", watcher.ID)
+	c.Set("role", watcher.Role)
+	NewResourceHandler(db, config.Config{}).ListWatches(c)
+
+	var body struct {
+		Items []models.Watch `json:"items"`
+		Total int64          `json:"total"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("status %d, body %s", w.Code, w.Body)
+	}
+	if body.Total != 3 || len(body.Items) != 3 {
+		t.Fatalf("total %d, %d items", body.Total, len(body.Items))
+	}
+	for _, item := range body.Items {
+		shown := item.Resource != nil
+		if want := item.ResourceID == resources[0].ID; shown != want {
+			t.Errorf("watch of %s: resource shown = %v, want %v", item.ResourceID, shown, want)
+		}
+	}
+}
+
+func TestCreateVersionRequiresParentUploader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := dbtest.Tx(t)
+	owner := newTestUser(t, db, "owner", models.RoleUser)
+	other := newTestUser(t, db, "other", models.RoleUser)
+	parent := models.Resource{Title: "original", Type: "template", UploaderID: owner.ID, Status: "approved"}
+	if err := db.Create(&parent).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	form := url.Values{"title": {"fork"}, "type": {"template"}, "parentId": {parent.ID.String()}}
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	c.Set("userID", other.ID)
+	c.Set("role", other.Role)
+	NewResourceHandler(db, config.Config{}).Create(c)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("status %d, want 403: %s", w.Code, w.Body)
+	}
+	var count int64
+	db.Model(&models.Resource{}).Where("parent_id = ?", parent.ID).Count(&count)
+	if count != 0 {
+		t.Fatalf("%d versions created", count)
+	}
+}
@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/A-Words/ne-resource-community/server/internal/audit"
+	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/http/middleware"
+	"github.com/A-Words/ne-resource-community/server/internal/linkcheck"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/A-Words/ne-resource-community/server/internal/webhook"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WebhookHandler struct {
+	db  *gorm.DB
+	cfg config.Config
+}
+
+func NewWebhookHandler(db *gorm.DB, cfg config.Config) *WebhookHandler {
+	return &WebhookHandler{db: db, cfg: cfg}
+}
+
+type webhookView struct {
+	models.Webhook
+	Events []string `json:"events"`
+}
+
+func newWebhookView(w models.Webhook) webhookView {
+	return webhookView{Webhook: w, Events: w.EventList()}
+}
+
+type webhookRequest struct {
+	Name         string   `json:"name" binding:"required,max=100"`
+	URL          string   `json:"url" binding:"required,max=1000"`
+	Events       []string `json:"events" binding:"required,min=1"`
+	Active       *bool    `json:"active"`       // defaults to true
+	RotateSecret bool     `json:"rotateSecret"` // update only
+}
+
+// validate checks the event list and that the URL points at a public host.
+func (h *WebhookHandler) validate(c *gin.Context, req webhookRequest) bool {
+	for _, e := range req.Events {
+		if !webhook.Valid(e) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown event " + e + "; expected one of " + strings.Join(webhook.Events, ", ")})
+			return false
+		}
+	}
+	if err := linkcheck.ValidateURL(c.Request.Context(), req.URL); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// List returns all webhooks.
+func (h *WebhookHandler) List(c *gin.Context) {
+	var hooks []models.Webhook
+	if err := h.db.Order("created_at").Find(&hooks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	views := make([]webhookView, 0, len(hooks))
+	for _, w := range hooks {
+		views = append(views, newWebhookView(w))
+	}
+	c.JSON(http.StatusOK, views)
+}
+
+// Create adds a webhook. The signing secret is only returned in this response.
+func (h *WebhookHandler) Create(c *gin.Context) {
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.validate(c, req) {
+		return
+	}
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create webhook"})
+		return
+	}
+	uid, _ := middleware.UserID(c)
+	hook := models.Webhook{
+		Name:        req.Name,
+		URL:         strings.TrimSpace(req.URL),
+		Secret:      secret,
+		Events:      strings.Join(dedupe(req.Events), ","),
+		Active:      req.Active == nil || *req.Active,
+		CreatedByID: uid,
+	}
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&hook).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, c, audit.ActionWebhookCreate, "webhook", hook.ID.String(), nil, newWebhookView(hook))
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create webhook"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"secret": secret, "webhook": newWebhookView(hook)})
+}
+
+// Update changes a webhook and optionally rotates its secret.
+func (h *WebhookHandler) Update(c *gin.Context) {
+	hook, ok := h.load(c)
+	if !ok {
+		return
+	}
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !h.validate(c, req) {
+		return
+	}
+
+	before := newWebhookView(hook)
+	hook.Name = req.Name
+	hook.URL = strings.TrimSpace(req.URL)
+	hook.Events = strings.Join(dedupe(req.Events), ",")
+	if req.Active != nil {
+		hook.Active = *req.Active
+	}
+	var secret string
+	if req.RotateSecret {
+		var err error
+		if secret, err = webhook.NewSecret(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update webhook"})
+			return
+		}
+		hook.Secret = secret
+	}
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&hook).Select("name", "url", "events", "active", "secret").Updates(&hook).Error; err != nil {
+			return err
+		}
+		after := gin.H{"webhook": newWebhookView(hook), "secretRotated": req.RotateSecret}
+		return recordAudit(tx, c, audit.ActionWebhookUpdate, "webhook", hook.ID.String(), before, after)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update webhook"})
+		return
+	}
+	resp := gin.H{"webhook": newWebhookView(hook)}
+	if secret != "" {
+		resp["secret"] = secret
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Delete removes a webhook together with its delivery log.
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	hook, ok := h.load(c)
+	if !ok {
+		return
+	}
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", hook.ID).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&hook).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, c, audit.ActionWebhookDelete, "webhook", hook.ID.String(), newWebhookView(hook), nil)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete webhook"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "webhook deleted"})
+}
+
+// Deliveries pages through a webhook's delivery log, newest first. status
+// filters by pending, delivered or failed.
+func (h *WebhookHandler) Deliveries(c *gin.Context) {
+	hook, ok := h.load(c)
+	if !ok {
+		return
+	}
+	var q pageQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if q.Limit <= 0 || q.Limit > 100 {
+		q.Limit = 50
+	}
+
+	query := h.db.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", hook.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if event := c.Query("event"); event != "" {
+		query = query.Where("event = ?", event)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	var items []models.WebhookDelivery
+	if err := query.Order("created_at DESC, id").Limit(q.Limit).Offset(q.Offset).Find(&items).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"total": total, "items": items})
+}
+
+// Redeliver queues a logged delivery again with the same payload.
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	hook, ok := h.load(c)
+	if !ok {
+		return
+	}
+	if !hook.Active {
+		c.JSON(http.StatusConflict, gin.H{"error": "webhook is disabled"})
+		return
+	}
+	deliveryID, err := uuid.Parse(c.Param("deliveryId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid delivery id"})
+		return
+	}
+	var original models.WebhookDelivery
+	if err := h.db.First(&original, "id = ? AND webhook_id = ?", deliveryID, hook.ID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "delivery not found"})
+		return
+	}
+
+	var queued models.WebhookDelivery
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if queued, err = webhook.Redeliver(tx, original); err != nil {
+			return err
+		}
+		return recordAudit(tx, c, audit.ActionWebhookRedeliver, "webhook", hook.ID.String(), nil,
+			gin.H{"deliveryId": original.ID, "redeliveryId": queued.ID})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to queue redelivery"})
+		return
+	}
+	c.JSON(http.StatusAccepted, queued)
+}
+
+func (h *WebhookHandler) load(c *gin.Context) (models.Webhook, bool) {
+	var hook models.Webhook
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid webhook id"})
+		return hook, false
+	}
+	if err := h.db.First(&hook, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		}
+		return hook, false
+	}
+	return hook, true
+}
+
+func dedupe(items []string) []string {
+	seen := make(map[string]bool, len(items))
+	out := make([]string, 0, len(items))
+	for _, it := range items {
+		if !seen[it] {
+			seen[it] = true
+			out = append(out, it)
+		}
+	}
+	return out
+}
@@ -1,17 +1,25 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/A-Words/ne-resource-community/server/internal/apitoken"
 	"github.com/A-Words/ne-resource-community/server/internal/config"
-	"github.com/golang-jwt/jwt/v5"
-	"github.com/google/uuid"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/A-Words/ne-resource-community/server/internal/session"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
-// AuthMiddleware verifies JWT bearer tokens and injects user id/role into the context.
-func AuthMiddleware(cfg config.Config) gin.HandlerFunc {
+// AuthMiddleware verifies access tokens and injects user id/role/session into
+// the context. Tokens of revoked sessions or outdated token versions are rejected.
+// Personal API tokens are accepted too; routes opt in to them with RequireScope.
+func AuthMiddleware(db *gorm.DB, cfg config.Config) gin.HandlerFunc {
+	sessions := session.NewManager(db, cfg)
 	return func(c *gin.Context) {
 		auth := c.GetHeader("Authorization")
 		if auth == "" || !strings.HasPrefix(strings.ToLower(auth), "bearer ") {
@@ -20,42 +28,99 @@ func AuthMiddleware(cfg config.Config) gin.HandlerFunc {
 		}
 		tokenStr := strings.TrimSpace(auth[7:])
 
-		token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(cfg.JWTSecret), nil
-		})
-		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		cred, err := authenticate(db, sessions, tokenStr, c.ClientIP())
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			return
 		}
-
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid claims"})
-			return
+		c.Set(bearerKey, tokenStr)
+		c.Set("userID", cred.userID)
+		c.Set("role", cred.role)
+		if cred.apiToken != nil {
+			c.Set("apiTokenID", cred.apiToken.ID)
+			c.Set("scopes", cred.apiToken.ScopeList())
+		} else {
+			c.Set("sessionID", cred.sessionID)
 		}
+		c.Next()
+	}
+}
 
-		sub, ok := claims["sub"].(string)
-		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid subject"})
-			return
+// bearerKey holds the credential that authenticated the request, for Recheck.
+const bearerKey = "bearerToken"
+
+// ErrRoleChanged is returned by Recheck when the user's role differs from
+// the one the request was authenticated with.
+var ErrRoleChanged = errors.New("role changed")
+
+type credential struct {
+	userID    uuid.UUID
+	role      string
+	sessionID uuid.UUID
+	apiToken  *models.APIToken
+}
+
+func authenticate(db *gorm.DB, sessions *session.Manager, tokenStr, ip string) (credential, error) {
+	if apitoken.IsToken(tokenStr) {
+		token, err := apitoken.Authenticate(db, tokenStr, ip)
+		if err != nil {
+			return credential{}, err
 		}
+		var user models.User
+		if err := db.Select("id", "role", "suspended_until").First(&user, "id = ?", token.UserID).Error; err != nil {
+			return credential{}, apitoken.ErrInvalid
+		}
+		if user.IsSuspended(time.Now()) {
+			return credential{}, session.ErrSuspended
+		}
+		return credential{userID: user.ID, role: user.Role, apiToken: &token}, nil
+	}
 
-		uid, err := uuid.Parse(sub)
+	claims, err := sessions.Parse(tokenStr)
+	if err != nil {
+		return credential{}, err
+	}
+	return credential{userID: claims.UserID, role: claims.Role, sessionID: claims.SessionID}, nil
+}
+
+// Recheck returns a function that validates the credential of an
+// authenticated request again, for long-lived responses such as event
+// streams. It fails once the access token has expired, the session or API
+// token was revoked, the user was suspended or their role changed.
+func Recheck(db *gorm.DB, cfg config.Config) func(c *gin.Context) error {
+	sessions := session.NewManager(db, cfg)
+	return func(c *gin.Context) error {
+		cred, err := authenticate(db, sessions, c.GetString(bearerKey), c.ClientIP())
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid subject"})
-			return
+			return err
+		}
+		if cred.role != Role(c) {
+			return ErrRoleChanged
 		}
+		return nil
+	}
+}
 
-		role, _ := claims["role"].(string)
-		c.Set("userID", uid)
-		c.Set("role", role)
-		c.Next()
+// OptionalAuth authenticates requests that carry a bearer token like
+// AuthMiddleware and lets anonymous ones through, for public routes that show
+// more to owners and staff.
+func OptionalAuth(db *gorm.DB, cfg config.Config) gin.HandlerFunc {
+	auth := AuthMiddleware(db, cfg)
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		auth(c)
 	}
 }
 
+// IsStaff reports whether the authenticated user is a moderator or admin.
+func IsStaff(c *gin.Context) bool {
+	role := Role(c)
+	return role == models.RoleAdmin || role == models.RoleModerator
+}
+
 // UserID extracts authenticated user id if present.
 func UserID(c *gin.Context) (uuid.UUID, bool) {
 	v, ok := c.Get("userID")
@@ -65,3 +130,149 @@ func UserID(c *gin.Context) (uuid.UUID, bool) {
 	uid, _ := v.(uuid.UUID)
 	return uid, uid != uuid.Nil
 }
+
+// SessionID returns the session of the current access token.
+func SessionID(c *gin.Context) uuid.UUID {
+	v, _ := c.Get("sessionID")
+	sid, _ := v.(uuid.UUID)
+	return sid
+}
+
+// Role returns the role claim of the authenticated user.
+func Role(c *gin.Context) string {
+	return c.GetString("role")
+}
+
+// RequireRole rejects requests whose authenticated role is not one of roles.
+// It must run after AuthMiddleware.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := Role(c)
+		for _, r := range roles {
+			if r == role {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+	}
+}
+
+// IsAPIToken reports whether the request authenticated with a personal token.
+func IsAPIToken(c *gin.Context) bool {
+	_, ok := c.Get("apiTokenID")
+	return ok
+}
+
+// HasScope reports whether the request may act with scope. Browser sessions
+// have every scope.
+func HasScope(c *gin.Context, scope string) bool {
+	if !IsAPIToken(c) {
+		return true
+	}
+	scopes, _ := c.Get("scopes")
+	list, _ := scopes.([]string)
+	return apitoken.Has(list, scope)
+}
+
+// RequireScope lets personal API tokens through only if they carry scope.
+// Browser sessions have every scope. It must run after AuthMiddleware.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if HasScope(c, scope) {
+			c.Next()
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "api token lacks scope " + scope})
+	}
+}
+
+// queryTokenKey holds the access_token query parameter removed by
+// RedactQueryToken.
+const queryTokenKey = "queryToken"
+
+// RedactQueryToken removes the access_token query parameter from the request
+// URL so that it is not written to access logs. It must run before the
+// logger; TokenFromQuery picks the value up again on the routes that take it.
+func RedactQueryToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		q := c.Request.URL.Query()
+		if token := q.Get("access_token"); token != "" {
+			c.Set(queryTokenKey, token)
+			q.Del("access_token")
+			c.Request.URL.RawQuery = q.Encode()
+		}
+		c.Next()
+	}
+}
+
+// TokenFromQuery accepts a session access token in the access_token query
+// parameter for clients such as EventSource that cannot set headers. Personal
+// API tokens are long-lived and must be sent in the Authorization header.
+func TokenFromQuery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.GetString(queryTokenKey)
+		if token == "" {
+			token = c.Query("access_token")
+		}
+		if token != "" && c.GetHeader("Authorization") == "" {
+			if apitoken.IsToken(token) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "api tokens must be sent in the Authorization header"})
+				return
+			}
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
+		c.Next()
+	}
+}
+
+// RequireSession rejects personal API tokens, for account management routes
+// that must not be reachable by scripts. It must run after AuthMiddleware.
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if IsAPIToken(c) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "this endpoint cannot be used with an api token"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireVerifiedEmail blocks users without a verified email address when
+// cfg.RequireVerifiedEmail is set. It must run after AuthMiddleware.
+func RequireVerifiedEmail(db *gorm.DB, cfg config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !cfg.RequireVerifiedEmail {
+			c.Next()
+			return
+		}
+		uid, _ := UserID(c)
+		var count int64
+		db.Model(&models.User{}).Where("id = ? AND email_verified_at IS NOT NULL", uid).Count(&count)
+		if count == 0 {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "please verify your email address first"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireTwoFactor blocks moderators and admins who have not enrolled TOTP
+// when cfg.Require2FAForStaff is set. It must run after AuthMiddleware.
+func RequireTwoFactor(db *gorm.DB, cfg config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := Role(c)
+		if !cfg.Require2FAForStaff || (role != models.RoleAdmin && role != models.RoleModerator) {
+			c.Next()
+			return
+		}
+		uid, _ := UserID(c)
+		var count int64
+		db.Model(&models.User{}).Where("id = ? AND totp_enabled_at IS NOT NULL", uid).Count(&count)
+		if count == 0 {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "two-factor authentication must be enabled for staff accounts", "code": "2fa_required"})
+			return
+		}
+		c.Next()
+	}
+}
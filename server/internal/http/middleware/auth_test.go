@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/apitoken"
+	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/database/dbtest"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/A-Words/ne-resource-community/server/internal/session"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestQueryTokenIsRedactedFromLogs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var logs bytes.Buffer
+	r := gin.New()
+	r.Use(RedactQueryToken(), gin.LoggerWithWriter(&logs))
+	r.GET("/stream", TokenFromQuery(), func(c *gin.Context) {
+		c.String(http.StatusOK, "%s|%s", c.GetHeader("Authorization"), c.Request.URL.RawQuery)
+	})
+	r.GET("/other", func(c *gin.Context) {
+		c.String(http.StatusOK, "%s", c.GetHeader("Authorization"))
+	})
+
+	cases := []struct {
+		name     string
+		url      string
+		wantCode int
+		wantBody string
+	}{
+		{"session token", "/stream?access_token=eyJ.secret.sig&last=1", http.StatusOK, "Bearer eyJ.secret.sig|last=1"},
+		{"api token", "/stream?access_token=" + apitoken.Prefix + "secret", http.StatusUnauthorized, ""},
+		{"other route ignores it", "/other?access_token=eyJ.secret.sig", http.StatusOK, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			logs.Reset()
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.url, nil))
+			if w.Code != tc.wantCode {
+				t.Fatalf("status = %d, want %d", w.Code, tc.wantCode)
+			}
+			if tc.wantCode == http.StatusOK && w.Body.String() != tc.wantBody {
+				t.Errorf("body = %q, want %q", w.Body, tc.wantBody)
+			}
+			if strings.Contains(logs.String(), "secret") {
+				t.Errorf("token logged: %s", logs.String())
+			}
+		})
+	}
+}
+
+func TestRecheck(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := dbtest.Tx(t)
+	cfg := config.Config{JWTSecret: "test", AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour}
+	user := models.User{Email: "stream-" + uuid.NewString()[:8] + "@example.com", DisplayName: "Stream", Role: models.RoleModerator}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	pair, err := session.NewManager(db, cfg).Start(user, "test", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, raw, err := apitoken.Issue(db, user.ID, "ci", []string{apitoken.ScopeRead}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	auth, recheck := AuthMiddleware(db, cfg), Recheck(db, cfg)
+	login := func(token string) *gin.Context {
+		t.Helper()
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		c.Request.Header.Set("Authorization", "Bearer "+token)
+		auth(c)
+		if c.IsAborted() {
+			t.Fatal("authentication failed")
+		}
+		return c
+	}
+	sessionCtx, tokenCtx := login(pair.AccessToken), login(raw)
+	for _, c := range []*gin.Context{sessionCtx, tokenCtx} {
+		if err := recheck(c); err != nil {
+			t.Fatalf("fresh credential: %v", err)
+		}
+	}
+
+	db.Model(&user).Update("role", models.RoleUser)
+	for _, c := range []*gin.Context{sessionCtx, tokenCtx} {
+		if err := recheck(c); !errors.Is(err, ErrRoleChanged) {
+			t.Errorf("after demotion: err = %v, want ErrRoleChanged", err)
+		}
+	}
+
+	db.Model(&user).Update("role", models.RoleModerator)
+	if err := session.Invalidate(db, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := apitoken.RevokeAll(db, user.ID); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []*gin.Context{sessionCtx, tokenCtx} {
+		if err := recheck(c); err == nil {
+			t.Error("revoked credential passed recheck")
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit throttles requests per key and reports the bucket state in the
+// RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers. A nil
+// limiter lets everything through.
+func RateLimit(l *ratelimit.Limiter, key func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if l == nil {
+			c.Next()
+			return
+		}
+		res := l.Allow(key(c))
+		c.Header("RateLimit-Limit", strconv.Itoa(res.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Header("RateLimit-Reset", seconds(res.Reset))
+		if !res.Allowed {
+			c.Header("Retry-After", seconds(res.RetryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "too many requests, please slow down"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// ByIP keys rate limits by client address. Forwarded headers are only
+// honoured from TRUSTED_PROXIES, so clients cannot pick their own bucket.
+func ByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// ByUser keys rate limits by authenticated user, falling back to the client
+// address. It must run after AuthMiddleware.
+func ByUser(c *gin.Context) string {
+	if uid, ok := UserID(c); ok {
+		return "user:" + uid.String()
+	}
+	return ByIP(c)
+}
+
+// seconds formats d as whole seconds, rounded up.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
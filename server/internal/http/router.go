@@ -1,70 +1,216 @@
 package http
 
 import (
+	"log"
+	"path/filepath"
+
+	"github.com/A-Words/ne-resource-community/server/internal/apitoken"
 	"github.com/A-Words/ne-resource-community/server/internal/config"
 	"github.com/A-Words/ne-resource-community/server/internal/http/handlers"
 	"github.com/A-Words/ne-resource-community/server/internal/http/middleware"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/A-Words/ne-resource-community/server/internal/ratelimit"
+	"github.com/A-Words/ne-resource-community/server/internal/realtime"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-// NewRouter wires up gin.Engine with routes and middleware.
-func NewRouter(db *gorm.DB, cfg config.Config) *gin.Engine {
-	r := gin.Default()
+// NewRouter wires up gin.Engine with routes and middleware. hub delivers
+// real-time events to streams opened on this replica.
+func NewRouter(db *gorm.DB, cfg config.Config, hub *realtime.Hub) *gin.Engine {
+	r := gin.New()
+	// Client IPs feed rate limits, sessions and the audit log, so forwarded
+	// headers only count when they come from a configured proxy.
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("invalid TRUSTED_PROXIES: %v", err)
+	}
+	r.Use(middleware.RedactQueryToken(), gin.Logger(), gin.Recovery())
 	r.Use(cors.Default())
 
+	authMiddleware := middleware.AuthMiddleware(db, cfg)
+	optionalAuth := middleware.OptionalAuth(db, cfg)
+	requireVerified := middleware.RequireVerifiedEmail(db, cfg)
+	// Every authenticated route states whether personal API tokens may call
+	// it (RequireScope) or only browser sessions (sessionOnly).
+	sessionOnly := middleware.RequireSession()
+	scopeRead := middleware.RequireScope(apitoken.ScopeRead)
+	scopeUpload := middleware.RequireScope(apitoken.ScopeUpload)
+	userLimit := middleware.RateLimit(ratelimit.New(cfg.RateLimitUser.Requests, cfg.RateLimitUser.Per), middleware.ByUser)
 	authHandler := handlers.NewAuthHandler(db, cfg)
+	oidcHandler := handlers.NewOIDCHandler(db, cfg)
 	resourceHandler := handlers.NewResourceHandler(db, cfg)
 	requestHandler := handlers.NewRequestHandler(db, cfg)
+	auditHandler := handlers.NewAuditHandler(db, cfg)
+	moderationHandler := handlers.NewModerationHandler(db, cfg)
+	adminUserHandler := handlers.NewAdminUserHandler(db, cfg)
+	profileHandler := handlers.NewProfileHandler(db, cfg)
+	pointsHandler := handlers.NewPointsHandler(db, cfg)
+	notificationHandler := handlers.NewNotificationHandler(db, cfg)
+	streamHandler := handlers.NewStreamHandler(db, cfg, hub)
+	webhookHandler := handlers.NewWebhookHandler(db, cfg)
+	followHandler := handlers.NewFollowHandler(db, cfg)
+	feedHandler := handlers.NewFeedHandler(db, cfg)
 
 	api := r.Group("/api")
+	api.Use(middleware.RateLimit(ratelimit.New(cfg.RateLimitAPI.Requests, cfg.RateLimitAPI.Per), middleware.ByIP))
 	{
 		api.GET("/health", handlers.Health)
+		// EventSource cannot send headers, so the stream also takes a session
+		// token as ?access_token=.
+		api.GET("/stream", middleware.TokenFromQuery(), authMiddleware, userLimit, scopeRead, streamHandler.Stream)
 
 		auth := api.Group("/auth")
+		auth.Use(middleware.RateLimit(ratelimit.New(cfg.RateLimitAuth.Requests, cfg.RateLimitAuth.Per), middleware.ByIP))
 		auth.POST("/register", authHandler.Register)
 		auth.POST("/login", authHandler.Login)
+		auth.POST("/refresh", authHandler.Refresh)
+		auth.POST("/logout", authHandler.Logout)
+		auth.POST("/verify-email", authHandler.VerifyEmail)
+		auth.POST("/forgot-password", authHandler.ForgotPassword)
+		auth.POST("/reset-password", authHandler.ResetPassword)
+		auth.POST("/2fa/verify", authHandler.VerifyTwoFactor)
+		auth.GET("/oidc/providers", oidcHandler.Providers)
+		auth.GET("/oidc/:provider/login", oidcHandler.Login)
+		auth.GET("/oidc/:provider/callback", oidcHandler.Callback)
 
 		resources := api.Group("/resources")
 		resources.GET("", resourceHandler.List)
 		resources.GET("/tags/popular", resourceHandler.GetPopularTags)
-		resources.GET(":id", resourceHandler.Get)
-		resources.GET(":id/recommendations", resourceHandler.Recommend)
-		resources.GET(":id/versions", resourceHandler.GetVersions)
+		resources.GET(":id", optionalAuth, resourceHandler.Get)
+		resources.GET(":id/recommendations", optionalAuth, resourceHandler.Recommend)
+		resources.GET(":id/versions", optionalAuth, resourceHandler.GetVersions)
 
 		protected := resources.Group("")
-		protected.Use(middleware.AuthMiddleware(cfg))
-		protected.POST("", resourceHandler.Create)
-		protected.POST(":id/reviews", resourceHandler.Review)
-		protected.POST(":id/favorite", resourceHandler.ToggleFavorite)
-		protected.POST(":id/report", resourceHandler.ReportResource)
-		protected.GET(":id/download", resourceHandler.Download)
-		protected.POST(":id/progress", resourceHandler.UpdateProgress)
-		protected.GET(":id/progress", resourceHandler.GetProgress)
+		protected.Use(authMiddleware, userLimit)
+		protected.POST("", scopeUpload, requireVerified, resourceHandler.Create)
+		protected.POST(":id/mirror", scopeUpload, resourceHandler.Mirror)
+		protected.POST(":id/reviews", sessionOnly, requireVerified, resourceHandler.Review)
+		protected.POST(":id/favorite", sessionOnly, resourceHandler.ToggleFavorite)
+		protected.POST(":id/report", sessionOnly, resourceHandler.ReportResource)
+		protected.GET(":id/download", scopeRead, resourceHandler.Download)
+		protected.POST(":id/progress", sessionOnly, resourceHandler.UpdateProgress)
+		protected.GET(":id/progress", scopeRead, resourceHandler.GetProgress)
+
+		// Linked from digest emails; the token identifies the user.
+		api.GET("/digest/unsubscribe", followHandler.UnsubscribePage)
+		api.POST("/digest/unsubscribe", followHandler.Unsubscribe)
+
+		// Atom by default, RSS 2.0 with ?format=rss.
+		feeds := api.Group("/feeds")
+		feeds.GET("/resources", feedHandler.Resources)
+		feeds.GET("/resources/:id/versions", feedHandler.Versions)
+		feeds.GET("/requests", feedHandler.Requests)
+
+		users := api.Group("/users")
+		users.GET(":id", profileHandler.Get)
+		users.GET(":id/uploads", profileHandler.Uploads)
 
 		user := api.Group("/user")
-		user.Use(middleware.AuthMiddleware(cfg))
-		user.POST("/change-password", authHandler.ChangePassword)
-		user.GET("/favorites", resourceHandler.ListFavorites)
-		user.GET("/downloads", resourceHandler.ListDownloads)
-		user.GET("/uploads", resourceHandler.ListMyUploads)
+		user.Use(authMiddleware, userLimit)
+		user.GET("/favorites", scopeRead, resourceHandler.ListFavorites)
+		user.GET("/downloads", scopeRead, resourceHandler.ListDownloads)
+		user.GET("/uploads", scopeRead, resourceHandler.ListMyUploads)
+		user.GET("/quota", scopeRead, resourceHandler.UploadQuota)
+		user.GET("/profile", scopeRead, profileHandler.Me)
+		user.GET("/points", scopeRead, pointsHandler.History)
+		user.GET("/matches", scopeRead, requestHandler.MyMatches)
+		user.GET("/notifications", scopeRead, notificationHandler.List)
+		user.GET("/notifications/unread-count", scopeRead, notificationHandler.UnreadCount)
+		user.POST("/notifications/:id/read", sessionOnly, notificationHandler.MarkRead)
+		user.POST("/notifications/read-all", sessionOnly, notificationHandler.MarkAllRead)
+		user.POST("/matches/:id/submit", sessionOnly, requestHandler.SubmitMatch)
+		user.GET("/follows", scopeRead, followHandler.List)
+		user.GET("/searches", scopeRead, resourceHandler.ListSavedSearches)
+		user.GET("/searches/:id/run", scopeRead, resourceHandler.RunSavedSearch)
+		user.GET("/digest", scopeRead, followHandler.Digest)
+		user.GET("/watches", scopeRead, resourceHandler.ListWatches)
+		user.GET("/watch-settings", scopeRead, resourceHandler.WatchSettings)
+
+		account := user.Group("", sessionOnly)
+		account.PUT("/profile", profileHandler.Update)
+		account.POST("/avatar", profileHandler.UploadAvatar)
+		account.DELETE("/avatar", profileHandler.DeleteAvatar)
+		account.GET("/notification-preferences", notificationHandler.Preferences)
+		account.PUT("/notification-preferences", notificationHandler.UpdatePreferences)
+		account.POST("/follows", followHandler.Create)
+		account.DELETE("/follows/:id", followHandler.Delete)
+		account.PUT("/digest", followHandler.UpdateDigest)
+		account.POST("/searches", resourceHandler.CreateSavedSearch)
+		account.PUT("/searches/:id", resourceHandler.UpdateSavedSearch)
+		account.DELETE("/searches/:id", resourceHandler.DeleteSavedSearch)
+		account.POST("/watches", resourceHandler.CreateWatch)
+		account.DELETE("/watches/:resourceId", resourceHandler.DeleteWatch)
+		account.PUT("/watch-settings", resourceHandler.UpdateWatchSettings)
+		account.POST("/change-password", authHandler.ChangePassword)
+		account.POST("/verify-email/resend", authHandler.ResendVerification)
+		account.GET("/sessions", authHandler.ListSessions)
+		account.DELETE("/sessions", authHandler.RevokeOtherSessions)
+		account.DELETE("/sessions/:id", authHandler.RevokeSession)
+		account.GET("/2fa", authHandler.TwoFactorStatus)
+		account.POST("/2fa/setup", authHandler.SetupTwoFactor)
+		account.POST("/2fa/enable", authHandler.EnableTwoFactor)
+		account.POST("/2fa/disable", authHandler.DisableTwoFactor)
+		account.POST("/2fa/recovery-codes", authHandler.RegenerateRecoveryCodes)
+		account.GET("/tokens", authHandler.ListAPITokens)
+		account.POST("/tokens", authHandler.CreateAPIToken)
+		account.DELETE("/tokens/:id", authHandler.RevokeAPIToken)
 
-		// Admin routes (simplified, reusing auth middleware but should check role)
+		// Moderators share the review routes; audit logs and user management are admin only.
 		admin := api.Group("/admin")
-		admin.Use(middleware.AuthMiddleware(cfg))
+		admin.Use(authMiddleware, userLimit, middleware.RequireRole(models.RoleAdmin, models.RoleModerator), middleware.RequireTwoFactor(db, cfg), middleware.RequireScope(apitoken.ScopeReview))
 		admin.GET("/pending", resourceHandler.AdminListPending)
 		admin.POST("/resources/:id/audit", resourceHandler.AdminAuditResource)
 		admin.GET("/reports", resourceHandler.AdminListReports)
 		admin.POST("/reports/:id/resolve", resourceHandler.AdminResolveReport)
+		admin.GET("/queue", moderationHandler.Queue)
+		admin.GET("/queue/stats", moderationHandler.Stats)
+		admin.POST("/queue/:id/claim", moderationHandler.Claim)
+		admin.POST("/queue/:id/release", moderationHandler.Release)
+		admin.POST("/requests/:id/cancel", requestHandler.AdminCancel)
+		adminOnly := admin.Group("", middleware.RequireRole(models.RoleAdmin), middleware.RequireScope(apitoken.ScopeAdmin))
+		adminOnly.GET("/audit-logs", auditHandler.List)
+		adminOnly.GET("/audit-logs/export", auditHandler.Export)
+		adminOnly.GET("/users", adminUserHandler.List)
+		adminOnly.GET("/users/:id", adminUserHandler.Get)
+		adminOnly.GET("/users/:id/uploads", adminUserHandler.Uploads)
+		adminOnly.GET("/users/:id/reviews", adminUserHandler.Reviews)
+		adminOnly.GET("/users/:id/reports", adminUserHandler.Reports)
+		adminOnly.PUT("/users/:id/role", adminUserHandler.SetRole)
+		adminOnly.POST("/users/:id/suspend", adminUserHandler.Suspend)
+		adminOnly.POST("/users/:id/unsuspend", adminUserHandler.Unsuspend)
+		adminOnly.POST("/users/:id/reset-password", adminUserHandler.ResetPassword)
+		adminOnly.POST("/users/:id/merge", adminUserHandler.Merge)
+		adminOnly.POST("/users/:id/identities", adminUserHandler.LinkIdentity)
+		adminOnly.GET("/points/reconcile", pointsHandler.CheckBalances)
+		adminOnly.POST("/points/reconcile", pointsHandler.Reconcile)
+		adminOnly.GET("/webhooks", webhookHandler.List)
+		adminOnly.POST("/webhooks", webhookHandler.Create)
+		adminOnly.PUT("/webhooks/:id", webhookHandler.Update)
+		adminOnly.DELETE("/webhooks/:id", webhookHandler.Delete)
+		adminOnly.GET("/webhooks/:id/deliveries", webhookHandler.Deliveries)
+		adminOnly.POST("/webhooks/:id/deliveries/:deliveryId/redeliver", webhookHandler.Redeliver)
 
 		requests := api.Group("/requests")
 		requests.GET("", requestHandler.List)
-		requests.POST("", middleware.AuthMiddleware(cfg), requestHandler.Create)
+		requests.POST("", authMiddleware, userLimit, sessionOnly, requestHandler.Create)
+		requests.GET(":id", requestHandler.Get)
+		requests.GET(":id/answers", optionalAuth, requestHandler.ListAnswers)
+		requests.GET(":id/comments", requestHandler.ListComments)
+		requests.GET(":id/suggestions", requestHandler.Suggestions)
+		requests.POST(":id/suggestions/:matchId/dismiss", authMiddleware, userLimit, sessionOnly, requestHandler.DismissSuggestion)
+		requests.POST(":id/comments", authMiddleware, userLimit, sessionOnly, requestHandler.CreateComment)
+		requests.DELETE(":id/comments/:commentId", authMiddleware, userLimit, sessionOnly, requestHandler.DeleteComment)
+		requests.POST(":id/vote", authMiddleware, userLimit, sessionOnly, requestHandler.Vote)
+		requests.POST(":id/bounty", authMiddleware, userLimit, sessionOnly, requestHandler.TopUp)
+		requests.POST(":id/answers", authMiddleware, userLimit, sessionOnly, requestHandler.Answer)
+		requests.POST(":id/answers/:answerId/accept", authMiddleware, userLimit, sessionOnly, requestHandler.Accept)
+		requests.POST(":id/close", authMiddleware, userLimit, sessionOnly, requestHandler.Close)
 	}
 
-	// Serve uploaded files statically for previews.
-	r.Static("/uploads", cfg.UploadDir)
+	// Only avatars are served statically; resource files go through Download,
+	// which checks the resource status.
+	r.Static(handlers.AvatarURLPrefix, filepath.Join(cfg.UploadDir, handlers.AvatarDir))
 
 	return r
 }
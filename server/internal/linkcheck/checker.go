@@ -0,0 +1,177 @@
+package linkcheck
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/events"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/A-Words/ne-resource-community/server/internal/textutil"
+	"github.com/A-Words/ne-resource-community/server/internal/watch"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Link status values stored on models.Resource.
+const (
+	StatusOK      = "ok"
+	StatusFailing = "failing"
+	StatusBroken  = "broken"
+)
+
+// batchSize caps how many links a single run probes.
+const batchSize = 500
+
+// CheckerOptions tunes the periodic external link checker.
+type CheckerOptions struct {
+	Concurrency  int           // parallel probes
+	BrokenAfter  int           // consecutive failures before a link is marked broken
+	RecheckAfter time.Duration // minimum age of the last check before probing again
+}
+
+// Checker probes external links of resources and records their health.
+type Checker struct {
+	db     *gorm.DB
+	client *http.Client
+	opts   CheckerOptions
+}
+
+// NewChecker creates a Checker. client is typically built with NewClient; tests
+// can pass a client pointed at a local HTTP stand-in.
+func NewChecker(db *gorm.DB, client *http.Client, opts CheckerOptions) *Checker {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	if opts.BrokenAfter <= 0 {
+		opts.BrokenAfter = 1
+	}
+	return &Checker{db: db, client: client, opts: opts}
+}
+
+// Run probes every due external link once.
+func (c *Checker) Run(ctx context.Context) error {
+	var resources []models.Resource
+	cutoff := time.Now().Add(-c.opts.RecheckAfter)
+	err := c.db.WithContext(ctx).
+		Where("external_link <> '' AND status IN ?", []string{"pending", "approved"}).
+		Where("link_checked_at IS NULL OR link_checked_at < ?", cutoff).
+		Order("link_checked_at ASC NULLS FIRST").
+		Limit(batchSize).
+		Find(&resources).Error
+	if err != nil {
+		return fmt.Errorf("load resources: %w", err)
+	}
+
+	sem := make(chan struct{}, c.opts.Concurrency)
+	var wg sync.WaitGroup
+	for i := range resources {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(r *models.Resource) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			code, probeErr := c.Probe(ctx, r.ExternalLink)
+			if err := c.record(ctx, r, code, probeErr); err != nil {
+				log.Printf("link check: record %s: %v", r.ID, err)
+			}
+		}(&resources[i])
+	}
+	wg.Wait()
+	return nil
+}
+
+// Probe requests link and returns the final HTTP status code. Any status of
+// 400 or above is reported as an error.
+func (c *Checker) Probe(ctx context.Context, link string) (int, error) {
+	code, err := c.do(ctx, http.MethodHead, link)
+	// Plenty of file hosts reject HEAD; retry with a ranged GET.
+	if err == nil && (code == http.StatusMethodNotAllowed || code == http.StatusNotImplemented || code == http.StatusForbidden) {
+		code, err = c.do(ctx, http.MethodGet, link)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if code >= http.StatusBadRequest {
+		return code, fmt.Errorf("unexpected status %d", code)
+	}
+	return code, nil
+}
+
+func (c *Checker) do(ctx context.Context, method, link string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, link, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", "ne-resource-community-linkcheck/1.0")
+	if method == http.MethodGet {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	return resp.StatusCode, nil
+}
+
+func (c *Checker) record(ctx context.Context, r *models.Resource, code int, probeErr error) error {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"link_checked_at": now,
+		"link_http_code":  code,
+	}
+
+	becameBroken := false
+	if probeErr == nil {
+		updates["link_status"] = StatusOK
+		updates["link_failures"] = 0
+		updates["link_error"] = ""
+	} else {
+		failures := r.LinkFailures + 1
+		status := StatusFailing
+		if failures >= c.opts.BrokenAfter {
+			status = StatusBroken
+			becameBroken = r.LinkStatus != StatusBroken
+		}
+		updates["link_status"] = status
+		updates["link_failures"] = failures
+		updates["link_error"] = textutil.Truncate(probeErr.Error(), 255)
+	}
+
+	if err := c.db.WithContext(ctx).Model(&models.Resource{}).Where("id = ?", r.ID).Updates(updates).Error; err != nil {
+		return err
+	}
+	if becameBroken {
+		body := fmt.Sprintf("The external link of \"%s\" failed %d consecutive checks: %s", r.Title, c.opts.BrokenAfter, r.ExternalLink)
+		db := c.db.WithContext(ctx)
+		if err := events.Publish(db, events.Event{
+			Type:       events.TypeLinkBroken,
+			Recipients: []uuid.UUID{r.UploaderID},
+			Title:      "External link is broken",
+			Body:       body,
+			RefType:    "resource",
+			RefID:      r.ID,
+		}); err != nil {
+			return err
+		}
+		return watch.Notify(db, r.ID, r.UploaderID, events.Event{
+			Type:    events.TypeWatchLinkBroken,
+			Title:   "Watched resource link is broken",
+			Body:    fmt.Sprintf("The external link of \"%s\" stopped working.", r.Title),
+			RefType: "resource",
+			RefID:   r.ID,
+		})
+	}
+	return nil
+}
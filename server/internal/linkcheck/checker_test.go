@@ -0,0 +1,74 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestChecker(allowPrivate bool) *Checker {
+	client := NewClient(ClientOptions{Timeout: 5 * time.Second, MaxRedirects: 2, AllowPrivate: allowPrivate})
+	return NewChecker(nil, client, CheckerOptions{})
+}
+
+func TestProbe(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/missing", http.NotFound)
+	mux.HandleFunc("/head-rejected", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Range") != "bytes=0-0" {
+			t.Errorf("fallback GET without range header")
+		}
+		w.WriteHeader(http.StatusPartialContent)
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusFound)
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	mux.HandleFunc("/ftp", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "ftp://example.com/file", http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	tests := []struct {
+		path     string
+		wantCode int
+		wantErr  bool
+	}{
+		{"/ok", http.StatusOK, false},
+		{"/missing", http.StatusNotFound, true},
+		{"/head-rejected", http.StatusPartialContent, false},
+		{"/redirect", http.StatusOK, false},
+		{"/loop", 0, true},
+		{"/ftp", 0, true},
+	}
+	c := newTestChecker(true)
+	for _, tt := range tests {
+		code, err := c.Probe(context.Background(), srv.URL+tt.path)
+		if code != tt.wantCode || (err != nil) != tt.wantErr {
+			t.Errorf("Probe(%s) = %d, %v; want %d, error %v", tt.path, code, err, tt.wantCode, tt.wantErr)
+		}
+	}
+}
+
+func TestProbeRefusesPrivateAddress(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("guarded client reached the loopback server")
+	}))
+	defer srv.Close()
+
+	_, err := newTestChecker(false).Probe(context.Background(), srv.URL)
+	if !errors.Is(err, ErrPrivateAddress) {
+		t.Fatalf("Probe = %v, want %v", err, ErrPrivateAddress)
+	}
+}
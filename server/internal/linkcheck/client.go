@@ -0,0 +1,48 @@
+package linkcheck
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ClientOptions configures the outbound HTTP client used for external links.
+type ClientOptions struct {
+	Timeout      time.Duration
+	MaxRedirects int
+	// AllowPrivate disables the public-address guard. Only meant for tests
+	// that point the client at a local HTTP stand-in.
+	AllowPrivate bool
+}
+
+// NewClient builds an http.Client that only talks to public addresses, caps
+// redirects and applies an overall timeout.
+func NewClient(opts ClientOptions) *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if !opts.AllowPrivate {
+		dialer.Control = guardedControl
+	}
+	transport := &http.Transport{
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: opts.Timeout,
+		MaxIdleConnsPerHost:   2,
+	}
+
+	maxRedirects := opts.MaxRedirects
+	return &http.Client{
+		Timeout:   opts.Timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			if !allowedSchemes[req.URL.Scheme] {
+				return ErrUnsupportedScheme
+			}
+			return nil
+		},
+	}
+}
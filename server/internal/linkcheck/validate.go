@@ -0,0 +1,117 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+var (
+	ErrInvalidURL        = errors.New("invalid url")
+	ErrUnsupportedScheme = errors.New("only http and https links are allowed")
+	ErrPrivateAddress    = errors.New("link points to a private or loopback address")
+)
+
+// allowedSchemes lists the URL schemes accepted for external links.
+var allowedSchemes = map[string]bool{
+	"http":  true,
+	"https": true,
+}
+
+// ValidateURL checks that raw is an absolute http(s) URL whose host resolves
+// only to public addresses.
+func ValidateURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return ErrInvalidURL
+	}
+	if !allowedSchemes[strings.ToLower(u.Scheme)] {
+		return ErrUnsupportedScheme
+	}
+	if u.User != nil {
+		return fmt.Errorf("%w: credentials in url are not allowed", ErrInvalidURL)
+	}
+
+	host := u.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		if !IsPublicIP(ip) {
+			return ErrPrivateAddress
+		}
+		return nil
+	}
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return ErrPrivateAddress
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("%w: cannot resolve host %s", ErrInvalidURL, host)
+	}
+	for _, a := range addrs {
+		if !IsPublicIP(a.IP) {
+			return ErrPrivateAddress
+		}
+	}
+	return nil
+}
+
+// IsPublicIP reports whether ip is routable on the public internet.
+func IsPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, n := range reservedNets {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// reservedNets are special-purpose ranges net.IP does not classify. NAT64
+// prefixes embed an IPv4 address that may well be private.
+var reservedNets = mustParseCIDRs(
+	"0.0.0.0/8",       // "this network"
+	"100.64.0.0/10",   // carrier-grade NAT
+	"192.0.0.0/24",    // IETF protocol assignments
+	"192.0.2.0/24",    // documentation
+	"198.18.0.0/15",   // benchmarking
+	"198.51.100.0/24", // documentation
+	"203.0.113.0/24",  // documentation
+	"240.0.0.0/4",     // reserved, includes broadcast
+	"64:ff9b::/96",    // NAT64
+	"64:ff9b:1::/48",  // local-use NAT64
+	"2001:db8::/32",   // documentation
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+// guardedControl is a net.Dialer Control hook that refuses connections to
+// non-public addresses, which also covers DNS answers that change after ValidateURL.
+func guardedControl(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !IsPublicIP(ip) {
+		return ErrPrivateAddress
+	}
+	return nil
+}
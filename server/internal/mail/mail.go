@@ -0,0 +1,167 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/config"
+)
+
+// Message is a multipart text/HTML email.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+	Headers map[string]string // extra headers, e.g. List-Unsubscribe
+}
+
+// Sender delivers email messages.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewSender returns an SMTP sender when SMTP_ADDR is configured and a
+// logging sender otherwise.
+func NewSender(cfg config.Config) Sender {
+	if cfg.SMTPAddr == "" {
+		return &LogSender{Bodies: cfg.MailLogBodies}
+	}
+	return &SMTPSender{
+		Addr:     cfg.SMTPAddr,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		From:     cfg.MailFrom,
+	}
+}
+
+// SMTPSender sends mail through an SMTP relay. STARTTLS is used when the
+// server offers it, so a local catcher such as MailHog works unchanged.
+type SMTPSender struct {
+	Addr     string
+	Username string
+	Password string
+	From     string
+}
+
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(30 * time.Second)
+	}
+	conn, err := (&net.Dialer{Deadline: deadline}).DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return fmt.Errorf("dial smtp: %w", err)
+	}
+	conn.SetDeadline(deadline)
+
+	host, _, _ := net.SplitHostPort(s.Addr)
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp handshake: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+	if s.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.Username, s.Password, host)); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+	if err := client.Mail(addressOf(s.From)); err != nil {
+		return err
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(build(s.From, msg)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// LogSender logs messages instead of sending them. It is used when no SMTP
+// server is configured. Bodies carry live account links, so they are only
+// logged when Bodies is set (MAIL_LOG_BODIES, for local development).
+type LogSender struct {
+	Bodies bool
+}
+
+func (s LogSender) Send(_ context.Context, msg Message) error {
+	if s.Bodies {
+		log.Printf("mail (not sent, SMTP_ADDR unset) to=%s subject=%q\n%s", msg.To, msg.Subject, msg.Text)
+		return nil
+	}
+	log.Printf("mail (not sent, SMTP_ADDR unset) to=%s subject=%q", msg.To, msg.Subject)
+	return nil
+}
+
+// build renders msg as an RFC 5322 multipart/alternative message.
+func build(from string, msg Message) []byte {
+	boundary := randomBoundary()
+	var b bytes.Buffer
+	header := func(k, v string) { fmt.Fprintf(&b, "%s: %s\r\n", k, v) }
+	header("From", from)
+	header("To", msg.To)
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	for k, v := range msg.Headers {
+		header(k, v)
+	}
+	header("Content-Type", `multipart/alternative; boundary="`+boundary+`"`)
+	b.WriteString("\r\n")
+
+	part := func(contentType, body string) {
+		fmt.Fprintf(&b, "--%s\r\n", boundary)
+		header("Content-Type", contentType+"; charset=utf-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		b.WriteString("\r\n")
+		qp := quotedprintable.NewWriter(&b)
+		qp.Write([]byte(body))
+		qp.Close()
+		b.WriteString("\r\n")
+	}
+	part("text/plain", msg.Text)
+	if msg.HTML != "" {
+		part("text/html", msg.HTML)
+	}
+	fmt.Fprintf(&b, "--%s--\r\n", boundary)
+	return b.Bytes()
+}
+
+func addressOf(from string) string {
+	if i := strings.LastIndex(from, "<"); i >= 0 {
+		return strings.TrimSuffix(from[i+1:], ">")
+	}
+	return from
+}
+
+func randomBoundary() string {
+	buf := make([]byte, 12)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
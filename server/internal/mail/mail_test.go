@@ -0,0 +1,236 @@
+package mail
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	netmail "net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+// catcher is a minimal SMTP server that records one message per session.
+// It offers neither STARTTLS nor AUTH, like a local mail catcher.
+type catcher struct {
+	ln   net.Listener
+	mail chan caught
+}
+
+type caught struct {
+	from string
+	rcpt []string
+	data []byte
+}
+
+func newCatcher(t *testing.T) *catcher {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &catcher{ln: ln, mail: make(chan caught, 1)}
+	t.Cleanup(func() { ln.Close() })
+	go c.serve()
+	return c
+}
+
+func (c *catcher) serve() {
+	for {
+		conn, err := c.ln.Accept()
+		if err != nil {
+			return
+		}
+		go c.session(conn)
+	}
+}
+
+func (c *catcher) session(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(s string) { io.WriteString(conn, s+"\r\n") }
+	reply("220 catcher ready")
+	var msg caught
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch {
+		case verb == "EHLO" || verb == "HELO":
+			reply("250 catcher")
+		case strings.HasPrefix(strings.ToUpper(line), "MAIL FROM:"):
+			msg.from = strings.Trim(line[len("MAIL FROM:"):], "<> ")
+			reply("250 ok")
+		case strings.HasPrefix(strings.ToUpper(line), "RCPT TO:"):
+			msg.rcpt = append(msg.rcpt, strings.Trim(line[len("RCPT TO:"):], "<> "))
+			reply("250 ok")
+		case verb == "DATA":
+			reply("354 end with <CRLF>.<CRLF>")
+			var data bytes.Buffer
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, "."))
+			}
+			msg.data = data.Bytes()
+			c.mail <- msg
+			reply("250 queued")
+		case verb == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func TestSMTPSenderSend(t *testing.T) {
+	c := newCatcher(t)
+	s := &SMTPSender{Addr: c.ln.Addr().String(), From: "Community <no-reply@example.com>"}
+	msg := Message{
+		To:      "alice@example.com",
+		Subject: "重置密码 / Reset",
+		Text:    "plain body with a long line " + strings.Repeat("x", 100),
+		HTML:    "<p>html body</p>",
+		Headers: map[string]string{"List-Unsubscribe": "<https://example.com/u>"},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.Send(ctx, msg); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	var got caught
+	select {
+	case got = <-c.mail:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+	if got.from != "no-reply@example.com" {
+		t.Errorf("MAIL FROM = %q", got.from)
+	}
+	if len(got.rcpt) != 1 || got.rcpt[0] != msg.To {
+		t.Errorf("RCPT TO = %v", got.rcpt)
+	}
+
+	parsed, err := netmail.ReadMessage(bytes.NewReader(got.data))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != msg.Subject {
+		t.Errorf("Subject = %q (%v), want %q", subject, err, msg.Subject)
+	}
+	if got := parsed.Header.Get("List-Unsubscribe"); got != msg.Headers["List-Unsubscribe"] {
+		t.Errorf("List-Unsubscribe = %q", got)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q (%v)", mediaType, err)
+	}
+	parts := map[string]string{}
+	mr := multipart.NewReader(parsed.Body, params["boundary"])
+	for {
+		p, err := mr.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read part: %v", err)
+		}
+		ct, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		body, err := io.ReadAll(quotedprintable.NewReader(p))
+		if err != nil {
+			t.Fatalf("decode %s: %v", ct, err)
+		}
+		parts[ct] = string(body)
+	}
+	if parts["text/plain"] != msg.Text {
+		t.Errorf("text part = %q", parts["text/plain"])
+	}
+	if parts["text/html"] != msg.HTML {
+		t.Errorf("html part = %q", parts["text/html"])
+	}
+}
+
+func TestSMTPSenderUnreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	s := &SMTPSender{Addr: addr, From: "no-reply@example.com"}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.Send(ctx, Message{To: "alice@example.com", Text: "x"}); err == nil {
+		t.Fatal("Send to a closed port succeeded")
+	}
+}
+
+func TestRender(t *testing.T) {
+	data := struct {
+		DisplayName string
+		Link        string
+		Hours       int
+	}{"Alice", "https://example.com/reset?token=abc", 1}
+
+	for _, name := range []string{"verify_email", "reset_password"} {
+		t.Run(name, func(t *testing.T) {
+			msg, err := Render(name, "alice@example.com", data)
+			if err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if msg.To != "alice@example.com" || msg.Subject == "" {
+				t.Errorf("To = %q, Subject = %q", msg.To, msg.Subject)
+			}
+			if strings.Contains(msg.Subject, "\n") {
+				t.Errorf("Subject spans lines: %q", msg.Subject)
+			}
+			if !strings.Contains(msg.Text, data.Link) || !strings.Contains(msg.HTML, data.Link) {
+				t.Error("link missing from text or HTML body")
+			}
+		})
+	}
+}
+
+func TestLogSenderHidesBodies(t *testing.T) {
+	var buf bytes.Buffer
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(&buf)
+
+	msg := Message{To: "alice@example.com", Subject: "Reset", Text: "https://example.com/reset?token=secret"}
+	if err := (LogSender{}).Send(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "token=secret") {
+		t.Errorf("body was logged: %q", buf.String())
+	}
+	if !strings.Contains(buf.String(), "alice@example.com") {
+		t.Errorf("recipient missing from log: %q", buf.String())
+	}
+
+	buf.Reset()
+	if err := (LogSender{Bodies: true}).Send(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "token=secret") {
+		t.Errorf("body not logged with Bodies set: %q", buf.String())
+	}
+}
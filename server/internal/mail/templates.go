@@ -0,0 +1,43 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var (
+	textTemplates = texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/*.txt.tmpl"))
+	htmlTemplates = htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/*.html.tmpl"))
+)
+
+// Render builds a message from the named template pair. The text template
+// must define a "subject" block; the HTML template is optional.
+func Render(name, to string, data interface{}) (Message, error) {
+	msg := Message{To: to}
+
+	var subject, text bytes.Buffer
+	if err := textTemplates.ExecuteTemplate(&subject, name+".subject", data); err != nil {
+		return msg, fmt.Errorf("render subject %s: %w", name, err)
+	}
+	if err := textTemplates.ExecuteTemplate(&text, name+".txt.tmpl", data); err != nil {
+		return msg, fmt.Errorf("render text %s: %w", name, err)
+	}
+	msg.Subject = strings.TrimSpace(subject.String())
+	msg.Text = text.String()
+
+	if t := htmlTemplates.Lookup(name + ".html.tmpl"); t != nil {
+		var html bytes.Buffer
+		if err := t.Execute(&html, data); err != nil {
+			return msg, fmt.Errorf("render html %s: %w", name, err)
+		}
+		msg.HTML = html.String()
+	}
+	return msg, nil
+}
@@ -0,0 +1,179 @@
+package matcher
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/A-Words/ne-resource-community/server/internal/events"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Match statuses.
+const (
+	StatusSuggested = "suggested"
+	StatusSubmitted = "submitted" // linked as an answer
+	StatusDismissed = "dismissed" // rejected by the requester
+)
+
+const (
+	maxSuggestions = 10  // per approved resource
+	candidateLimit = 200 // open requests scored per resource
+)
+
+// Bonuses added to the text rank for shared attributes.
+const (
+	vendorBonus   = 0.3
+	protocolBonus = 0.2
+	tagBonus      = 0.1 // per shared tag
+	maxTagBonus   = 0.3
+
+	// minContent is the text rank plus tag bonus a request needs before the
+	// vendor and protocol bonuses count.
+	minContent = 0.01
+)
+
+type candidate struct {
+	ID       uuid.UUID
+	UserID   uuid.UUID
+	Title    string
+	Vendor   string
+	Protocol string
+	Tags     string
+	TextRank float64
+}
+
+// candidatesSQL ranks open requests against an OR query built from the
+// resource's title and tags, so any shared term counts. Requests sharing the
+// vendor or protocol are candidates even without common words.
+const candidatesSQL = `
+WITH q AS (
+	SELECT to_tsquery('simple', string_agg('''' || lexeme || '''', ' | ')) AS query
+	FROM unnest(to_tsvector('english', ?))
+	WHERE lexeme ~ '^[[:alnum:]._-]+$'
+)
+SELECT r.id, r.user_id, r.title, r.vendor, r.protocol, r.tags,
+	COALESCE(ts_rank(r.search_vector, q.query), 0) AS text_rank
+FROM requests r, q
+WHERE r.status = 'open' AND r.user_id <> ?
+	AND ((q.query IS NOT NULL AND r.search_vector @@ q.query)
+		OR (? <> '' AND r.vendor ILIKE ?)
+		OR (? <> '' AND r.protocol ILIKE ?))
+ORDER BY text_rank DESC
+LIMIT ?`
+
+// Run suggests an approved resource to the owners of open requests it scores
+// at least minScore against. Each pair is suggested once; requesters and the
+// uploader are notified of new suggestions.
+func Run(tx *gorm.DB, resource models.Resource, minScore float64) ([]models.RequestMatch, error) {
+	var candidates []candidate
+	err := tx.Raw(candidatesSQL,
+		resource.Title+" "+strings.ReplaceAll(resource.Tags, ",", " "),
+		resource.UploaderID,
+		resource.Vendor, resource.Vendor,
+		resource.Protocol, resource.Protocol,
+		candidateLimit,
+	).Scan(&candidates).Error
+	if err != nil {
+		return nil, fmt.Errorf("match candidates: %w", err)
+	}
+
+	var matches []models.RequestMatch
+	for _, cand := range candidates {
+		if s := score(resource, cand); s >= minScore {
+			matches = append(matches, models.RequestMatch{RequestID: cand.ID, ResourceID: resource.ID, Score: s})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if len(matches) > maxSuggestions {
+		matches = matches[:maxSuggestions]
+	}
+
+	owners := make(map[uuid.UUID]candidate, len(candidates))
+	for _, cand := range candidates {
+		owners[cand.ID] = cand
+	}
+	var created []models.RequestMatch
+	for _, m := range matches {
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&m)
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected == 0 {
+			continue // suggested on an earlier approval
+		}
+		created = append(created, m)
+		req := owners[m.RequestID]
+		body := fmt.Sprintf("The newly approved resource \"%s\" may answer your request \"%s\".", resource.Title, req.Title)
+		if err := events.Publish(tx, events.Event{
+			Type:       events.TypeRequestMatch,
+			Recipients: []uuid.UUID{req.UserID},
+			Title:      "Possible answer to your request",
+			Body:       body,
+			RefType:    "request",
+			RefID:      req.ID,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(created) > 0 {
+		body := fmt.Sprintf("\"%s\" may answer %d open request(s). Link it as an answer to claim their bounties.", resource.Title, len(created))
+		if err := events.Publish(tx, events.Event{
+			Type:       events.TypeRequestMatch,
+			Recipients: []uuid.UUID{resource.UploaderID},
+			Title:      "Your resource matches open requests",
+			Body:       body,
+			RefType:    "resource",
+			RefID:      resource.ID,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return created, nil
+}
+
+// score combines the text rank with bonuses for shared vendor, protocol and
+// tags. Requests sharing no words or tags with the resource score 0, so a
+// common vendor or protocol alone is never a match.
+func score(resource models.Resource, c candidate) float64 {
+	shared := 0.0
+	requestTags := tagSet(c.Tags)
+	for tag := range tagSet(resource.Tags) {
+		if requestTags[tag] {
+			shared += tagBonus
+		}
+	}
+	if shared > maxTagBonus {
+		shared = maxTagBonus
+	}
+	total := c.TextRank + shared
+	if total < minContent {
+		return 0
+	}
+	if sameValue(resource.Vendor, c.Vendor) {
+		total += vendorBonus
+	}
+	if sameValue(resource.Protocol, c.Protocol) {
+		total += protocolBonus
+	}
+	return total
+}
+
+func sameValue(a, b string) bool {
+	a = strings.TrimSpace(a)
+	return a != "" && strings.EqualFold(a, strings.TrimSpace(b))
+}
+
+func tagSet(tags string) map[string]bool {
+	set := make(map[string]bool)
+	for _, t := range strings.Split(tags, ",") {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			set[t] = true
+		}
+	}
+	return set
+}
@@ -0,0 +1,36 @@
+package matcher
+
+import (
+	"math"
+	"testing"
+
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+)
+
+func TestScore(t *testing.T) {
+	resource := models.Resource{Vendor: "Cisco", Protocol: "BGP", Tags: "bgp, route-map, ios, nx-os"}
+	cases := []struct {
+		name string
+		c    candidate
+		want float64
+	}{
+		{"nothing shared", candidate{Vendor: "Juniper", Tags: "junos"}, 0},
+		{"vendor only", candidate{Vendor: " cisco "}, 0},
+		{"vendor and protocol only", candidate{Vendor: "cisco", Protocol: "bgp"}, 0},
+		{"text only", candidate{TextRank: 0.06}, 0.06},
+		{"text and vendor", candidate{TextRank: 0.06, Vendor: "CISCO"}, 0.06 + vendorBonus},
+		{"one tag", candidate{Tags: "BGP"}, tagBonus},
+		{"one tag, vendor and protocol", candidate{Tags: "bgp", Vendor: "cisco", Protocol: "bgp"}, tagBonus + vendorBonus + protocolBonus},
+		{"tag bonus capped", candidate{Tags: "bgp,route-map,ios,nx-os"}, maxTagBonus},
+		{"empty vendor is no match", candidate{TextRank: 0.06, Vendor: ""}, 0.06},
+	}
+	for _, tc := range cases {
+		if got := score(resource, tc.c); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("%s: score = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	if got := score(models.Resource{Tags: "bgp"}, candidate{TextRank: 0.06, Tags: "bgp"}); math.Abs(got-(0.06+tagBonus)) > 1e-9 {
+		t.Errorf("resource without vendor: score = %v", got)
+	}
+}
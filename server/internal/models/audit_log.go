@@ -0,0 +1,41 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrAuditLogImmutable is returned when code tries to modify an audit record.
+var ErrAuditLogImmutable = errors.New("audit log entries are append-only")
+
+// AuditLog records a privileged action. Rows are never updated or deleted.
+type AuditLog struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	ActorID    *uuid.UUID `gorm:"type:uuid;index" json:"actorId"` // nil for CLI tools
+	Actor      string     `gorm:"size:255" json:"actor"`          // email or tool name at the time of the action
+	Action     string     `gorm:"size:64;index" json:"action"`    // e.g. resource.approve
+	TargetType string     `gorm:"size:32;index" json:"targetType"`
+	TargetID   string     `gorm:"size:64;index" json:"targetId"`
+	Before     string     `gorm:"type:text" json:"before"` // JSON snapshot of changed fields
+	After      string     `gorm:"type:text" json:"after"`
+	IP         string     `gorm:"size:64" json:"ip"`
+	CreatedAt  time.Time  `gorm:"index" json:"createdAt"`
+}
+
+func (a *AuditLog) BeforeCreate(_ *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+func (a *AuditLog) BeforeUpdate(_ *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
+
+func (a *AuditLog) BeforeDelete(_ *gorm.DB) error {
+	return ErrAuditLogImmutable
+}
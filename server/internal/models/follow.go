@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Follow subscribes a user to a topic for email digests.
+type Follow struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_follows_user_topic" json:"userId"`
+	Kind      string    `gorm:"size:16;uniqueIndex:idx_follows_user_topic" json:"kind"` // vendor, protocol, tag, scenario
+	Value     string    `gorm:"size:128;uniqueIndex:idx_follows_user_topic" json:"value"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (f *Follow) BeforeCreate(_ *gorm.DB) error {
+	if f.ID == uuid.Nil {
+		f.ID = uuid.New()
+	}
+	return nil
+}
+
+// DigestSubscription holds how often a user receives the email digest.
+type DigestSubscription struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey" json:"-"`
+	UserID     uuid.UUID  `gorm:"type:uuid;uniqueIndex" json:"-"`
+	Frequency  string     `gorm:"size:16;index" json:"frequency"` // off, daily, weekly
+	LastSentAt *time.Time `json:"lastSentAt"`                     // end of the last covered period
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+func (d *DigestSubscription) BeforeCreate(_ *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ModerationItem is an entry in the moderation queue. It points either at a
+// pending resource or at a pending report.
+type ModerationItem struct {
+	ID             uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	Kind           string     `gorm:"size:16;index:idx_moderation_target" json:"kind"` // resource, report
+	TargetID       uuid.UUID  `gorm:"type:uuid;index:idx_moderation_target" json:"targetId"`
+	Priority       int        `gorm:"default:0" json:"priority"`                // higher is handled first
+	Status         string     `gorm:"size:16;default:open;index" json:"status"` // open, done
+	ClaimedByID    *uuid.UUID `gorm:"type:uuid;index" json:"claimedById"`
+	ClaimedAt      *time.Time `json:"claimedAt"`
+	ClaimExpiresAt *time.Time `json:"claimExpiresAt"`
+	Escalated      bool       `gorm:"default:false" json:"escalated"` // waited past the SLA
+	EscalatedAt    *time.Time `json:"escalatedAt"`
+	ResolvedByID   *uuid.UUID `gorm:"type:uuid;index" json:"resolvedById"`
+	ResolvedAt     *time.Time `json:"resolvedAt"`
+	Outcome        string     `gorm:"size:32" json:"outcome"` // approve, reject, resolved...
+	CreatedAt      time.Time  `gorm:"index" json:"createdAt"`
+	UpdatedAt      time.Time  `json:"updatedAt"`
+}
+
+func (m *ModerationItem) BeforeCreate(_ *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
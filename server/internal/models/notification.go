@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Notification is an in-app message addressed to a single user.
+type Notification struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;index" json:"userId"`
+	Type      string     `gorm:"size:64;index" json:"type"` // e.g. resource.link_broken
+	Title     string     `gorm:"size:255" json:"title"`
+	Body      string     `gorm:"type:text" json:"body"`
+	RefType   string     `gorm:"size:32" json:"refType"` // resource, request, report
+	RefID     *uuid.UUID `gorm:"type:uuid" json:"refId"`
+	ReadAt    *time.Time `json:"readAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+func (n *Notification) BeforeCreate(_ *gorm.DB) error {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NotificationPreference records whether a user wants notifications of one
+// type. Types without a row are delivered.
+type NotificationPreference struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_notification_preferences_user_type" json:"userId"`
+	Type      string    `gorm:"size:64;uniqueIndex:idx_notification_preferences_user_type" json:"type"`
+	Enabled   bool      `json:"enabled"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (p *NotificationPreference) BeforeCreate(_ *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PointEntry is one change to a user's points. The ledger is the source of
+// truth; User.Points caches the running balance.
+type PointEntry struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;index:idx_point_entries_user_created" json:"userId"`
+	Delta     int       `json:"delta"`
+	Balance   int       `json:"balance"` // balance after this entry
+	Reason    string    `gorm:"size:32;index" json:"reason"`
+	RefType   string    `gorm:"size:32" json:"refType"`
+	RefID     uuid.UUID `gorm:"type:uuid;index" json:"refId"`
+	Note      string    `gorm:"size:255" json:"note"`
+	CreatedAt time.Time `gorm:"index:idx_point_entries_user_created" json:"createdAt"`
+}
+
+func (p *PointEntry) BeforeCreate(_ *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
@@ -1,22 +1,39 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// Report categories.
+const (
+	ReportMalware           = "malware"
+	ReportCopyright         = "copyright"
+	ReportWrongMetadata     = "wrong_metadata"
+	ReportBrokenLink        = "broken_link"
+	ReportSpam              = "spam"
+	ReportLeakedCredentials = "leaked_credentials"
+	ReportOther             = "other"
+)
+
 // Report represents a user report on a resource.
 type Report struct {
-	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
-	UserID     uuid.UUID `gorm:"type:uuid;index" json:"userId"`
-	User       User      `json:"user"`
-	ResourceID uuid.UUID `gorm:"type:uuid;index" json:"resourceId"`
-	Resource   Resource  `json:"resource"`
-	Reason     string    `gorm:"size:512" json:"reason"`
-	Status     string    `gorm:"size:32;default:pending" json:"status"` // pending, resolved
-	CreatedAt  time.Time `json:"createdAt"`
+	ID             uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID         uuid.UUID  `gorm:"type:uuid;index" json:"userId"`
+	User           User       `json:"user"`
+	ResourceID     uuid.UUID  `gorm:"type:uuid;index" json:"resourceId"`
+	Resource       Resource   `json:"resource"`
+	Category       string     `gorm:"size:32;default:other;index" json:"category"`
+	Reason         string     `gorm:"size:512" json:"reason"`
+	Status         string     `gorm:"size:32;default:pending" json:"status"` // pending, resolved, dismissed
+	Resolution     string     `gorm:"size:32" json:"resolution"`             // action applied by the moderator
+	ResolutionNote string     `gorm:"size:512" json:"resolutionNote"`
+	ResolvedByID   *uuid.UUID `gorm:"type:uuid" json:"resolvedById"`
+	ResolvedAt     *time.Time `json:"resolvedAt"`
+	CreatedAt      time.Time  `json:"createdAt"`
 }
 
 func (r *Report) BeforeCreate(_ *gorm.DB) error {
@@ -25,3 +42,13 @@ func (r *Report) BeforeCreate(_ *gorm.DB) error {
 	}
 	return nil
 }
+
+// MarshalJSON embeds the public projection of User so emails never leak
+// through listings.
+func (r Report) MarshalJSON() ([]byte, error) {
+	type plain Report
+	return json.Marshal(struct {
+		plain
+		User PublicUser `json:"user"`
+	}{plain(r), r.User.Public()})
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,12 +12,23 @@ type Request struct {
 	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
 	Title       string    `gorm:"size:255;not null" json:"title"`
 	Description string    `gorm:"type:text" json:"description"`
-	Bounty      int       `gorm:"default:0" json:"bounty"`
-	Status      string    `gorm:"size:32;default:open" json:"status"` // open, fulfilled, closed
+	Vendor      string    `gorm:"size:128" json:"vendor"`
+	Protocol    string    `gorm:"size:128" json:"protocol"`
+	Tags        string    `gorm:"size:512" json:"tags"`                     // comma-separated
+	Bounty      int       `gorm:"default:0" json:"bounty"`                  // escrowed points, including top-ups
+	Status      string    `gorm:"size:32;default:open;index" json:"status"` // open, fulfilled, closed, cancelled, expired
 	UserID      uuid.UUID `gorm:"type:uuid" json:"userId"`
 	User        User      `json:"user"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
+
+	AcceptedAnswerID *uuid.UUID `gorm:"type:uuid" json:"acceptedAnswerId"`
+	ExpiresAt        *time.Time `gorm:"index" json:"expiresAt"` // open requests are refunded and expired after this
+	ClosedAt         *time.Time `json:"closedAt"`
+
+	VoteCount    int `gorm:"default:0" json:"voteCount"`
+	CommentCount int `gorm:"default:0" json:"commentCount"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
 }
 
 func (r *Request) BeforeCreate(_ *gorm.DB) error {
@@ -25,3 +37,13 @@ func (r *Request) BeforeCreate(_ *gorm.DB) error {
 	}
 	return nil
 }
+
+// MarshalJSON embeds the public projection of User so emails never leak
+// through listings.
+func (r Request) MarshalJSON() ([]byte, error) {
+	type plain Request
+	return json.Marshal(struct {
+		plain
+		User PublicUser `json:"user"`
+	}{plain(r), r.User.Public()})
+}
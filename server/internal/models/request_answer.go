@@ -0,0 +1,37 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RequestAnswer offers a resource as the answer to a request. The submitter
+// receives the bounty when the requester accepts it.
+type RequestAnswer struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	RequestID  uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_request_answers_request_resource" json:"requestId"`
+	ResourceID uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_request_answers_request_resource" json:"resourceId"`
+	Resource   Resource  `json:"resource"`
+	UserID     uuid.UUID `gorm:"type:uuid;index" json:"userId"`
+	User       User      `json:"user"`
+	Note       string    `gorm:"type:text" json:"note"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+func (a *RequestAnswer) BeforeCreate(_ *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+func (a RequestAnswer) MarshalJSON() ([]byte, error) {
+	type plain RequestAnswer
+	return json.Marshal(struct {
+		plain
+		User PublicUser `json:"user"`
+	}{plain(a), a.User.Public()})
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RequestComment is a comment on a request. Replies point at their parent
+// comment.
+type RequestComment struct {
+	ID        uuid.UUID        `gorm:"type:uuid;primaryKey" json:"id"`
+	RequestID uuid.UUID        `gorm:"type:uuid;index" json:"requestId"`
+	ParentID  *uuid.UUID       `gorm:"type:uuid;index" json:"parentId"`
+	UserID    uuid.UUID        `gorm:"type:uuid;index" json:"userId"`
+	User      User             `json:"user"`
+	Body      string           `gorm:"type:text" json:"body"`
+	Deleted   bool             `json:"deleted"` // body removed, kept for its replies
+	Replies   []RequestComment `gorm:"-" json:"replies"`
+	CreatedAt time.Time        `json:"createdAt"`
+	UpdatedAt time.Time        `json:"updatedAt"`
+}
+
+func (rc *RequestComment) BeforeCreate(_ *gorm.DB) error {
+	if rc.ID == uuid.Nil {
+		rc.ID = uuid.New()
+	}
+	return nil
+}
+
+func (rc RequestComment) MarshalJSON() ([]byte, error) {
+	type plain RequestComment
+	return json.Marshal(struct {
+		plain
+		User PublicUser `json:"user"`
+	}{plain(rc), rc.User.Public()})
+}
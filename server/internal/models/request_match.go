@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RequestMatch suggests an approved resource as a likely answer to an open
+// request.
+type RequestMatch struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	RequestID  uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_request_matches_request_resource" json:"requestId"`
+	Request    Request   `json:"request"`
+	ResourceID uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_request_matches_request_resource;index" json:"resourceId"`
+	Resource   Resource  `json:"resource"`
+	Score      float64   `json:"score"`
+	Status     string    `gorm:"size:16;default:suggested;index" json:"status"` // suggested, submitted, dismissed
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+func (m *RequestMatch) BeforeCreate(_ *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RequestVote is a user's upvote on a request.
+type RequestVote struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	RequestID uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_request_votes_request_user" json:"requestId"`
+	UserID    uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_request_votes_request_user" json:"userId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (v *RequestVote) BeforeCreate(_ *gorm.DB) error {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+	return nil
+}
+
+// BountyContribution is points another user added to a request's bounty. It
+// is refunded to them if the request ends without an accepted answer.
+type BountyContribution struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	RequestID uuid.UUID `gorm:"type:uuid;index" json:"requestId"`
+	UserID    uuid.UUID `gorm:"type:uuid;index" json:"userId"`
+	Amount    int       `json:"amount"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (b *BountyContribution) BeforeCreate(_ *gorm.DB) error {
+	if b.ID == uuid.Nil {
+		b.ID = uuid.New()
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,13 +19,25 @@ type Resource struct {
 	Protocol      string     `gorm:"size:128" json:"protocol"`
 	Scenario      string     `gorm:"size:128" json:"scenario"`
 	Tags          string     `gorm:"size:512" json:"tags"` // comma-separated
-	FilePath      string     `gorm:"size:512" json:"filePath"`
+	FilePath      string     `gorm:"size:512" json:"-"`    // server-side path, never sent to clients
 	FileName      string     `gorm:"size:255" json:"fileName"`
 	ContentType   string     `gorm:"size:128" json:"contentType"`
-	FileHash      string     `gorm:"size:64;index" json:"fileHash"`         // SHA256
-	ExternalLink  string     `gorm:"size:512" json:"externalLink"`          // Optional external link
-	Status        string     `gorm:"size:32;default:pending" json:"status"` // pending, approved, rejected
+	FileHash      string     `gorm:"size:64;index" json:"fileHash"`          // SHA256
+	FileSize      int64      `gorm:"default:0" json:"fileSize"`              // Bytes stored locally
+	ExternalLink  string     `gorm:"size:512" json:"externalLink"`           // Optional external link
+	LinkStatus    string     `gorm:"size:16" json:"linkStatus"`              // "", ok, failing, broken
+	LinkHTTPCode  int        `json:"linkHttpCode"`                           // Status code of the last probe
+	LinkError     string     `gorm:"size:255" json:"linkError"`              // Error of the last failed probe
+	LinkFailures  int        `gorm:"default:0" json:"linkFailures"`          // Consecutive failed checks
+	LinkCheckedAt *time.Time `json:"linkCheckedAt"`                          // Last probe time
+	MirroredAt    *time.Time `json:"mirroredAt"`                             // Set once ExternalLink was copied into local storage
+	MirrorStatus  string     `gorm:"size:16;default:''" json:"mirrorStatus"` // "", queued, running, done, failed
+	MirrorError   string     `gorm:"size:255" json:"mirrorError"`            // Why the last mirror failed
+	MirrorByID    *uuid.UUID `gorm:"type:uuid" json:"-"`                     // Who requested the pending mirror
+	MirrorLease   *time.Time `json:"-"`                                      // A running mirror is retried after this
+	Status        string     `gorm:"size:32;default:pending" json:"status"`  // pending, approved, rejected
 	RejectReason  string     `gorm:"size:255" json:"rejectReason"`
+	ApprovedAt    *time.Time `gorm:"index" json:"approvedAt"` // first approval
 	DownloadCount int64      `gorm:"default:0" json:"downloadCount"`
 	RatingAverage float64    `gorm:"default:0" json:"ratingAverage"`
 	RatingCount   int64      `gorm:"default:0" json:"ratingCount"`
@@ -37,9 +50,39 @@ type Resource struct {
 	SearchVector  string     `gorm:"type:tsvector" json:"-"`
 }
 
+// HasTag is a SQL condition on the comma-separated tags column of resources
+// and requests. It matches rows carrying the lower-cased tag bound to ? as one
+// whole tag, so "bgp" does not match "ebgp".
+const HasTag = `? = ANY(string_to_array(btrim(regexp_replace(lower(tags), '\s*,\s*', ',', 'g')), ','))`
+
+// VersionChain selects the id of every resource linked to the one bound to ?
+// through ParentID: it walks up to the oldest version and back down all
+// branches.
+const VersionChain = `
+WITH RECURSIVE up AS (
+	SELECT id, parent_id FROM resources WHERE id = ?
+	UNION
+	SELECT r.id, r.parent_id FROM resources r JOIN up ON r.id = up.parent_id
+), down AS (
+	SELECT id FROM up WHERE parent_id IS NULL OR parent_id NOT IN (SELECT id FROM up)
+	UNION
+	SELECT r.id FROM resources r JOIN down ON r.parent_id = down.id
+)
+SELECT id FROM down`
+
 func (r *Resource) BeforeCreate(_ *gorm.DB) error {
 	if r.ID == uuid.Nil {
 		r.ID = uuid.New()
 	}
 	return nil
 }
+
+// MarshalJSON embeds the public projection of Uploader so emails never leak
+// through listings.
+func (r Resource) MarshalJSON() ([]byte, error) {
+	type plain Resource
+	return json.Marshal(struct {
+		plain
+		Uploader PublicUser `json:"uploader"`
+	}{plain(r), r.Uploader.Public()})
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestResourceJSONHidesFilePath(t *testing.T) {
+	raw, err := json.Marshal(Resource{Title: "config", FilePath: "/srv/uploads/2024/secret.bin", FileName: "backup.cfg"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "secret.bin") || strings.Contains(string(raw), "filePath") {
+		t.Errorf("file path leaked: %s", raw)
+	}
+	if !strings.Contains(string(raw), `"fileName":"backup.cfg"`) {
+		t.Errorf("fileName missing: %s", raw)
+	}
+}
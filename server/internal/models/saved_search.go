@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SavedSearch is a named resource query. Its filters mirror the query
+// parameters of GET /api/resources.
+type SavedSearch struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID      uuid.UUID  `gorm:"type:uuid;index" json:"userId"`
+	Name        string     `gorm:"size:100" json:"name"`
+	Search      string     `gorm:"size:255" json:"search"`
+	Type        string     `gorm:"size:64" json:"type"`
+	Vendor      string     `gorm:"size:128" json:"vendor"`
+	Device      string     `gorm:"size:128" json:"device"`
+	Protocol    string     `gorm:"size:128" json:"protocol"`
+	Scenario    string     `gorm:"size:128" json:"scenario"`
+	Tag         string     `gorm:"size:64" json:"tag"`
+	Sort        string     `gorm:"size:16" json:"sort"`
+	Alert       bool       `gorm:"index" json:"alert"` // notify on newly approved matches
+	NewMatches  int        `json:"newMatches"`         // approved matches since the last run
+	LastMatchAt *time.Time `json:"lastMatchAt"`
+	LastRunAt   *time.Time `json:"lastRunAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+func (s *SavedSearch) BeforeCreate(_ *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
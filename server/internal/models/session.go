@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Session is a logged-in device. It holds the hash of the current refresh
+// token, which is rotated on every refresh.
+type Session struct {
+	ID              uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID          uuid.UUID  `gorm:"type:uuid;index" json:"userId"`
+	RefreshHash     string     `gorm:"size:64;uniqueIndex" json:"-"`
+	PrevRefreshHash string     `gorm:"size:64;index" json:"-"` // previous token, kept to detect reuse
+	TokenVersion    int        `json:"-"`
+	UserAgent       string     `gorm:"size:255" json:"userAgent"`
+	IP              string     `gorm:"size:64" json:"ip"`
+	LastUsedAt      time.Time  `json:"lastUsedAt"`
+	ExpiresAt       time.Time  `json:"expiresAt"`
+	RevokedAt       *time.Time `json:"revokedAt"`
+	CreatedAt       time.Time  `json:"createdAt"`
+}
+
+func (s *Session) BeforeCreate(_ *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
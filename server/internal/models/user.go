@@ -8,17 +8,40 @@ import (
 	"gorm.io/gorm"
 )
 
+// User roles.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// BannedUntil is stored as SuspendedUntil for permanent bans.
+var BannedUntil = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
 // User represents a platform user (uploader, reviewer, or admin).
 type User struct {
-	ID           uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
-	Email        string    `gorm:"uniqueIndex;size:255;not null" json:"email"`
-	PasswordHash string    `gorm:"not null" json:"-"`
-	DisplayName  string    `gorm:"size:255" json:"displayName"`
-	Role         string    `gorm:"size:32;default:user" json:"role"`
-	Points       int       `gorm:"default:0" json:"points"`
-	Level        int       `gorm:"-" json:"level"`
-	CreatedAt    time.Time `json:"createdAt"`
-	UpdatedAt    time.Time `json:"updatedAt"`
+	ID              uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	Email           string     `gorm:"uniqueIndex;size:255;not null" json:"email"`
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt"`
+	PasswordHash    string     `gorm:"not null" json:"-"`
+	DisplayName     string     `gorm:"size:255" json:"displayName"`
+	Bio             string     `gorm:"size:500" json:"bio"`
+	AvatarURL       string     `gorm:"size:255" json:"avatarUrl"`
+	Role            string     `gorm:"size:32;default:user" json:"role"`
+	Points          int        `gorm:"default:0" json:"points"`
+	Level           int        `gorm:"-" json:"level"`
+	Warnings        int        `gorm:"default:0" json:"warnings"`
+	SuspendedUntil  *time.Time `json:"suspendedUntil"`
+	SuspendReason   string     `gorm:"size:255" json:"suspendReason"`
+	TokenVersion    int        `gorm:"default:0" json:"-"` // bumped to invalidate issued access tokens
+	TOTPSecret      string     `gorm:"size:128" json:"-"`  // sealed with secretbox on enrollment, active once TOTPEnabledAt is set
+	TOTPEnabledAt   *time.Time `json:"totpEnabledAt"`
+	TOTPLastStep    int64      `gorm:"default:0" json:"-"` // last accepted time step, blocks code replay
+	FailedLogins    int        `gorm:"default:0" json:"-"` // consecutive failures, reset on success
+	LockedUntil     *time.Time `json:"lockedUntil"`
+	WatchFavorites  bool       `gorm:"default:true" json:"watchFavorites"` // favoriting a resource also watches it
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
 }
 
 func (u *User) BeforeCreate(_ *gorm.DB) error {
@@ -52,3 +75,40 @@ func (u *User) SetPassword(raw string) error {
 func (u *User) CheckPassword(raw string) bool {
 	return bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(raw)) == nil
 }
+
+// IsSuspended reports whether the account is suspended at the given time.
+func (u *User) IsSuspended(now time.Time) bool {
+	return u.SuspendedUntil != nil && u.SuspendedUntil.After(now)
+}
+
+// TwoFactorEnabled reports whether login requires a TOTP code.
+func (u *User) TwoFactorEnabled() bool {
+	return u.TOTPEnabledAt != nil
+}
+
+// PublicUser is the part of a user that may be shown to anyone. It never
+// contains contact details or account state.
+type PublicUser struct {
+	ID          uuid.UUID `json:"id"`
+	DisplayName string    `json:"displayName"`
+	AvatarURL   string    `json:"avatarUrl"`
+	Bio         string    `json:"bio"`
+	Role        string    `json:"role"`
+	Points      int       `json:"points"`
+	Level       int       `json:"level"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// Public returns the public projection of u.
+func (u User) Public() PublicUser {
+	return PublicUser{
+		ID:          u.ID,
+		DisplayName: u.DisplayName,
+		AvatarURL:   u.AvatarURL,
+		Bio:         u.Bio,
+		Role:        u.Role,
+		Points:      u.Points,
+		Level:       u.Level,
+		CreatedAt:   u.CreatedAt,
+	}
+}
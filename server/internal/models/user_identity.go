@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserIdentity links a local user to an account at an external identity provider.
+type UserIdentity struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID      uuid.UUID `gorm:"type:uuid;index" json:"userId"`
+	Provider    string    `gorm:"size:64;uniqueIndex:idx_identity_provider_subject" json:"provider"`
+	Subject     string    `gorm:"size:255;uniqueIndex:idx_identity_provider_subject" json:"subject"`
+	Email       string    `gorm:"size:255" json:"email"`
+	Provisioned bool      `gorm:"not null;default:false" json:"provisioned"` // the identity created the account
+	LastLoginAt time.Time `json:"lastLoginAt"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+func (i *UserIdentity) BeforeCreate(_ *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+// OAuthState holds the per-login secrets of an authorization code flow until
+// the IdP redirects back. Stored in the database so any replica can finish it.
+type OAuthState struct {
+	State        string    `gorm:"size:64;primaryKey" json:"-"`
+	Provider     string    `gorm:"size:64" json:"-"`
+	Nonce        string    `gorm:"size:64" json:"-"`
+	CodeVerifier string    `gorm:"size:128" json:"-"`
+	ExpiresAt    time.Time `gorm:"index" json:"-"`
+	CreatedAt    time.Time `json:"-"`
+}
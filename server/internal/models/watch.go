@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Watch subscribes a user to changes of one resource: new versions, metadata
+// edits, unpublishing and broken links.
+type Watch struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID     uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_watches_user_resource" json:"userId"`
+	ResourceID uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_watches_user_resource;index" json:"resourceId"`
+	Source     string    `gorm:"size:16" json:"source"` // manual, favorite
+	CreatedAt  time.Time `json:"createdAt"`
+	Resource   *Resource `json:"resource,omitempty"`
+}
+
+func (w *Watch) BeforeCreate(_ *gorm.DB) error {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	return nil
+}
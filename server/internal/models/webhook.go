@@ -0,0 +1,63 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Webhook is an admin-configured endpoint that receives signed event payloads.
+type Webhook struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	Name        string    `gorm:"size:100" json:"name"`
+	URL         string    `gorm:"size:1000" json:"url"`
+	Secret      string    `gorm:"size:64" json:"-"`  // HMAC key, only shown on creation
+	Events      string    `gorm:"size:255" json:"-"` // comma separated event types
+	Active      bool      `gorm:"index" json:"active"`
+	CreatedByID uuid.UUID `gorm:"type:uuid" json:"createdById"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+func (w *Webhook) BeforeCreate(_ *gorm.DB) error {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	return nil
+}
+
+// EventList returns the subscribed event types.
+func (w Webhook) EventList() []string {
+	if w.Events == "" {
+		return nil
+	}
+	return strings.Split(w.Events, ",")
+}
+
+// WebhookDelivery is one attempt series to deliver an event to a webhook. It
+// doubles as the delivery log.
+type WebhookDelivery struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	WebhookID     uuid.UUID  `gorm:"type:uuid;index" json:"webhookId"`
+	Event         string     `gorm:"size:50" json:"event"`
+	Payload       string     `gorm:"type:text" json:"payload"`
+	Status        string     `gorm:"size:16;index:idx_webhook_deliveries_due" json:"status"` // pending, delivered, failed
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt *time.Time `gorm:"index:idx_webhook_deliveries_due" json:"nextAttemptAt"`
+	LastAttemptAt *time.Time `json:"lastAttemptAt"`
+	ResponseCode  int        `json:"responseCode"`
+	ResponseBody  string     `gorm:"size:1000" json:"responseBody"` // truncated
+	Error         string     `gorm:"size:500" json:"error"`
+	DeliveredAt   *time.Time `json:"deliveredAt"`
+	RedeliveryOf  *uuid.UUID `gorm:"type:uuid" json:"redeliveryOf"`
+	CreatedAt     time.Time  `gorm:"index" json:"createdAt"`
+}
+
+func (d *WebhookDelivery) BeforeCreate(_ *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
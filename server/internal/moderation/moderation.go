@@ -0,0 +1,213 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/events"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/A-Words/ne-resource-community/server/internal/realtime"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Item kinds.
+const (
+	KindResource = "resource"
+	KindReport   = "report"
+)
+
+// Default priorities. Reports concern content that is already public, so they
+// jump ahead of new uploads.
+const (
+	PriorityResource = 0
+	PriorityReport   = 10
+	escalationBoost  = 100
+)
+
+var (
+	ErrNotFound       = errors.New("moderation item not found")
+	ErrClaimedByOther = errors.New("item is claimed by another reviewer")
+)
+
+// Enqueue adds an open item for the target unless one already exists.
+func Enqueue(tx *gorm.DB, kind string, targetID uuid.UUID, priority int) error {
+	var count int64
+	if err := tx.Model(&models.ModerationItem{}).
+		Where("kind = ? AND target_id = ? AND status = ?", kind, targetID, "open").
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	item := models.ModerationItem{Kind: kind, TargetID: targetID, Priority: priority, Status: "open"}
+	if err := tx.Create(&item).Error; err != nil {
+		return err
+	}
+	return changed(tx, "enqueued", item.ID, kind, targetID)
+}
+
+// QueueChange is streamed to staff whenever the queue changes so open
+// dashboards can refresh.
+type QueueChange struct {
+	Action   string    `json:"action"` // enqueued, completed, claimed, released, escalated
+	ItemID   uuid.UUID `json:"itemId,omitempty"`
+	Kind     string    `json:"kind,omitempty"`
+	TargetID uuid.UUID `json:"targetId,omitempty"`
+}
+
+func changed(db *gorm.DB, action string, itemID uuid.UUID, kind string, targetID uuid.UUID) error {
+	return realtime.ToStaff(db, "moderation", QueueChange{Action: action, ItemID: itemID, Kind: kind, TargetID: targetID})
+}
+
+// Complete closes the open item for the target. It fails with
+// ErrClaimedByOther if another reviewer holds an unexpired claim.
+func Complete(tx *gorm.DB, kind string, targetID, reviewerID uuid.UUID, outcome string) error {
+	var item models.ModerationItem
+	err := tx.Where("kind = ? AND target_id = ? AND status = ?", kind, targetID, "open").First(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil // target predates the queue or was handled already
+	}
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if claimedByOther(item, reviewerID, now) {
+		return ErrClaimedByOther
+	}
+	if err := tx.Model(&item).Updates(map[string]interface{}{
+		"status":         "done",
+		"resolved_by_id": reviewerID,
+		"resolved_at":    now,
+		"outcome":        outcome,
+	}).Error; err != nil {
+		return err
+	}
+	return changed(tx, "completed", item.ID, kind, targetID)
+}
+
+// CompleteMany closes the open items for targetIDs regardless of claims. It is
+// meant for items settled as a side effect of another decision.
+func CompleteMany(tx *gorm.DB, kind string, targetIDs []uuid.UUID, reviewerID uuid.UUID, outcome string) error {
+	if len(targetIDs) == 0 {
+		return nil
+	}
+	res := tx.Model(&models.ModerationItem{}).
+		Where("kind = ? AND target_id IN ? AND status = ?", kind, targetIDs, "open").
+		Updates(map[string]interface{}{
+			"status":         "done",
+			"resolved_by_id": reviewerID,
+			"resolved_at":    time.Now(),
+			"outcome":        outcome,
+		})
+	if res.Error != nil || res.RowsAffected == 0 {
+		return res.Error
+	}
+	return changed(tx, "completed", uuid.Nil, kind, uuid.Nil)
+}
+
+// ReportPriority ranks reports by how much harm the content can do while it
+// stays online.
+func ReportPriority(category string) int {
+	switch category {
+	case models.ReportMalware, models.ReportLeakedCredentials:
+		return PriorityReport + 40
+	case models.ReportCopyright:
+		return PriorityReport + 10
+	default:
+		return PriorityReport
+	}
+}
+
+// Claim locks an open item for reviewerID until ttl elapses. Reviewers can
+// renew their own claim and take over expired ones.
+func Claim(db *gorm.DB, itemID, reviewerID uuid.UUID, ttl time.Duration) (models.ModerationItem, error) {
+	now := time.Now()
+	res := db.Model(&models.ModerationItem{}).
+		Where("id = ? AND status = ?", itemID, "open").
+		Where("claimed_by_id IS NULL OR claimed_by_id = ? OR claim_expires_at < ?", reviewerID, now).
+		Updates(map[string]interface{}{
+			"claimed_by_id":    reviewerID,
+			"claimed_at":       now,
+			"claim_expires_at": now.Add(ttl),
+		})
+	if res.Error != nil {
+		return models.ModerationItem{}, res.Error
+	}
+
+	var item models.ModerationItem
+	if err := db.First(&item, "id = ?", itemID).Error; err != nil {
+		return item, ErrNotFound
+	}
+	if res.RowsAffected == 0 {
+		if item.Status != "open" {
+			return item, ErrNotFound
+		}
+		return item, ErrClaimedByOther
+	}
+	return item, changed(db, "claimed", item.ID, item.Kind, item.TargetID)
+}
+
+// Release drops reviewerID's claim on an item.
+func Release(db *gorm.DB, itemID, reviewerID uuid.UUID) error {
+	res := db.Model(&models.ModerationItem{}).
+		Where("id = ? AND claimed_by_id = ?", itemID, reviewerID).
+		Updates(map[string]interface{}{
+			"claimed_by_id":    nil,
+			"claimed_at":       nil,
+			"claim_expires_at": nil,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return changed(db, "released", itemID, "", uuid.Nil)
+}
+
+// Escalator returns a job that flags items waiting longer than sla, bumps
+// their priority and notifies staff.
+func Escalator(db *gorm.DB, sla time.Duration) func(context.Context) error {
+	return func(ctx context.Context) error {
+		now := time.Now()
+		var items []models.ModerationItem
+		err := db.WithContext(ctx).Model(&items).
+			Clauses(clause.Returning{}).
+			Where("status = ? AND escalated = ? AND created_at < ?", "open", false, now.Add(-sla)).
+			Updates(map[string]interface{}{
+				"escalated":    true,
+				"escalated_at": now,
+				"priority":     gorm.Expr("priority + ?", escalationBoost),
+			}).Error
+		if err != nil {
+			return fmt.Errorf("escalate: %w", err)
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		if err := changed(db.WithContext(ctx), "escalated", uuid.Nil, "", uuid.Nil); err != nil {
+			return err
+		}
+
+		var staff []uuid.UUID
+		if err := db.WithContext(ctx).Model(&models.User{}).Where("role IN ?", []string{models.RoleAdmin, models.RoleModerator}).Pluck("id", &staff).Error; err != nil {
+			return fmt.Errorf("load staff: %w", err)
+		}
+		return events.Publish(db.WithContext(ctx), events.Event{
+			Type:       events.TypeModerationEscalated,
+			Recipients: staff,
+			Title:      "Moderation SLA exceeded",
+			Body:       fmt.Sprintf("%d moderation item(s) have been waiting longer than %s.", len(items), sla),
+		})
+	}
+}
+
+func claimedByOther(item models.ModerationItem, reviewerID uuid.UUID, now time.Time) bool {
+	return item.ClaimedByID != nil && *item.ClaimedByID != reviewerID &&
+		item.ClaimExpiresAt != nil && item.ClaimExpiresAt.After(now)
+}
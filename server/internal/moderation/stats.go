@@ -0,0 +1,83 @@
+package moderation
+
+import (
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ReviewerStats summarizes one reviewer's work since a point in time.
+type ReviewerStats struct {
+	ReviewerID           uuid.UUID `json:"reviewerId"`
+	DisplayName          string    `json:"displayName"`
+	Resolved             int64     `json:"resolved"`
+	AvgTurnaroundSeconds float64   `json:"avgTurnaroundSeconds"` // enqueue to resolution
+	ActiveClaims         int64     `json:"activeClaims"`
+}
+
+// QueueStats describes the current queue and recent throughput.
+type QueueStats struct {
+	Open                 int64           `json:"open"`
+	Claimed              int64           `json:"claimed"`
+	Escalated            int64           `json:"escalated"`
+	OldestOpenAt         *time.Time      `json:"oldestOpenAt"`
+	Resolved             int64           `json:"resolved"`
+	AvgTurnaroundSeconds float64         `json:"avgTurnaroundSeconds"`
+	Reviewers            []ReviewerStats `json:"reviewers"`
+}
+
+// Stats computes queue metrics; turnaround figures cover items resolved after since.
+func Stats(db *gorm.DB, since time.Time) (QueueStats, error) {
+	var s QueueStats
+	now := time.Now()
+	open := db.Model(&models.ModerationItem{}).Where("status = ?", "open")
+
+	if err := open.Session(&gorm.Session{}).Count(&s.Open).Error; err != nil {
+		return s, err
+	}
+	if err := open.Session(&gorm.Session{}).Where("claimed_by_id IS NOT NULL AND claim_expires_at > ?", now).Count(&s.Claimed).Error; err != nil {
+		return s, err
+	}
+	if err := open.Session(&gorm.Session{}).Where("escalated = ?", true).Count(&s.Escalated).Error; err != nil {
+		return s, err
+	}
+	var oldest models.ModerationItem
+	if err := open.Session(&gorm.Session{}).Order("created_at ASC").Limit(1).Find(&oldest).Error; err != nil {
+		return s, err
+	}
+	if oldest.ID != uuid.Nil {
+		s.OldestOpenAt = &oldest.CreatedAt
+	}
+
+	var overall struct {
+		Resolved int64
+		Avg      float64
+	}
+	err := db.Model(&models.ModerationItem{}).
+		Select("COUNT(*) AS resolved, COALESCE(AVG(EXTRACT(EPOCH FROM resolved_at - created_at)), 0) AS avg").
+		Where("status = ? AND resolved_at >= ?", "done", since).
+		Scan(&overall).Error
+	if err != nil {
+		return s, err
+	}
+	s.Resolved = overall.Resolved
+	s.AvgTurnaroundSeconds = overall.Avg
+
+	err = db.Raw(`
+		SELECT u.id AS reviewer_id, u.display_name,
+			COUNT(*) FILTER (WHERE m.resolved_by_id = u.id AND m.resolved_at >= ?) AS resolved,
+			COALESCE(AVG(EXTRACT(EPOCH FROM m.resolved_at - m.created_at))
+				FILTER (WHERE m.resolved_by_id = u.id AND m.resolved_at >= ?), 0) AS avg_turnaround_seconds,
+			COUNT(*) FILTER (WHERE m.status = 'open' AND m.claimed_by_id = u.id AND m.claim_expires_at > ?) AS active_claims
+		FROM users u
+		JOIN moderation_items m ON m.resolved_by_id = u.id OR m.claimed_by_id = u.id
+		GROUP BY u.id, u.display_name
+		ORDER BY resolved DESC
+	`, since, since, now).Scan(&s.Reviewers).Error
+	if s.Reviewers == nil {
+		s.Reviewers = []ReviewerStats{}
+	}
+	return s, err
+}
@@ -0,0 +1,59 @@
+package notify
+
+import (
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/A-Words/ne-resource-community/server/internal/realtime"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Send stores an in-app notification for userID about the referenced object
+// and pushes it to the user's open streams. Domain code publishes events
+// instead of calling Send directly.
+func Send(db *gorm.DB, userID uuid.UUID, kind, title, body, refType string, refID uuid.UUID) error {
+	n := models.Notification{
+		UserID:  userID,
+		Type:    kind,
+		Title:   title,
+		Body:    body,
+		RefType: refType,
+	}
+	if refID != uuid.Nil {
+		n.RefID = &refID
+	}
+	if err := db.Create(&n).Error; err != nil {
+		return err
+	}
+	return realtime.ToUser(db, userID, "notification", n)
+}
+
+// Muted reports whether userID turned off notifications of kind.
+func Muted(db *gorm.DB, userID uuid.UUID, kind string) (bool, error) {
+	var count int64
+	err := db.Model(&models.NotificationPreference{}).
+		Where("user_id = ? AND type = ? AND enabled = ?", userID, kind, false).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Preferences returns the explicit choices of userID by type.
+func Preferences(db *gorm.DB, userID uuid.UUID) (map[string]bool, error) {
+	var prefs []models.NotificationPreference
+	if err := db.Where("user_id = ?", userID).Find(&prefs).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[string]bool, len(prefs))
+	for _, p := range prefs {
+		out[p.Type] = p.Enabled
+	}
+	return out, nil
+}
+
+// SetPreference records whether userID wants notifications of kind.
+func SetPreference(db *gorm.DB, userID uuid.UUID, kind string, enabled bool) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(&models.NotificationPreference{UserID: userID, Type: kind, Enabled: enabled}).Error
+}
@@ -0,0 +1,314 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrInvalidIDToken = errors.New("invalid id token")
+
+// Identity is the verified content of an ID token.
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Groups        []string
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider is an OpenID Connect identity provider using the authorization
+// code flow with PKCE. Discovery and keys are fetched lazily and cached.
+type Provider struct {
+	cfg    config.OIDCProvider
+	client *http.Client
+
+	mu         sync.Mutex
+	meta       *discovery
+	keys       map[string]interface{}
+	keysAt     time.Time     // last JWKS fetch attempt
+	refreshing chan struct{} // closed when the running JWKS fetch ends
+}
+
+// jwksMinRefresh limits how often an unknown key id can trigger a JWKS
+// fetch, so forged tokens cannot make us hammer the IdP.
+const jwksMinRefresh = time.Minute
+
+func NewProvider(cfg config.OIDCProvider, client *http.Client) *Provider {
+	if client == nil {
+		client = &http.Client{Timeout: 15 * time.Second}
+	}
+	return &Provider{cfg: cfg, client: client}
+}
+
+// Config returns the provider configuration.
+func (p *Provider) Config() config.OIDCProvider {
+	return p.cfg
+}
+
+// AuthCodeURL returns the IdP URL the browser is sent to.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.cfg.RedirectURL},
+		"scope":                 {strings.Join(p.scopes(), " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {challenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// Exchange redeems an authorization code and verifies the returned ID token
+// against the provider keys, client id and expected nonce.
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (Identity, error) {
+	meta, err := p.discover(ctx)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"client_id":     {p.cfg.ClientID},
+		"code_verifier": {verifier},
+	}
+	if p.cfg.ClientSecret != "" {
+		form.Set("client_secret", p.cfg.ClientSecret)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Identity{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Identity{}, fmt.Errorf("token request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var tok struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return Identity{}, fmt.Errorf("decode token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || tok.IDToken == "" {
+		return Identity{}, fmt.Errorf("token endpoint: %s %s", tok.Error, tok.ErrorDescription)
+	}
+	return p.verify(ctx, meta, tok.IDToken, nonce)
+}
+
+func (p *Provider) verify(ctx context.Context, meta *discovery, raw, nonce string) (Identity, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.key(ctx, meta, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384"}),
+		jwt.WithIssuer(meta.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return Identity{}, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	id := Identity{}
+	id.Subject, _ = claims["sub"].(string)
+	id.Email, _ = claims["email"].(string)
+	id.Name, _ = claims["name"].(string)
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		id.EmailVerified = v
+	case string: // some IdPs send "true"
+		id.EmailVerified = v == "true"
+	}
+	if groups, ok := claims[p.groupsClaim()].([]interface{}); ok {
+		for _, g := range groups {
+			if s, ok := g.(string); ok {
+				id.Groups = append(id.Groups, s)
+			}
+		}
+	}
+	if id.Subject == "" {
+		return Identity{}, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	}
+	return id, nil
+}
+
+// key returns the verification key for kid, refreshing the key set when the
+// IdP has rotated keys. Fetches run outside the lock, at most once per
+// jwksMinRefresh, and concurrent callers wait for the running one.
+func (p *Provider) key(ctx context.Context, meta *discovery, kid string) (interface{}, error) {
+	p.mu.Lock()
+	if k, ok := p.lookup(kid); ok {
+		p.mu.Unlock()
+		return k, nil
+	}
+	if wait := p.refreshing; wait != nil {
+		p.mu.Unlock()
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		p.mu.Lock()
+		k, ok := p.lookup(kid)
+		p.mu.Unlock()
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		return k, nil
+	}
+	if !p.keysAt.IsZero() && time.Since(p.keysAt) < jwksMinRefresh {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	done := make(chan struct{})
+	p.refreshing = done
+	p.mu.Unlock()
+
+	keys, err := fetchJWKS(ctx, p.client, meta.JWKSURI)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.refreshing = nil
+	p.keysAt = time.Now()
+	close(done)
+	if err != nil {
+		return nil, err
+	}
+	p.keys = keys
+	if k, ok := p.lookup(kid); ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (p *Provider) lookup(kid string) (interface{}, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, k := range p.keys {
+			return k, true
+		}
+	}
+	k, ok := p.keys[kid]
+	return k, ok
+}
+
+// discover returns the cached provider metadata. The fetch runs outside the
+// lock; concurrent first calls may fetch twice, which is harmless.
+func (p *Provider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	cached := p.meta
+	p.mu.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+	var meta discovery
+	uri := strings.TrimSuffix(p.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := getJSON(ctx, p.client, uri, &meta); err != nil {
+		return nil, fmt.Errorf("oidc discovery for %s: %w", p.cfg.Name, err)
+	}
+	if meta.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("oidc discovery for %s: issuer mismatch %q", p.cfg.Name, meta.Issuer)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.meta == nil {
+		p.meta = &meta
+	}
+	return p.meta, nil
+}
+
+func (p *Provider) scopes() []string {
+	if len(p.cfg.Scopes) > 0 {
+		return p.cfg.Scopes
+	}
+	return []string{"openid", "email", "profile"}
+}
+
+func (p *Provider) groupsClaim() string {
+	if p.cfg.GroupsClaim != "" {
+		return p.cfg.GroupsClaim
+	}
+	return "groups"
+}
+
+// RandomString returns a URL-safe random string for state, nonce and PKCE verifiers.
+func RandomString() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// MapRole derives the local role from IdP groups. ok is false when the
+// provider has no group mapping, in which case local roles are left alone.
+func (p *Provider) MapRole(groups []string) (role string, ok bool) {
+	if len(p.cfg.AdminGroups) == 0 && len(p.cfg.ModeratorGroups) == 0 {
+		return "", false
+	}
+	member := func(wanted []string) bool {
+		for _, w := range wanted {
+			for _, g := range groups {
+				if strings.EqualFold(w, g) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	switch {
+	case member(p.cfg.AdminGroups):
+		return models.RoleAdmin, true
+	case member(p.cfg.ModeratorGroups):
+		return models.RoleModerator, true
+	default:
+		return models.RoleUser, true
+	}
+}
@@ -0,0 +1,254 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockIdP serves discovery, JWKS and a token endpoint that answers with
+// whatever ID token the test put in idToken.
+type mockIdP struct {
+	srv       *httptest.Server
+	key       *rsa.PrivateKey
+	kid       string
+	idToken   string
+	jwksHits  atomic.Int32
+	lastForm  url.Values
+	tokenCode int
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockIdP{key: key, kid: "k1", tokenCode: http.StatusOK}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(discovery{
+			Issuer:                m.srv.URL,
+			AuthorizationEndpoint: m.srv.URL + "/authorize",
+			TokenEndpoint:         m.srv.URL + "/token",
+			JWKSURI:               m.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		m.jwksHits.Add(1)
+		pub := m.key.PublicKey
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []jwk{{
+			Kid: m.kid,
+			Kty: "RSA",
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		m.lastForm = r.PostForm
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(m.tokenCode)
+		if m.tokenCode != http.StatusOK {
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": m.idToken})
+	})
+	m.srv = httptest.NewServer(mux)
+	t.Cleanup(m.srv.Close)
+	return m
+}
+
+func (m *mockIdP) sign(t *testing.T, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	tok.Header["kid"] = kid
+	raw, err := tok.SignedString(m.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func (m *mockIdP) claims(nonce string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            m.srv.URL,
+		"aud":            "client",
+		"sub":            "user-1",
+		"email":          "alice@example.com",
+		"email_verified": true,
+		"name":           "Alice",
+		"groups":         []string{"staff", "ne-admins"},
+		"nonce":          nonce,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+	}
+}
+
+func (m *mockIdP) provider() *Provider {
+	return NewProvider(config.OIDCProvider{
+		Name:         "mock",
+		Issuer:       m.srv.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "https://app.example.com/callback",
+		AdminGroups:  []string{"ne-admins"},
+	}, m.srv.Client())
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	m := newMockIdP(t)
+	raw, err := m.provider().AuthCodeURL(context.Background(), "st", "no", "verifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if !strings.HasPrefix(raw, m.srv.URL+"/authorize?") {
+		t.Errorf("endpoint = %s", raw)
+	}
+	for k, want := range map[string]string{
+		"client_id":             "client",
+		"state":                 "st",
+		"nonce":                 "no",
+		"code_challenge":        challenge("verifier"),
+		"code_challenge_method": "S256",
+		"scope":                 "openid email profile",
+	} {
+		if got := q.Get(k); got != want {
+			t.Errorf("%s = %q, want %q", k, got, want)
+		}
+	}
+}
+
+func TestExchange(t *testing.T) {
+	m := newMockIdP(t)
+	p := m.provider()
+	ctx := context.Background()
+
+	cases := []struct {
+		name    string
+		kid     string
+		mutate  func(jwt.MapClaims)
+		wantErr bool
+	}{
+		{name: "valid", kid: "k1"},
+		{name: "nonce mismatch", kid: "k1", mutate: func(c jwt.MapClaims) { c["nonce"] = "other" }, wantErr: true},
+		{name: "wrong audience", kid: "k1", mutate: func(c jwt.MapClaims) { c["aud"] = "someone-else" }, wantErr: true},
+		{name: "wrong issuer", kid: "k1", mutate: func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }, wantErr: true},
+		{name: "expired", kid: "k1", mutate: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }, wantErr: true},
+		{name: "missing subject", kid: "k1", mutate: func(c jwt.MapClaims) { delete(c, "sub") }, wantErr: true},
+		{name: "unknown key", kid: "rotated", wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			claims := m.claims("nonce")
+			if tc.mutate != nil {
+				tc.mutate(claims)
+			}
+			m.idToken = m.sign(t, tc.kid, claims)
+			id, err := p.Exchange(ctx, "code", "verifier", "nonce")
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("Exchange succeeded")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Exchange: %v", err)
+			}
+			if id.Subject != "user-1" || id.Email != "alice@example.com" || !id.EmailVerified || id.Name != "Alice" {
+				t.Errorf("identity = %+v", id)
+			}
+			if len(id.Groups) != 2 {
+				t.Errorf("groups = %v", id.Groups)
+			}
+			if m.lastForm.Get("code_verifier") != "verifier" || m.lastForm.Get("client_secret") != "secret" {
+				t.Errorf("token request = %v", m.lastForm)
+			}
+		})
+	}
+}
+
+func TestExchangeTokenEndpointError(t *testing.T) {
+	m := newMockIdP(t)
+	m.tokenCode = http.StatusBadRequest
+	if _, err := m.provider().Exchange(context.Background(), "code", "verifier", "nonce"); err == nil {
+		t.Fatal("Exchange succeeded")
+	}
+}
+
+func TestUnknownKeyRefetchIsRateLimited(t *testing.T) {
+	m := newMockIdP(t)
+	p := m.provider()
+	ctx := context.Background()
+
+	m.idToken = m.sign(t, "k1", m.claims("n"))
+	if _, err := p.Exchange(ctx, "code", "v", "n"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		m.idToken = m.sign(t, "forged", m.claims("n"))
+		if _, err := p.Exchange(ctx, "code", "v", "n"); err == nil {
+			t.Fatal("token with unknown key accepted")
+		}
+	}
+	if hits := m.jwksHits.Load(); hits != 1 {
+		t.Errorf("JWKS fetched %d times, want 1", hits)
+	}
+
+	// Once the interval has passed, a rotated key is picked up.
+	p.mu.Lock()
+	p.keysAt = time.Now().Add(-jwksMinRefresh)
+	p.mu.Unlock()
+	m.kid = "k2"
+	m.idToken = m.sign(t, "k2", m.claims("n"))
+	if _, err := p.Exchange(ctx, "code", "v", "n"); err != nil {
+		t.Fatalf("rotated key: %v", err)
+	}
+	if hits := m.jwksHits.Load(); hits != 2 {
+		t.Errorf("JWKS fetched %d times, want 2", hits)
+	}
+}
+
+func TestMapRole(t *testing.T) {
+	p := NewProvider(config.OIDCProvider{AdminGroups: []string{"ne-admins"}, ModeratorGroups: []string{"ne-reviewers"}}, nil)
+	cases := []struct {
+		groups []string
+		want   string
+	}{
+		{[]string{"NE-Admins"}, models.RoleAdmin},
+		{[]string{"ne-reviewers", "ne-admins"}, models.RoleAdmin},
+		{[]string{"ne-reviewers"}, models.RoleModerator},
+		{[]string{"other"}, models.RoleUser},
+		{nil, models.RoleUser},
+	}
+	for _, tc := range cases {
+		if got, ok := p.MapRole(tc.groups); !ok || got != tc.want {
+			t.Errorf("MapRole(%v) = %q, %v; want %q", tc.groups, got, ok, tc.want)
+		}
+	}
+	if _, ok := NewProvider(config.OIDCProvider{}, nil).MapRole([]string{"ne-admins"}); ok {
+		t.Error("MapRole without group mapping reported ok")
+	}
+}
@@ -0,0 +1,74 @@
+package onetime
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Token purposes.
+const (
+	PurposeVerifyEmail   = "verify_email"
+	PurposeResetPassword = "reset_password"
+)
+
+// ErrInvalid covers unknown, expired and already used tokens alike so callers
+// do not leak which one it was.
+var ErrInvalid = errors.New("invalid or expired token")
+
+// Issue creates a token for userID and purpose, voiding older unused tokens
+// of the same purpose. The raw token is returned once and never stored.
+func Issue(db *gorm.DB, userID uuid.UUID, purpose string, ttl time.Duration) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	raw := base64.RawURLEncoding.EncodeToString(buf)
+	now := time.Now()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.UserToken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+			Update("used_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.UserToken{
+			UserID:    userID,
+			Purpose:   purpose,
+			TokenHash: hash(raw),
+			ExpiresAt: now.Add(ttl),
+		}).Error
+	})
+	return raw, err
+}
+
+// Consume marks the token as used and returns it. It fails with ErrInvalid
+// unless the token exists, matches purpose, is unexpired and unused.
+func Consume(tx *gorm.DB, raw, purpose string) (models.UserToken, error) {
+	var tokens []models.UserToken
+	now := time.Now()
+	err := tx.Model(&tokens).
+		Clauses(clause.Returning{}).
+		Where("token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", hash(raw), purpose, now).
+		Update("used_at", now).Error
+	if err != nil {
+		return models.UserToken{}, err
+	}
+	if len(tokens) == 0 {
+		return models.UserToken{}, ErrInvalid
+	}
+	return tokens[0], nil
+}
+
+func hash(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,136 @@
+package points
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Ledger reasons.
+const (
+	ReasonOpeningBalance = "opening_balance" // balance carried over from before the ledger
+	ReasonUploadApproved = "upload_approved"
+	ReasonReview         = "review"
+	ReasonBountyEscrow   = "bounty_escrow"
+	ReasonBountyAward    = "bounty_award"
+	ReasonBountyRefund   = "bounty_refund"
+	ReasonAccountMerge   = "account_merge"
+)
+
+// Rewards.
+const (
+	UploadApproved = 10
+	Review         = 2
+)
+
+var ErrInsufficient = errors.New("insufficient points")
+
+// Entry describes a point change.
+type Entry struct {
+	UserID  uuid.UUID
+	Delta   int
+	Reason  string
+	RefType string
+	RefID   uuid.UUID
+	Note    string
+}
+
+// Apply records e and updates the cached balance. It must run inside the
+// transaction of the action that causes the change. Debits that would make
+// the balance negative fail with ErrInsufficient.
+func Apply(tx *gorm.DB, e Entry) (models.PointEntry, error) {
+	current, err := lockBalance(tx, e.UserID)
+	if err != nil {
+		return models.PointEntry{}, err
+	}
+	return apply(tx, e, current)
+}
+
+// ApplyOnce is Apply unless the user already has an entry with the same
+// reason and reference, so repeated events such as re-approvals pay once.
+// The check runs under the balance lock, so concurrent calls award once.
+func ApplyOnce(tx *gorm.DB, e Entry) error {
+	current, err := lockBalance(tx, e.UserID)
+	if err != nil {
+		return err
+	}
+	var count int64
+	if err := tx.Model(&models.PointEntry{}).
+		Where("user_id = ? AND reason = ? AND ref_type = ? AND ref_id = ?", e.UserID, e.Reason, e.RefType, e.RefID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	_, err = apply(tx, e, current)
+	return err
+}
+
+// lockBalance locks the user row until the transaction ends and returns the
+// cached balance.
+func lockBalance(tx *gorm.DB, userID uuid.UUID) (int, error) {
+	var user models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "points").First(&user, "id = ?", userID).Error; err != nil {
+		return 0, fmt.Errorf("load balance: %w", err)
+	}
+	return user.Points, nil
+}
+
+func apply(tx *gorm.DB, e Entry, current int) (models.PointEntry, error) {
+	balance := current + e.Delta
+	if e.Delta < 0 && balance < 0 {
+		return models.PointEntry{}, ErrInsufficient
+	}
+	if err := tx.Model(&models.User{}).Where("id = ?", e.UserID).UpdateColumn("points", balance).Error; err != nil {
+		return models.PointEntry{}, err
+	}
+	entry := models.PointEntry{
+		UserID:  e.UserID,
+		Delta:   e.Delta,
+		Balance: balance,
+		Reason:  e.Reason,
+		RefType: e.RefType,
+		RefID:   e.RefID,
+		Note:    e.Note,
+	}
+	return entry, tx.Create(&entry).Error
+}
+
+// Mismatch is a user whose cached balance disagrees with the ledger.
+type Mismatch struct {
+	UserID uuid.UUID `json:"userId"`
+	Cached int       `json:"cached"`
+	Ledger int       `json:"ledger"`
+}
+
+// Check lists users whose cached balance differs from their ledger sum.
+func Check(db *gorm.DB) ([]Mismatch, error) {
+	var out []Mismatch
+	err := db.Raw(`
+SELECT u.id AS user_id, u.points AS cached, COALESCE(SUM(p.delta), 0) AS ledger
+FROM users u
+LEFT JOIN point_entries p ON p.user_id = u.id
+GROUP BY u.id, u.points
+HAVING u.points <> COALESCE(SUM(p.delta), 0)`).Scan(&out).Error
+	return out, err
+}
+
+// Reconcile resets the cached balance of every mismatched user to the ledger
+// sum and returns what was fixed.
+func Reconcile(tx *gorm.DB) ([]Mismatch, error) {
+	mismatches, err := Check(tx)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range mismatches {
+		if err := tx.Model(&models.User{}).Where("id = ?", m.UserID).UpdateColumn("points", m.Ledger).Error; err != nil {
+			return nil, err
+		}
+	}
+	return mismatches, nil
+}
@@ -0,0 +1,71 @@
+package points
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/A-Words/ne-resource-community/server/internal/database/dbtest"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func newUser(t *testing.T, db *gorm.DB) models.User {
+	t.Helper()
+	user := models.User{Email: "points-" + uuid.NewString()[:8] + "@example.com", DisplayName: "Points", Role: models.RoleUser}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestApplyRejectsOverdraft(t *testing.T) {
+	db := dbtest.Tx(t)
+	user := newUser(t, db)
+	if _, err := Apply(db, Entry{UserID: user.ID, Delta: 5, Reason: ReasonUploadApproved}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Apply(db, Entry{UserID: user.ID, Delta: -6, Reason: ReasonBountyEscrow}); !errors.Is(err, ErrInsufficient) {
+		t.Fatalf("err = %v, want ErrInsufficient", err)
+	}
+	entry, err := Apply(db, Entry{UserID: user.ID, Delta: -5, Reason: ReasonBountyEscrow})
+	if err != nil || entry.Balance != 0 {
+		t.Fatalf("Apply = %+v, %v", entry, err)
+	}
+}
+
+func TestApplyOnceConcurrent(t *testing.T) {
+	db := dbtest.Open(t)
+	user := newUser(t, db)
+	t.Cleanup(func() {
+		db.Where("user_id = ?", user.ID).Delete(&models.PointEntry{})
+		db.Delete(&user)
+	})
+
+	e := Entry{UserID: user.ID, Delta: Review, Reason: ReasonReview, RefType: "resource", RefID: uuid.New()}
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- db.Transaction(func(tx *gorm.DB) error { return ApplyOnce(tx, e) })
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("ApplyOnce: %v", err)
+		}
+	}
+
+	var n int64
+	db.Model(&models.PointEntry{}).Where("user_id = ?", user.ID).Count(&n)
+	var after models.User
+	db.First(&after, "id = ?", user.ID)
+	if n != 1 || after.Points != Review {
+		t.Errorf("%d entries, balance %d; want 1 entry, balance %d", n, after.Points, Review)
+	}
+}
@@ -0,0 +1,70 @@
+package profile
+
+import (
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Contributions summarises what a user gave to the community.
+type Contributions struct {
+	ApprovedUploads   int64 `json:"approvedUploads"`
+	Reviews           int64 `json:"reviews"`
+	DownloadsReceived int64 `json:"downloadsReceived"` // downloads of the user's resources
+	Requests          int64 `json:"requests"`
+}
+
+// Badge is an achievement shown on profiles.
+type Badge struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+}
+
+// Load counts the public contributions of userID.
+func Load(db *gorm.DB, userID uuid.UUID) (Contributions, error) {
+	var c Contributions
+	err := db.Model(&models.Resource{}).
+		Select("COUNT(*) AS approved_uploads, COALESCE(SUM(download_count), 0) AS downloads_received").
+		Where("uploader_id = ? AND status = ?", userID, "approved").
+		Scan(&c).Error
+	if err != nil {
+		return c, err
+	}
+	if err := db.Model(&models.Review{}).Where("user_id = ?", userID).Count(&c.Reviews).Error; err != nil {
+		return c, err
+	}
+	err = db.Model(&models.Request{}).Where("user_id = ?", userID).Count(&c.Requests).Error
+	return c, err
+}
+
+// badgeRules are checked in display order.
+var badgeRules = []struct {
+	Badge
+	earned func(u models.User, c Contributions, now time.Time) bool
+}{
+	{Badge{"staff", "社区管理员 / Staff"}, func(u models.User, _ Contributions, _ time.Time) bool {
+		return u.Role == models.RoleAdmin || u.Role == models.RoleModerator
+	}},
+	{Badge{"first_upload", "首次贡献 / First upload"}, func(_ models.User, c Contributions, _ time.Time) bool { return c.ApprovedUploads >= 1 }},
+	{Badge{"contributor", "活跃贡献者 / Contributor"}, func(_ models.User, c Contributions, _ time.Time) bool { return c.ApprovedUploads >= 10 }},
+	{Badge{"prolific", "资深贡献者 / Prolific contributor"}, func(_ models.User, c Contributions, _ time.Time) bool { return c.ApprovedUploads >= 50 }},
+	{Badge{"reviewer", "热心评测 / Reviewer"}, func(_ models.User, c Contributions, _ time.Time) bool { return c.Reviews >= 10 }},
+	{Badge{"popular", "人气作者 / Popular"}, func(_ models.User, c Contributions, _ time.Time) bool { return c.DownloadsReceived >= 1000 }},
+	{Badge{"veteran", "老用户 / Veteran"}, func(u models.User, _ Contributions, now time.Time) bool {
+		return !u.CreatedAt.IsZero() && u.CreatedAt.AddDate(1, 0, 0).Before(now)
+	}},
+}
+
+// Badges returns the badges user has earned.
+func Badges(user models.User, c Contributions) []Badge {
+	now := time.Now()
+	badges := []Badge{}
+	for _, r := range badgeRules {
+		if r.earned(user, c, now) {
+			badges = append(badges, r.Badge)
+		}
+	}
+	return badges
+}
@@ -0,0 +1,61 @@
+package quota
+
+import (
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Window is the rolling period upload quotas apply to.
+const Window = 24 * time.Hour
+
+// Limits are the upload allowance of a user per Window. Zero means unlimited.
+type Limits struct {
+	Count int   `json:"count"`
+	Bytes int64 `json:"bytes"`
+}
+
+// Usage is what a user uploaded during the current window.
+type Usage struct {
+	Count int64 `json:"count"`
+	Bytes int64 `json:"bytes"`
+}
+
+// For returns the limits for user. They grow with the user's level; staff
+// are not limited.
+func For(cfg config.Config, user models.User) Limits {
+	if user.Role == models.RoleAdmin || user.Role == models.RoleModerator {
+		return Limits{}
+	}
+	extra := user.Level - 1
+	return Limits{
+		Count: cfg.UploadDailyCount + extra*cfg.UploadDailyCountPerLevel,
+		Bytes: cfg.UploadDailyBytes + int64(extra)*cfg.UploadDailyBytesPerLevel,
+	}
+}
+
+// Used sums the uploads of userID during the window ending now. Mirrors count
+// towards the volume when they happened.
+func Used(db *gorm.DB, userID uuid.UUID) (Usage, error) {
+	since := time.Now().Add(-Window)
+	var u Usage
+	err := db.Model(&models.Resource{}).
+		Select("COUNT(*) FILTER (WHERE created_at > ?) AS count, COALESCE(SUM(file_size), 0) AS bytes", since).
+		Where("uploader_id = ? AND (created_at > ? OR mirrored_at > ?)", userID, since, since).
+		Scan(&u).Error
+	return u, err
+}
+
+// Allows reports whether adding files uploads totalling size stays within limits.
+func (l Limits) Allows(u Usage, files int, size int64) bool {
+	if l.Count > 0 && u.Count+int64(files) > int64(l.Count) {
+		return false
+	}
+	if l.Bytes > 0 && u.Bytes+size > l.Bytes {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepEvery is how often idle buckets are dropped.
+const sweepEvery = time.Minute
+
+// Limiter keeps one token bucket per key. Buckets live in process memory, so
+// with several replicas each enforces its own share of the limit.
+type Limiter struct {
+	limit    int
+	per      time.Duration
+	interval time.Duration // time to regain one token
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Result describes the outcome of a request against a bucket.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until the next request is allowed, when denied
+}
+
+// New returns a limiter allowing bursts of limit requests, refilled evenly
+// over per. It returns nil when limit is not positive, which disables limiting.
+func New(limit int, per time.Duration) *Limiter {
+	if limit <= 0 || per <= 0 {
+		return nil
+	}
+	return &Limiter{
+		limit:    limit,
+		per:      per,
+		interval: per / time.Duration(limit),
+		buckets:  map[string]*bucket{},
+	}
+}
+
+// Allow takes a token from key's bucket if one is available.
+func (l *Limiter) Allow(key string) Result {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.limit), last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(l.limit), b.tokens+float64(now.Sub(b.last))/float64(l.interval))
+	b.last = now
+
+	res := Result{Limit: l.limit}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration((1 - b.tokens) * float64(l.interval))
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = time.Duration((float64(l.limit) - b.tokens) * float64(l.interval))
+	return res
+}
+
+// sweep drops buckets that have refilled completely; they are equivalent to
+// new ones.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepEvery {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= l.per {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+// channel is the PostgreSQL NOTIFY channel shared by all API replicas.
+const channel = "realtime"
+
+// maxPayload stays below the 8000 byte NOTIFY payload limit.
+const maxPayload = 7900
+
+// clientBuffer is how many messages a slow client may lag behind before
+// messages to it are dropped.
+const clientBuffer = 64
+
+// Message is one event streamed to connected clients.
+type Message struct {
+	Event  string          `json:"event"`            // SSE event name
+	UserID uuid.UUID       `json:"userId,omitempty"` // recipient; uuid.Nil when Staff is set
+	Staff  bool            `json:"staff,omitempty"`  // every connected admin and moderator
+	Data   json.RawMessage `json:"data"`
+}
+
+// ToUser queues an event for one user's streams. In a transaction it is
+// delivered on commit.
+func ToUser(db *gorm.DB, userID uuid.UUID, event string, data interface{}) error {
+	return publish(db, Message{Event: event, UserID: userID}, data)
+}
+
+// ToStaff queues an event for the streams of all admins and moderators.
+func ToStaff(db *gorm.DB, event string, data interface{}) error {
+	return publish(db, Message{Event: event, Staff: true}, data)
+}
+
+func publish(db *gorm.DB, m Message, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	m.Data = raw
+	payload, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if len(payload) > maxPayload {
+		// Clients refetch over the REST API when data is missing.
+		m.Data = json.RawMessage(`{"truncated":true}`)
+		if payload, err = json.Marshal(m); err != nil {
+			return err
+		}
+	}
+	return db.Exec("SELECT pg_notify(?, ?)", channel, string(payload)).Error
+}
+
+// Client is one open stream.
+type Client struct {
+	userID uuid.UUID
+	staff  bool
+	C      <-chan Message
+	send   chan Message
+}
+
+// Hub fans messages out to the streams connected to this replica.
+type Hub struct {
+	mu      sync.RWMutex
+	clients map[*Client]struct{}
+	closed  bool
+}
+
+func NewHub() *Hub {
+	return &Hub{clients: make(map[*Client]struct{})}
+}
+
+// Subscribe registers a stream for userID. staff streams also receive
+// moderation events.
+func (h *Hub) Subscribe(userID uuid.UUID, staff bool) *Client {
+	send := make(chan Message, clientBuffer)
+	c := &Client{userID: userID, staff: staff, C: send, send: send}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(send)
+		return c
+	}
+	h.clients[c] = struct{}{}
+	return c
+}
+
+func (h *Hub) Unsubscribe(c *Client) {
+	h.mu.Lock()
+	delete(h.clients, c)
+	h.mu.Unlock()
+}
+
+// Close ends every stream, e.g. on shutdown, so open connections do not hold
+// the server up. Client channels are closed.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for c := range h.clients {
+		close(c.send)
+		delete(h.clients, c)
+	}
+}
+
+// Dispatch delivers m to every matching stream without blocking.
+func (h *Hub) Dispatch(m Message) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for c := range h.clients {
+		if (m.Staff && c.staff) || (m.UserID != uuid.Nil && m.UserID == c.userID) {
+			select {
+			case c.send <- m:
+			default: // client is too slow; it resynchronises over REST
+			}
+		}
+	}
+}
+
+// Listen relays NOTIFY messages from PostgreSQL to hub until ctx is
+// cancelled, reconnecting with backoff when the connection drops.
+func Listen(ctx context.Context, dsn string, hub *Hub) {
+	const maxBackoff = 30 * time.Second
+	backoff := time.Second
+	for {
+		started := time.Now()
+		err := listen(ctx, dsn, hub)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(started) > time.Minute {
+			backoff = time.Second
+		}
+		log.Printf("realtime listener: %v; reconnecting in %s", err, backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+func listen(ctx context.Context, dsn string, hub *Hub) error {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return fmt.Errorf("connect: %w", err)
+	}
+	defer conn.Close(context.Background())
+	if _, err := conn.Exec(ctx, "LISTEN "+channel); err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var m Message
+		if err := json.Unmarshal([]byte(n.Payload), &m); err != nil {
+			log.Printf("realtime listener: bad payload: %v", err)
+			continue
+		}
+		hub.Dispatch(m)
+	}
+}
@@ -0,0 +1,84 @@
+// Package secretbox encrypts small secrets stored in the database and derives
+// per-purpose keys from configured master secrets.
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// prefix marks sealed values so plaintext written before encryption was
+// introduced can be told apart.
+const prefix = "enc:v1:"
+
+var ErrCorrupt = errors.New("sealed value is corrupt or was sealed with another key")
+
+// DeriveKey derives a 32-byte key for purpose from master with HKDF-SHA256.
+// Keys for different purposes are independent, so one leaking does not
+// expose the others or the master.
+func DeriveKey(master, purpose string) []byte {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, []byte(master), nil, []byte(purpose)), key); err != nil {
+		panic(err) // only fails when asking for more than 255 blocks
+	}
+	return key
+}
+
+// Box seals values with AES-256-GCM.
+type Box struct {
+	aead cipher.AEAD
+}
+
+// New returns a Box for a 32-byte key, typically from DeriveKey.
+func New(key []byte) (*Box, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Box{aead: aead}, nil
+}
+
+// Seal encrypts plaintext with a random nonce.
+func (b *Box) Seal(plaintext string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	out := b.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return prefix + base64.RawStdEncoding.EncodeToString(out), nil
+}
+
+// Open decrypts a value from Seal. Values without the sealed prefix are
+// returned unchanged; see IsSealed.
+func (b *Box) Open(value string) (string, error) {
+	if !IsSealed(value) {
+		return value, nil
+	}
+	raw, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(value, prefix))
+	if err != nil || len(raw) < b.aead.NonceSize() {
+		return "", ErrCorrupt
+	}
+	nonce, ciphertext := raw[:b.aead.NonceSize()], raw[b.aead.NonceSize():]
+	plain, err := b.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", ErrCorrupt
+	}
+	return string(plain), nil
+}
+
+// IsSealed reports whether value came from Seal.
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
@@ -0,0 +1,67 @@
+package secretbox
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDeriveKey(t *testing.T) {
+	a := DeriveKey("master", "totp-secret")
+	if len(a) != 32 {
+		t.Fatalf("len = %d", len(a))
+	}
+	if !bytes.Equal(a, DeriveKey("master", "totp-secret")) {
+		t.Error("DeriveKey is not deterministic")
+	}
+	if bytes.Equal(a, DeriveKey("master", "digest-unsubscribe")) {
+		t.Error("different purposes share a key")
+	}
+	if bytes.Equal(a, DeriveKey("other", "totp-secret")) {
+		t.Error("different masters share a key")
+	}
+}
+
+func TestSealOpen(t *testing.T) {
+	box, err := New(DeriveKey("master", "test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := box.Seal("JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsSealed(sealed) || strings.Contains(sealed, "JBSWY3DPEHPK3PXP") {
+		t.Fatalf("sealed = %q", sealed)
+	}
+	again, _ := box.Seal("JBSWY3DPEHPK3PXP")
+	if again == sealed {
+		t.Error("nonce reused")
+	}
+	if got, err := box.Open(sealed); err != nil || got != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("Open = %q, %v", got, err)
+	}
+
+	cases := map[string]string{
+		"legacy plaintext": "JBSWY3DPEHPK3PXP",
+		"empty":            "",
+	}
+	for name, v := range cases {
+		if got, err := box.Open(v); err != nil || got != v {
+			t.Errorf("%s: Open = %q, %v", name, got, err)
+		}
+	}
+
+	other, _ := New(DeriveKey("master", "other"))
+	if _, err := other.Open(sealed); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("wrong key: err = %v", err)
+	}
+	tampered := sealed[:len(sealed)-2] + "AA"
+	if _, err := box.Open(tampered); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("tampered: err = %v", err)
+	}
+	if _, err := box.Open(prefix + "!!"); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("bad base64: err = %v", err)
+	}
+}
@@ -0,0 +1,319 @@
+package session
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/config"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/A-Words/ne-resource-community/server/internal/textutil"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrRevoked      = errors.New("session revoked")
+	ErrReused       = errors.New("refresh token reuse detected")
+	ErrSuspended    = errors.New("account suspended")
+	ErrNotFound     = errors.New("session not found")
+)
+
+// Pair is returned to clients after login or refresh.
+type Pair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresIn    int    `json:"expiresIn"` // access token lifetime in seconds
+}
+
+// Claims are the verified contents of an access token.
+type Claims struct {
+	UserID    uuid.UUID
+	SessionID uuid.UUID
+	Role      string
+	Version   int
+}
+
+// Manager issues access tokens and manages server-side refresh sessions.
+type Manager struct {
+	db         *gorm.DB
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+func NewManager(db *gorm.DB, cfg config.Config) *Manager {
+	return &Manager{
+		db:         db,
+		secret:     []byte(cfg.JWTSecret),
+		accessTTL:  cfg.AccessTokenTTL,
+		refreshTTL: cfg.RefreshTokenTTL,
+	}
+}
+
+// Start creates a new session for user and returns its first token pair.
+func (m *Manager) Start(user models.User, userAgent, ip string) (Pair, error) {
+	refresh, hash, err := newRefreshToken()
+	if err != nil {
+		return Pair{}, err
+	}
+	now := time.Now()
+	s := models.Session{
+		UserID:       user.ID,
+		RefreshHash:  hash,
+		TokenVersion: user.TokenVersion,
+		UserAgent:    textutil.Truncate(userAgent, 255),
+		IP:           ip,
+		LastUsedAt:   now,
+		ExpiresAt:    now.Add(m.refreshTTL),
+	}
+	if err := m.db.Create(&s).Error; err != nil {
+		return Pair{}, fmt.Errorf("create session: %w", err)
+	}
+	return m.pair(user, s.ID, refresh)
+}
+
+// Refresh rotates refreshToken and returns a new pair. Presenting a token
+// that was already rotated revokes the session, since it means the token
+// leaked.
+func (m *Manager) Refresh(refreshToken, userAgent, ip string) (Pair, error) {
+	hash := hashToken(refreshToken)
+	var s models.Session
+	err := m.db.Where("refresh_hash = ?", hash).First(&s).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err := m.db.Where("prev_refresh_hash = ?", hash).First(&s).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Pair{}, ErrInvalidToken
+		}
+		if err != nil {
+			return Pair{}, err
+		}
+		if err := m.revoke(m.db.Where("id = ?", s.ID)); err != nil {
+			return Pair{}, fmt.Errorf("revoke reused session: %w", err)
+		}
+		return Pair{}, ErrReused
+	}
+	if err != nil {
+		return Pair{}, err
+	}
+	now := time.Now()
+	if s.RevokedAt != nil {
+		return Pair{}, ErrRevoked
+	}
+	if now.After(s.ExpiresAt) {
+		return Pair{}, ErrInvalidToken
+	}
+
+	var user models.User
+	if err := m.db.First(&user, "id = ?", s.UserID).Error; err != nil {
+		return Pair{}, ErrInvalidToken
+	}
+	if user.TokenVersion != s.TokenVersion {
+		return Pair{}, ErrRevoked
+	}
+	if user.IsSuspended(now) {
+		return Pair{}, ErrSuspended
+	}
+
+	refresh, newHash, err := newRefreshToken()
+	if err != nil {
+		return Pair{}, err
+	}
+	// Only rotate if nobody else rotated this token concurrently.
+	res := m.db.Model(&models.Session{}).
+		Where("id = ? AND refresh_hash = ?", s.ID, hash).
+		Updates(map[string]interface{}{
+			"refresh_hash":      newHash,
+			"prev_refresh_hash": hash,
+			"last_used_at":      now,
+			"expires_at":        now.Add(m.refreshTTL),
+			"user_agent":        textutil.Truncate(userAgent, 255),
+			"ip":                ip,
+		})
+	if res.Error != nil {
+		return Pair{}, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return Pair{}, ErrInvalidToken
+	}
+	return m.pair(user, s.ID, refresh)
+}
+
+// Logout revokes the session that owns refreshToken.
+func (m *Manager) Logout(refreshToken string) error {
+	return m.revoke(m.db.Where("refresh_hash = ?", hashToken(refreshToken)))
+}
+
+// Revoke revokes one session of userID. It returns ErrNotFound if userID
+// has no such session.
+func (m *Manager) Revoke(userID, sessionID uuid.UUID) error {
+	var count int64
+	if err := m.db.Model(&models.Session{}).Where("id = ? AND user_id = ?", sessionID, userID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return m.revoke(m.db.Where("id = ? AND user_id = ?", sessionID, userID))
+}
+
+// RevokeAll revokes every session of userID except keep (uuid.Nil keeps none).
+func (m *Manager) RevokeAll(userID, keep uuid.UUID) error {
+	return RevokeOthers(m.db, userID, keep)
+}
+
+// RevokeOthers is RevokeAll inside tx.
+func RevokeOthers(tx *gorm.DB, userID, keep uuid.UUID) error {
+	return tx.Model(&models.Session{}).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, keep).
+		Update("revoked_at", time.Now()).Error
+}
+
+// Invalidate bumps the user's token version inside tx, which kills every
+// access token and refresh session issued so far. Use it after password or
+// role changes.
+func Invalidate(tx *gorm.DB, userID uuid.UUID) error {
+	if err := tx.Model(&models.User{}).Where("id = ?", userID).
+		UpdateColumn("token_version", gorm.Expr("token_version + 1")).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// Parse verifies an access token and checks that its session is still live
+// and issued for the user's current token version.
+func (m *Manager) Parse(tokenStr string) (Claims, error) {
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return m.secret, nil
+	})
+	if err != nil || !token.Valid {
+		return Claims{}, ErrInvalidToken
+	}
+	mc, ok := token.Claims.(jwt.MapClaims)
+	if !ok || mc["typ"] != "access" {
+		return Claims{}, ErrInvalidToken
+	}
+	sub, _ := mc["sub"].(string)
+	sid, _ := mc["sid"].(string)
+	ver, _ := mc["ver"].(float64)
+	uid, err := uuid.Parse(sub)
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	sessionID, err := uuid.Parse(sid)
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	var row struct {
+		Role           string
+		TokenVersion   int
+		RevokedAt      *time.Time
+		SuspendedUntil *time.Time
+	}
+	err = m.db.Table("sessions").
+		Select("users.role, users.token_version, users.suspended_until, sessions.revoked_at").
+		Joins("JOIN users ON users.id = sessions.user_id").
+		Where("sessions.id = ? AND sessions.user_id = ?", sessionID, uid).
+		Take(&row).Error
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	if row.RevokedAt != nil || row.TokenVersion != int(ver) {
+		return Claims{}, ErrRevoked
+	}
+	if row.SuspendedUntil != nil && row.SuspendedUntil.After(time.Now()) {
+		return Claims{}, ErrSuspended
+	}
+	// Role comes from the database so demotions apply immediately.
+	return Claims{UserID: uid, SessionID: sessionID, Role: row.Role, Version: row.TokenVersion}, nil
+}
+
+// challengeTTL bounds the time between password and 2FA code.
+const challengeTTL = 5 * time.Minute
+
+// Challenge returns a short-lived token proving that user passed the first
+// login factor. It cannot be used as an access token.
+func (m *Manager) Challenge(user models.User) (string, error) {
+	now := time.Now()
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": user.ID.String(),
+		"ver": user.TokenVersion,
+		"typ": "mfa",
+		"iat": now.Unix(),
+		"exp": now.Add(challengeTTL).Unix(),
+	}).SignedString(m.secret)
+}
+
+// ParseChallenge verifies a token from Challenge and returns its user and
+// token version.
+func (m *Manager) ParseChallenge(tokenStr string) (uuid.UUID, int, error) {
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return m.secret, nil
+	})
+	if err != nil || !token.Valid {
+		return uuid.Nil, 0, ErrInvalidToken
+	}
+	mc, ok := token.Claims.(jwt.MapClaims)
+	if !ok || mc["typ"] != "mfa" {
+		return uuid.Nil, 0, ErrInvalidToken
+	}
+	sub, _ := mc["sub"].(string)
+	ver, _ := mc["ver"].(float64)
+	uid, err := uuid.Parse(sub)
+	if err != nil {
+		return uuid.Nil, 0, ErrInvalidToken
+	}
+	return uid, int(ver), nil
+}
+
+func (m *Manager) pair(user models.User, sessionID uuid.UUID, refresh string) (Pair, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"sub":  user.ID.String(),
+		"sid":  sessionID.String(),
+		"role": user.Role,
+		"ver":  user.TokenVersion,
+		"typ":  "access",
+		"iat":  now.Unix(),
+		"exp":  now.Add(m.accessTTL).Unix(),
+	}
+	access, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secret)
+	if err != nil {
+		return Pair{}, err
+	}
+	return Pair{AccessToken: access, RefreshToken: refresh, ExpiresIn: int(m.accessTTL.Seconds())}, nil
+}
+
+func (m *Manager) revoke(scope *gorm.DB) error {
+	return scope.Model(&models.Session{}).Where("revoked_at IS NULL").Update("revoked_at", time.Now()).Error
+}
+
+func newRefreshToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,18 @@
+// Package textutil holds small string helpers shared across packages.
+package textutil
+
+// Truncate shortens s to at most n runes, matching how PostgreSQL counts the
+// length of varchar columns. It never splits a multi-byte character.
+func Truncate(s string, n int) string {
+	if len(s) <= n {
+		return s // n bytes can hold at most n runes
+	}
+	i := 0
+	for pos := range s {
+		if i == n {
+			return s[:pos]
+		}
+		i++
+	}
+	return s
+}
@@ -0,0 +1,24 @@
+package textutil
+
+import "testing"
+
+func TestTruncate(t *testing.T) {
+	cases := []struct {
+		in   string
+		n    int
+		want string
+	}{
+		{"hello", 10, "hello"},
+		{"hello", 5, "hello"},
+		{"hello", 3, "hel"},
+		{"交换机配置", 2, "交换"},
+		{"交换机配置", 5, "交换机配置"},
+		{"a交b", 2, "a交"},
+		{"abc", 0, ""},
+	}
+	for _, tc := range cases {
+		if got := Truncate(tc.in, tc.n); got != tc.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tc.in, tc.n, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package watch
+
+import (
+	"github.com/A-Words/ne-resource-community/server/internal/events"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Watch sources. A favorite watch goes away with the favorite; a manual one
+// stays until the user unwatches.
+const (
+	SourceManual   = "manual"
+	SourceFavorite = "favorite"
+)
+
+// Add makes userID watch resourceID. Watching manually upgrades an existing
+// favorite watch so unfavoriting keeps it. It reports whether the user was
+// not watching before.
+func Add(tx *gorm.DB, userID, resourceID uuid.UUID, source string) (bool, error) {
+	w := models.Watch{UserID: userID, ResourceID: resourceID, Source: source}
+	res := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "resource_id"}},
+		DoNothing: true,
+	}).Create(&w)
+	if res.Error != nil || res.RowsAffected > 0 {
+		return res.RowsAffected > 0, res.Error
+	}
+	if source == SourceManual {
+		return false, tx.Model(&models.Watch{}).
+			Where("user_id = ? AND resource_id = ?", userID, resourceID).
+			Update("source", SourceManual).Error
+	}
+	return false, nil
+}
+
+// RemoveFavorite drops the watch a favorite created, leaving manual ones.
+func RemoveFavorite(tx *gorm.DB, userID, resourceID uuid.UUID) error {
+	return tx.Where("user_id = ? AND resource_id = ? AND source = ?", userID, resourceID, SourceFavorite).
+		Delete(&models.Watch{}).Error
+}
+
+// Watchers returns the users watching resourceID, except exclude.
+func Watchers(tx *gorm.DB, resourceID, exclude uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := tx.Model(&models.Watch{}).
+		Where("resource_id = ? AND user_id <> ?", resourceID, exclude).
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+// Notify publishes e to the watchers of resourceID other than exclude,
+// usually the uploader who gets their own notice.
+func Notify(tx *gorm.DB, resourceID, exclude uuid.UUID, e events.Event) error {
+	ids, err := Watchers(tx, resourceID, exclude)
+	if err != nil {
+		return err
+	}
+	return publish(tx, ids, e)
+}
+
+// NotifyChain is Notify for everyone watching any version in the chain of
+// resourceID, so watchers of an older version or a sibling branch hear about
+// a new one too.
+func NotifyChain(tx *gorm.DB, resourceID, exclude uuid.UUID, e events.Event) error {
+	var ids []uuid.UUID
+	if err := tx.Model(&models.Watch{}).Distinct("user_id").
+		Where("resource_id IN (?) AND user_id <> ?", gorm.Expr(models.VersionChain, resourceID), exclude).
+		Pluck("user_id", &ids).Error; err != nil {
+		return err
+	}
+	return publish(tx, ids, e)
+}
+
+func publish(tx *gorm.DB, ids []uuid.UUID, e events.Event) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	e.Recipients = ids
+	return events.Publish(tx, e)
+}
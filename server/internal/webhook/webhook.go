@@ -0,0 +1,318 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/A-Words/ne-resource-community/server/internal/textutil"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Event types webhooks can subscribe to.
+const (
+	EventResourceApproved = "resource.approved"
+	EventResourceRejected = "resource.rejected"
+	EventReportCreated    = "report.created"
+	EventRequestCreated   = "request.created"
+	EventReviewCreated    = "review.created"
+)
+
+// Events lists every event type webhooks can subscribe to.
+var Events = []string{
+	EventResourceApproved,
+	EventResourceRejected,
+	EventReportCreated,
+	EventRequestCreated,
+	EventReviewCreated,
+}
+
+// Delivery statuses.
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed" // gave up after the last attempt
+)
+
+// Request headers sent with every delivery.
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature" // sha256=<hex HMAC of "<timestamp>.<body>">
+)
+
+const (
+	batchSize    = 50
+	claimLease   = 5 * time.Minute // minimum; a claimed delivery is retried if the sender dies
+	backoffBase  = time.Minute
+	backoffMax   = 6 * time.Hour
+	responseKeep = 1000
+)
+
+// Valid reports whether event is a known event type.
+func Valid(event string) bool {
+	for _, e := range Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// NewSecret returns a random signing secret.
+func NewSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Sign computes the signature header value for body sent at timestamp.
+// Receivers recompute it with their copy of the secret.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// envelope is the JSON body of a delivery. ID identifies the event and stays
+// the same across retries and redeliveries so receivers can deduplicate.
+type envelope struct {
+	ID        uuid.UUID   `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Data      interface{} `json:"data"`
+}
+
+// Enqueue queues event for every active webhook subscribed to it. Pass the
+// transaction that performs the change so nothing is sent if it rolls back.
+func Enqueue(tx *gorm.DB, event string, data interface{}) error {
+	var hooks []models.Webhook
+	if err := tx.Where("active = ?", true).Find(&hooks).Error; err != nil {
+		return fmt.Errorf("load webhooks: %w", err)
+	}
+	var deliveries []models.WebhookDelivery
+	var payload []byte
+	now := time.Now()
+	for _, h := range hooks {
+		if !subscribed(h, event) {
+			continue
+		}
+		if payload == nil {
+			var err error
+			payload, err = json.Marshal(envelope{ID: uuid.New(), Event: event, CreatedAt: now, Data: data})
+			if err != nil {
+				return fmt.Errorf("marshal webhook payload: %w", err)
+			}
+		}
+		deliveries = append(deliveries, models.WebhookDelivery{
+			WebhookID:     h.ID,
+			Event:         event,
+			Payload:       string(payload),
+			Status:        StatusPending,
+			NextAttemptAt: &now,
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return tx.Create(&deliveries).Error
+}
+
+func subscribed(h models.Webhook, event string) bool {
+	for _, e := range h.EventList() {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Redeliver queues a fresh copy of a logged delivery with the same payload.
+func Redeliver(tx *gorm.DB, d models.WebhookDelivery) (models.WebhookDelivery, error) {
+	now := time.Now()
+	again := models.WebhookDelivery{
+		WebhookID:     d.WebhookID,
+		Event:         d.Event,
+		Payload:       d.Payload,
+		Status:        StatusPending,
+		NextAttemptAt: &now,
+		RedeliveryOf:  &d.ID,
+	}
+	return again, tx.Create(&again).Error
+}
+
+// Backoff returns the wait after the given number of failed attempts.
+func Backoff(attempts int) time.Duration {
+	d := backoffBase
+	for i := 1; i < attempts && d < backoffMax; i++ {
+		d *= 2
+	}
+	if d > backoffMax {
+		d = backoffMax
+	}
+	return d
+}
+
+// Dispatcher returns a job that sends due deliveries. Failed attempts are
+// retried with exponential backoff until maxAttempts is reached. Deliveries
+// are claimed with SKIP LOCKED so several replicas can run it.
+func Dispatcher(db *gorm.DB, client *http.Client, maxAttempts int) func(context.Context) error {
+	lease := Lease(client.Timeout)
+	return func(ctx context.Context) error {
+		db := db.WithContext(ctx)
+		for {
+			due, err := claim(db, lease)
+			if err != nil {
+				return fmt.Errorf("claim webhook deliveries: %w", err)
+			}
+			for _, d := range due {
+				if ctx.Err() != nil {
+					return nil
+				}
+				if err := attempt(ctx, db, client, d, maxAttempts); err != nil {
+					return fmt.Errorf("record webhook delivery %s: %w", d.ID, err)
+				}
+			}
+			if len(due) < batchSize {
+				return nil
+			}
+		}
+	}
+}
+
+// Lease returns how long a claimed batch is reserved when each delivery may
+// take up to timeout, so no delivery of a slow batch is sent twice.
+func Lease(timeout time.Duration) time.Duration {
+	if lease := time.Duration(batchSize)*timeout + time.Minute; lease > claimLease {
+		return lease
+	}
+	return claimLease
+}
+
+// claim picks due deliveries and pushes their next attempt past the lease so
+// other replicas leave them alone while they are being sent.
+func claim(db *gorm.DB, lease time.Duration) ([]models.WebhookDelivery, error) {
+	var due []models.WebhookDelivery
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", StatusPending, now).
+			Order("next_attempt_at").Limit(batchSize).
+			Find(&due).Error; err != nil {
+			return err
+		}
+		if len(due) == 0 {
+			return nil
+		}
+		ids := make([]uuid.UUID, len(due))
+		for i, d := range due {
+			ids[i] = d.ID
+		}
+		return tx.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	return due, err
+}
+
+// Pruner returns a job that deletes finished deliveries older than
+// retention. A retention of zero keeps the log forever.
+func Pruner(db *gorm.DB, retention time.Duration) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if retention <= 0 {
+			return nil
+		}
+		res := db.WithContext(ctx).
+			Where("status IN ? AND created_at < ?", []string{StatusDelivered, StatusFailed}, time.Now().Add(-retention)).
+			Delete(&models.WebhookDelivery{})
+		if res.Error != nil {
+			return fmt.Errorf("prune webhook deliveries: %w", res.Error)
+		}
+		if res.RowsAffected > 0 {
+			log.Printf("webhook: pruned %d deliveries", res.RowsAffected)
+		}
+		return nil
+	}
+}
+
+func attempt(ctx context.Context, db *gorm.DB, client *http.Client, d models.WebhookDelivery, maxAttempts int) error {
+	var hook models.Webhook
+	err := db.First(&hook, "id = ?", d.WebhookID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	now := time.Now()
+	updates := map[string]interface{}{"last_attempt_at": now}
+	if err != nil || !hook.Active {
+		updates["status"] = StatusFailed
+		updates["error"] = "webhook was disabled or deleted"
+		return db.Model(&d).Updates(updates).Error
+	}
+
+	code, body, sendErr := send(ctx, client, hook, d)
+	d.Attempts++
+	updates["attempts"] = d.Attempts
+	updates["response_code"] = code
+	updates["response_body"] = body
+	switch {
+	case sendErr == nil:
+		updates["status"] = StatusDelivered
+		updates["delivered_at"] = now
+		updates["error"] = ""
+	case d.Attempts >= maxAttempts:
+		updates["status"] = StatusFailed
+		updates["error"] = textutil.Truncate(sendErr.Error(), 500)
+		log.Printf("webhook %s: giving up on delivery %s after %d attempts: %v", hook.ID, d.ID, d.Attempts, sendErr)
+	default:
+		updates["next_attempt_at"] = now.Add(Backoff(d.Attempts))
+		updates["error"] = textutil.Truncate(sendErr.Error(), 500)
+	}
+	return db.Model(&d).Updates(updates).Error
+}
+
+// send posts the payload and treats any 2xx response as delivered.
+func send(ctx context.Context, client *http.Client, hook models.Webhook, d models.WebhookDelivery) (int, string, error) {
+	body := []byte(d.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ne-resource-community-webhook/1")
+	req.Header.Set(HeaderEvent, d.Event)
+	req.Header.Set(HeaderDelivery, d.ID.String())
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(hook.Secret, ts, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, responseKeep))
+	snippet := strings.ToValidUTF8(string(raw), "")
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, snippet, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, snippet, nil
+}
@@ -0,0 +1,117 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/A-Words/ne-resource-community/server/internal/database/dbtest"
+	"github.com/A-Words/ne-resource-community/server/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"event":"review.created"}`)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("1700000000." + string(body)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if got := Sign("secret", 1700000000, body); got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+	if Sign("secret", 1700000001, body) == want {
+		t.Error("signature does not cover the timestamp")
+	}
+	if Sign("other", 1700000000, body) == want {
+		t.Error("signature does not depend on the secret")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	cases := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, time.Minute},
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{5, 16 * time.Minute},
+		{9, 256 * time.Minute},
+		{10, backoffMax},
+		{100, backoffMax},
+	}
+	for _, tc := range cases {
+		if got := Backoff(tc.attempts); got != tc.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tc.attempts, got, tc.want)
+		}
+	}
+}
+
+func TestLeaseCoversBatch(t *testing.T) {
+	if got := Lease(time.Second); got != claimLease {
+		t.Errorf("Lease(1s) = %v, want %v", got, claimLease)
+	}
+	for _, timeout := range []time.Duration{10 * time.Second, time.Minute} {
+		if got := Lease(timeout); got < batchSize*timeout {
+			t.Errorf("Lease(%v) = %v, shorter than a batch of timeouts", timeout, got)
+		}
+	}
+}
+
+func TestSendSignsPayload(t *testing.T) {
+	var got http.Header
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusTeapot)
+		io.WriteString(w, "nope")
+	}))
+	defer srv.Close()
+
+	hook := models.Webhook{URL: srv.URL, Secret: "s3cret"}
+	d := models.WebhookDelivery{ID: uuid.New(), Event: EventReviewCreated, Payload: `{"id":"x"}`}
+	code, body, err := send(context.Background(), srv.Client(), hook, d)
+	if err == nil || code != http.StatusTeapot || body != "nope" {
+		t.Fatalf("send = %d, %q, %v", code, body, err)
+	}
+	ts, err := strconv.ParseInt(got.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		t.Fatalf("timestamp header: %v", err)
+	}
+	if sig := got.Get(HeaderSignature); sig != Sign(hook.Secret, ts, gotBody) {
+		t.Errorf("signature %s does not verify", sig)
+	}
+	if got.Get(HeaderEvent) != d.Event || got.Get(HeaderDelivery) != d.ID.String() {
+		t.Errorf("headers = %v", got)
+	}
+}
+
+func TestPrunerKeepsPendingAndRecent(t *testing.T) {
+	db := dbtest.Tx(t)
+	hook := uuid.New()
+	old := time.Now().Add(-48 * time.Hour)
+	rows := []models.WebhookDelivery{
+		{WebhookID: hook, Status: StatusDelivered, CreatedAt: old},
+		{WebhookID: hook, Status: StatusFailed, CreatedAt: old},
+		{WebhookID: hook, Status: StatusPending, CreatedAt: old},
+		{WebhookID: hook, Status: StatusDelivered, CreatedAt: time.Now()},
+	}
+	if err := db.Create(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := Pruner(db, 24*time.Hour)(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var left []models.WebhookDelivery
+	db.Where("webhook_id = ?", hook).Order("created_at").Find(&left)
+	if len(left) != 2 || left[0].Status != StatusPending || left[1].Status != StatusDelivered {
+		t.Errorf("left = %+v", left)
+	}
+}
@@ -17,7 +17,6 @@ export interface Resource {
   protocol: string
   scenario: string
   tags: string
-  filePath: string
   fileName: string
   contentType: string
   downloadCount: number